@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Date models a date (day in year) tag value.
@@ -24,9 +25,25 @@ func NewDate(year int, month int, day int) Date {
 	}
 }
 
-// NewDateFromIso creates a Date object from a string in the format: "YYYY-MM-DD"
+// NewDateChecked creates a new Date object, returning an error if the values are not a valid calendar date.
+func NewDateChecked(year int, month int, day int) (Date, error) {
+	date := NewDate(year, month, day)
+	return date, date.Validate()
+}
+
+// NewDateFromGo creates a Date object from the calendar date of the Go time, in the time's location.
+func NewDateFromGo(goTime time.Time) Date {
+	year, month, day := goTime.Date()
+	return NewDate(year, int(month), day)
+}
+
+// NewDateFromIso creates a Date object from a string in the format: "YYYY-MM-DD". An error is returned if the
+// string is malformed or is not a valid calendar date.
 func NewDateFromIso(str string) (Date, error) {
 	parts := strings.Split(str, "-")
+	if len(parts) != 3 {
+		return NewDate(0, 0, 0), errors.New("invalid date format: " + str)
+	}
 
 	year, yearErr := strconv.Atoi(parts[0])
 	if yearErr != nil {
@@ -41,7 +58,7 @@ func NewDateFromIso(str string) (Date, error) {
 		return NewDate(0, 0, 0), dayErr
 	}
 
-	return NewDate(year, month, day), nil
+	return NewDateChecked(year, month, day)
 }
 
 // Year returns the years of the object.
@@ -59,6 +76,78 @@ func (date Date) Day() int {
 	return date.day
 }
 
+// Validate returns an error if the date is not a valid calendar date, like 2023-02-30.
+func (date Date) Validate() error {
+	if date.month < 1 || date.month > 12 {
+		return errors.New("invalid month: " + date.toIso())
+	}
+	if date.day < 1 || date.day > daysInMonth(date.year, date.month) {
+		return errors.New("invalid day of month: " + date.toIso())
+	}
+	return nil
+}
+
+// IsValid returns true if the date is a valid calendar date.
+func (date Date) IsValid() bool {
+	return date.Validate() == nil
+}
+
+// PlusDays returns a new Date that is the given number of days later. Negative values move the date earlier.
+func (date Date) PlusDays(days int) Date {
+	return NewDateFromGo(date.toGo(time.UTC).AddDate(0, 0, days))
+}
+
+// MinusDays returns a new Date that is the given number of days earlier.
+func (date Date) MinusDays(days int) Date {
+	return date.PlusDays(-days)
+}
+
+// Weekday returns the day of the week of the date.
+func (date Date) Weekday() time.Weekday {
+	return date.toGo(time.UTC).Weekday()
+}
+
+// DayOfYear returns the day of the year, in the range [1,365] or [1,366] for leap years.
+func (date Date) DayOfYear() int {
+	return date.toGo(time.UTC).YearDay()
+}
+
+// IsLeapYear returns true if the date's year is a leap year.
+func (date Date) IsLeapYear() bool {
+	return daysInMonth(date.year, 2) == 29
+}
+
+// FirstOfMonth returns the first day of the date's month.
+func (date Date) FirstOfMonth() Date {
+	return NewDate(date.year, date.month, 1)
+}
+
+// LastOfMonth returns the last day of the date's month.
+func (date Date) LastOfMonth() Date {
+	return NewDate(date.year, date.month, daysInMonth(date.year, date.month))
+}
+
+// Before returns true if the date is before the other date.
+func (date Date) Before(other Date) bool {
+	return date.compare(other) < 0
+}
+
+// After returns true if the date is after the other date.
+func (date Date) After(other Date) bool {
+	return date.compare(other) > 0
+}
+
+// Equal returns true if the dates are the same day.
+func (date Date) Equal(other Date) bool {
+	return date.equals(other)
+}
+
+// Midnight returns the first instant of the date in the given timezone. If midnight doesn't exist because of a
+// daylight saving transition, the first valid time of the day is returned.
+func (date Date) Midnight(tz Tz) DateTime {
+	return DateTime{time: midnight(date.year, time.Month(date.month), date.day, tz.Location())}
+}
+
 // ToZinc representes the object as: "YYYY-MM-DD"
 func (date Date) ToZinc() string {
 	return date.toIso()
@@ -114,6 +203,41 @@ func (date Date) toIso() string {
 	return result
 }
 
+func (date Date) toGo(loc *time.Location) time.Time {
+	return time.Date(date.year, time.Month(date.month), date.day, 0, 0, 0, 0, loc)
+}
+
+func (date Date) compare(other Date) int {
+	if date.year != other.year {
+		return date.year - other.year
+	}
+	if date.month != other.month {
+		return date.month - other.month
+	}
+	return date.day - other.day
+}
+
+func daysInMonth(year int, month int) int {
+	// Day 0 of the next month normalizes to the last day of this month
+	return time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+// midnight returns the first instant of the day in the location. If a daylight saving transition skips midnight,
+// the instant at the end of the gap is returned.
+func midnight(year int, month time.Month, day int, loc *time.Location) time.Time {
+	goTime := time.Date(year, month, day, 0, 0, 0, 0, loc)
+	// Noon normalizes out-of-range days and is never inside a transition
+	noon := time.Date(year, month, day, 12, 0, 0, 0, loc)
+	if goTime.Day() == noon.Day() {
+		return goTime
+	}
+	// Midnight fell in a gap, so it resolved to the previous day. Midnight with the offset from before the
+	// transition is the instant the gap ends.
+	_, offset := goTime.Zone()
+	year, month, day = noon.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.FixedZone("", offset)).In(loc)
+}
+
 func (date Date) equals(otherDate Date) bool {
 	return date.year == otherDate.year &&
 		date.month == otherDate.month &&
@@ -130,3 +130,51 @@ func valTest_ToAxon(val DateTime, expected string, t *testing.T) {
 		t.Error(actual + " != " + expected)
 	}
 }
+
+func TestDateTime_Millis(t *testing.T) {
+	dateTime, _ := NewDateTimeRaw(2020, 8, 17, 23, 7, 10, 957, "UTC")
+	assert.Equal(t, 957*int(time.Millisecond), dateTime.time.Nanosecond())
+	assert.Equal(t, NewTime(23, 7, 10, 957), dateTime.Time())
+}
+
+func TestDateTime_Plus(t *testing.T) {
+	// DST began in New York at 2021-03-14T02:00
+	dateTime, _ := NewDateTimeFromString("2021-03-13T12:00:00-05:00 New_York")
+	assert.Equal(t, "2021-03-14T13:00:00-04:00 New_York", dateTime.Plus(24*time.Hour).ToZinc())
+	assert.Equal(t, "2021-03-14T12:00:00-04:00 New_York", dateTime.PlusDays(1).ToZinc())
+	assert.Equal(t, "2021-03-13T11:00:00-05:00 New_York", dateTime.Minus(time.Hour).ToZinc())
+	assert.Equal(t, "2021-03-12T12:00:00-05:00 New_York", dateTime.MinusDays(1).ToZinc())
+	assert.Equal(t, 23*time.Hour, dateTime.PlusDays(1).Sub(dateTime))
+}
+
+func TestDateTime_Calendar(t *testing.T) {
+	// 2020-08-17 in New York is the 18th in UTC
+	dateTime, _ := NewDateTimeFromString("2020-08-17T23:07:10-04:00 New_York")
+	assert.Equal(t, time.Monday, dateTime.Weekday())
+	assert.Equal(t, 230, dateTime.DayOfYear())
+	assert.Equal(t, "2020-08-17T00:00:00-04:00 New_York", dateTime.StartOfDay().ToZinc())
+	assert.Equal(t, "2020-08-17T23:59:59.999-04:00 New_York", dateTime.EndOfDay().ToZinc())
+	assert.Equal(t, "2020-08-16T00:00:00-04:00 New_York", dateTime.StartOfWeek(time.Sunday).ToZinc())
+	assert.Equal(t, "2020-08-17T00:00:00-04:00 New_York", dateTime.StartOfWeek(time.Monday).ToZinc())
+	assert.Equal(t, "2020-08-23T23:59:59.999-04:00 New_York", dateTime.EndOfWeek(time.Monday).ToZinc())
+	assert.Equal(t, "2020-08-01T00:00:00-04:00 New_York", dateTime.StartOfMonth().ToZinc())
+	assert.Equal(t, "2020-08-31T23:59:59.999-04:00 New_York", dateTime.EndOfMonth().ToZinc())
+	assert.Equal(t, "2020-01-01T00:00:00-05:00 New_York", dateTime.StartOfYear().ToZinc())
+	assert.Equal(t, "2020-12-31T23:59:59.999-05:00 New_York", dateTime.EndOfYear().ToZinc())
+}
+
+func TestDateTime_StartOfDay_dstGap(t *testing.T) {
+	// Sao Paulo skipped from 00:00 to 01:00 when DST began in 2018
+	dateTime, _ := NewDateTimeFromString("2018-11-04T12:00:00-02:00 Sao_Paulo")
+	assert.Equal(t, "2018-11-04T01:00:00-02:00 Sao_Paulo", dateTime.StartOfDay().ToZinc())
+	assert.Equal(t, "2018-11-03T23:59:59.999-03:00 Sao_Paulo", dateTime.MinusDays(1).EndOfDay().ToZinc())
+}
+
+func TestDateTime_Compare(t *testing.T) {
+	utc, _ := NewDateTimeFromString("2020-08-18T03:07:10Z UTC")
+	newYork, _ := NewDateTimeFromString("2020-08-17T23:07:10-04:00 New_York")
+	assert.True(t, utc.Equal(newYork))
+	assert.False(t, utc.Before(newYork))
+	assert.True(t, utc.Before(newYork.Plus(time.Second)))
+	assert.True(t, utc.After(newYork.Minus(time.Second)))
+}
@@ -3,6 +3,7 @@ package haystack
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -55,3 +56,56 @@ func TestDate_UnmarshalJSON(t *testing.T) {
 func TestDate_MarshalHayson(t *testing.T) {
 	valTest_MarshalHayson(NewDate(2020, 8, 17), "{\"_kind\":\"date\",\"val\":\"2020-08-17\"}", t)
 }
+
+func TestDate_Validate(t *testing.T) {
+	assert.Nil(t, NewDate(2020, 2, 29).Validate())
+	assert.NotNil(t, NewDate(2023, 2, 29).Validate())
+	assert.NotNil(t, NewDate(2023, 2, 30).Validate())
+	assert.NotNil(t, NewDate(2023, 13, 1).Validate())
+	assert.NotNil(t, NewDate(2023, 4, 0).Validate())
+	assert.False(t, NewDate(0, 0, 0).IsValid())
+
+	_, err := NewDateChecked(2023, 2, 30)
+	assert.NotNil(t, err)
+	_, err = NewDateFromIso("2023-02-30")
+	assert.NotNil(t, err)
+	_, err = NewDateFromIso("2023-02")
+	assert.NotNil(t, err)
+}
+
+func TestDate_PlusDays(t *testing.T) {
+	assert.Equal(t, NewDate(2021, 1, 1), NewDate(2020, 12, 31).PlusDays(1))
+	assert.Equal(t, NewDate(2020, 2, 29), NewDate(2020, 3, 1).MinusDays(1))
+	assert.Equal(t, NewDate(2020, 3, 31), NewDate(2020, 3, 1).PlusDays(30))
+}
+
+func TestDate_Calendar(t *testing.T) {
+	date := NewDate(2020, 8, 17)
+	assert.Equal(t, time.Monday, date.Weekday())
+	assert.Equal(t, 230, date.DayOfYear())
+	assert.True(t, date.IsLeapYear())
+	assert.False(t, NewDate(1900, 1, 1).IsLeapYear())
+	assert.Equal(t, NewDate(2020, 8, 1), date.FirstOfMonth())
+	assert.Equal(t, NewDate(2020, 8, 31), date.LastOfMonth())
+	assert.Equal(t, NewDate(2021, 2, 28), NewDate(2021, 2, 3).LastOfMonth())
+}
+
+func TestDate_Compare(t *testing.T) {
+	date := NewDate(2020, 8, 17)
+	assert.True(t, date.Before(NewDate(2020, 8, 18)))
+	assert.True(t, date.Before(NewDate(2021, 1, 1)))
+	assert.False(t, date.Before(date))
+	assert.True(t, date.After(NewDate(2020, 7, 31)))
+	assert.True(t, date.Equal(NewDate(2020, 8, 17)))
+}
+
+func TestDate_Midnight(t *testing.T) {
+	newYork, _ := NewTz("New_York")
+	midnight := NewDate(2020, 8, 17).Midnight(newYork)
+	assert.Equal(t, "2020-08-17T00:00:00-04:00 New_York", midnight.ToZinc())
+
+	// Sao Paulo skipped from 00:00 to 01:00 when DST began in 2018
+	saoPaulo, _ := NewTz("Sao_Paulo")
+	midnight = NewDate(2018, 11, 4).Midnight(saoPaulo)
+	assert.Equal(t, "2018-11-04T01:00:00-02:00 Sao_Paulo", midnight.ToZinc())
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Dict is a map of name/Val pairs.
@@ -31,6 +32,133 @@ func (dict Dict) Get(name string) Val {
 	return val
 }
 
+// Has returns true if the Dict contains a non-null value for the name.
+func (dict Dict) Has(name string) bool {
+	val, ok := dict.items[name]
+	if !ok || val == nil {
+		return false
+	}
+	_, isNull := val.(Null)
+	return !isNull
+}
+
+// Missing returns true if the Dict does not contain a non-null value for the name.
+func (dict Dict) Missing(name string) bool {
+	return !dict.Has(name)
+}
+
+// GetNumber returns the Number of the given name. An error is returned if the tag is missing or not a Number.
+func (dict Dict) GetNumber(name string) (Number, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return Number{}, err
+	}
+	number, ok := val.(Number)
+	if !ok {
+		return Number{}, NewTagTypeError(name, "Number", val)
+	}
+	return number, nil
+}
+
+// GetDuration returns the Number of the given name as a Go duration, like the `hisInterval` tag. An error is returned
+// if the tag is missing, not a Number, or does not have an exact time unit. See Number.ToDuration.
+func (dict Dict) GetDuration(name string) (time.Duration, error) {
+	number, err := dict.GetNumber(name)
+	if err != nil {
+		return 0, err
+	}
+	return number.ToDuration()
+}
+
+// GetRef returns the Ref of the given name. An error is returned if the tag is missing or not a Ref.
+func (dict Dict) GetRef(name string) (Ref, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return Ref{}, err
+	}
+	ref, ok := val.(Ref)
+	if !ok {
+		return Ref{}, NewTagTypeError(name, "Ref", val)
+	}
+	return ref, nil
+}
+
+// GetStr returns the Str of the given name. An error is returned if the tag is missing or not a Str.
+func (dict Dict) GetStr(name string) (Str, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return Str{}, err
+	}
+	str, ok := val.(Str)
+	if !ok {
+		return Str{}, NewTagTypeError(name, "Str", val)
+	}
+	return str, nil
+}
+
+// GetBool returns the Bool of the given name. An error is returned if the tag is missing or not a Bool.
+func (dict Dict) GetBool(name string) (Bool, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return Bool{}, err
+	}
+	switch val := val.(type) {
+	case Bool:
+		return val, nil
+	case *Bool:
+		return *val, nil
+	default:
+		return Bool{}, NewTagTypeError(name, "Bool", val)
+	}
+}
+
+// GetDateTime returns the DateTime of the given name. An error is returned if the tag is missing or not a DateTime.
+func (dict Dict) GetDateTime(name string) (DateTime, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return DateTime{}, err
+	}
+	dateTime, ok := val.(DateTime)
+	if !ok {
+		return DateTime{}, NewTagTypeError(name, "DateTime", val)
+	}
+	return dateTime, nil
+}
+
+// GetList returns the List of the given name. An error is returned if the tag is missing or not a List.
+func (dict Dict) GetList(name string) (List, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return List{}, err
+	}
+	list, ok := val.(List)
+	if !ok {
+		return List{}, NewTagTypeError(name, "List", val)
+	}
+	return list, nil
+}
+
+// GetDict returns the Dict of the given name. An error is returned if the tag is missing or not a Dict.
+func (dict Dict) GetDict(name string) (Dict, error) {
+	val, err := dict.getRequired(name)
+	if err != nil {
+		return EmptyDict(), err
+	}
+	dictVal, ok := val.(Dict)
+	if !ok {
+		return EmptyDict(), NewTagTypeError(name, "Dict", val)
+	}
+	return dictVal, nil
+}
+
+// getRequired returns the non-null Val of the given name, or a MissingTagError.
+func (dict Dict) getRequired(name string) (Val, error) {
+	if dict.Missing(name) {
+		return NewNull(), NewMissingTagError(name)
+	}
+	return dict.items[name], nil
+}
+
 // Names returns the key names for the given dict.
 func (dict Dict) Names() []string {
 	names := []string{}
@@ -63,6 +191,17 @@ func (dict Dict) dup() Dict {
 	return Dict{items: newItems}
 }
 
+// withoutNulls returns a copy of the Dict with all Null values removed
+func (dict Dict) withoutNulls() Dict {
+	newItems := map[string]Val{}
+	for name, val := range dict.items {
+		if dict.Has(name) {
+			newItems[name] = val
+		}
+	}
+	return Dict{items: newItems}
+}
+
 // Size returns the number of name/Val pairs.
 func (dict Dict) Size() int {
 	return len(dict.items)
@@ -155,7 +294,9 @@ func (dict Dict) WriteZincTo(buf *bufio.Writer, brackets bool) {
 		val := dict.items[name]
 		switch val := val.(type) {
 		case Grid:
+			buf.WriteString(":<<\n")
 			val.WriteZincTo(buf, 1)
+			buf.WriteString("\n>>")
 		case Marker:
 			break
 		default:
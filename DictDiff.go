@@ -0,0 +1,103 @@
+package haystack
+
+import "sort"
+
+// Diff returns the minimal change Dict that transforms this Dict into the target Dict. Tags that are new or have
+// changed values (see ValEquals) are included with their target value, and tags that exist in this Dict but not in
+// the target are included with a Remove value. Null values are treated as missing tags.
+//
+// The result is suitable for a `commit` update, and can be applied with Patch.
+func (dict Dict) Diff(target Dict) Dict {
+	changes := map[string]Val{}
+	for name, val := range target.items {
+		if !target.Has(name) {
+			continue
+		}
+		if dict.Missing(name) || !ValEquals(dict.items[name], val) {
+			changes[name] = val
+		}
+	}
+	for name := range dict.items {
+		if dict.Has(name) && target.Missing(name) {
+			changes[name] = NewRemove()
+		}
+	}
+	return NewDict(changes)
+}
+
+// Patch applies a change Dict, like one produced by Diff, and returns a new Dict. Tags with a Remove or Null value
+// are removed, and all other tags are set.
+func (dict Dict) Patch(changes Dict) Dict {
+	newDict := dict.dup()
+	for name, val := range changes.items {
+		switch val.(type) {
+		case Remove, Null, nil:
+			delete(newDict.items, name)
+		default:
+			newDict.items[name] = val
+		}
+	}
+	return newDict
+}
+
+// MergePolicy decides the value of a tag that is present in both Dicts of a Merge with different values. If the
+// policy returns a nil Val and no error, the tag is removed from the result.
+type MergePolicy func(name string, existing Val, incoming Val) (Val, error)
+
+// MergeKeepExisting is a MergePolicy that keeps the value of the Dict being merged into.
+func MergeKeepExisting(name string, existing Val, incoming Val) (Val, error) {
+	return existing, nil
+}
+
+// MergeOverwrite is a MergePolicy that takes the value of the incoming Dict.
+func MergeOverwrite(name string, existing Val, incoming Val) (Val, error) {
+	return incoming, nil
+}
+
+// MergeFailOnConflict is a MergePolicy that rejects any conflicting values. Merge collects all conflicts into a
+// single MergeConflictError.
+func MergeFailOnConflict(name string, existing Val, incoming Val) (Val, error) {
+	return nil, NewMergeConflictError([]string{name})
+}
+
+// Merge combines the incoming Dict into this one and returns a new Dict. Tags that are only in one of the Dicts are
+// kept, and tags in both with different values are resolved using the policy. A Remove value in the incoming Dict
+// removes the tag, unless the policy keeps the existing value.
+func (dict Dict) Merge(incoming Dict, policy MergePolicy) (Dict, error) {
+	newDict := dict.dup()
+	conflicts := []string{}
+	for name, val := range incoming.items {
+		if !incoming.Has(name) {
+			continue
+		}
+		if dict.Missing(name) {
+			if _, isRemove := val.(Remove); !isRemove {
+				newDict.items[name] = val
+			}
+			continue
+		}
+		existing := dict.items[name]
+		if ValEquals(existing, val) {
+			continue
+		}
+		merged, err := policy(name, existing, val)
+		if err != nil {
+			if conflictErr, ok := err.(MergeConflictError); ok {
+				conflicts = append(conflicts, conflictErr.Names...)
+				continue
+			}
+			return EmptyDict(), err
+		}
+		switch merged.(type) {
+		case Remove, Null, nil:
+			delete(newDict.items, name)
+		default:
+			newDict.items[name] = merged
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Strings(conflicts)
+		return EmptyDict(), NewMergeConflictError(conflicts)
+	}
+	return newDict, nil
+}
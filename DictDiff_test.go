@@ -0,0 +1,80 @@
+package haystack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDict_Diff(t *testing.T) {
+	from := NewDict(map[string]Val{
+		"id":      NewRef("p1", "Old Name"),
+		"dis":     NewStr("Old Name"),
+		"point":   NewMarker(),
+		"unit":    NewStr("°F"),
+		"nullTag": NewNull(),
+	})
+	to := NewDict(map[string]Val{
+		"id":    NewRef("p1", "New Name"),
+		"dis":   NewStr("New Name"),
+		"point": NewMarker(),
+		"his":   NewMarker(),
+	})
+
+	diff := from.Diff(to)
+	expected := NewDict(map[string]Val{
+		"dis":  NewStr("New Name"),
+		"his":  NewMarker(),
+		"unit": NewRemove(),
+	})
+	assert.Equal(t, expected, diff)
+
+	assert.True(t, from.Diff(from).IsEmpty())
+}
+
+func TestDict_Patch(t *testing.T) {
+	from := NewDict(map[string]Val{
+		"dis":   NewStr("Old Name"),
+		"point": NewMarker(),
+		"unit":  NewStr("°F"),
+	})
+	to := NewDict(map[string]Val{
+		"dis":   NewStr("New Name"),
+		"point": NewMarker(),
+		"his":   NewMarker(),
+	})
+
+	patched := from.Patch(from.Diff(to))
+	assert.Equal(t, to.ToZinc(), patched.ToZinc())
+
+	// Ensure original wasn't changed
+	assert.Equal(t, NewStr("°F"), from.Get("unit"))
+}
+
+func TestDict_Merge(t *testing.T) {
+	existing := NewDict(map[string]Val{
+		"dis":   NewStr("Existing"),
+		"point": NewMarker(),
+		"unit":  NewStr("°F"),
+	})
+	incoming := NewDict(map[string]Val{
+		"dis":  NewStr("Incoming"),
+		"his":  NewMarker(),
+		"unit": NewRemove(),
+	})
+
+	kept, err := existing.Merge(incoming, MergeKeepExisting)
+	assert.Nil(t, err)
+	assert.Equal(t, "{dis:\"Existing\" his point unit:\"°F\"}", kept.ToZinc())
+
+	overwritten, err := existing.Merge(incoming, MergeOverwrite)
+	assert.Nil(t, err)
+	assert.Equal(t, "{dis:\"Incoming\" his point}", overwritten.ToZinc())
+
+	_, err = existing.Merge(incoming, MergeFailOnConflict)
+	assert.Equal(t, NewMergeConflictError([]string{"dis", "unit"}), err)
+
+	noConflict, err := existing.Merge(NewDict(map[string]Val{"dis": NewStr("Existing"), "his": NewMarker()}), MergeFailOnConflict)
+	assert.Nil(t, err)
+	assert.Equal(t, "{dis:\"Existing\" his point unit:\"°F\"}", noConflict.ToZinc())
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"sort"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -131,6 +132,12 @@ func TestDict_ToZinc(t *testing.T) {
 		},
 	)
 	assert.Equal(t, dict.ToZinc(), "{area:35000ft² dis:\"Building\" site}")
+
+	var gb GridBuilder
+	gb.AddColNoMeta("id")
+	gb.AddRow([]Val{NewRef("p1", "")})
+	dict = NewDict(map[string]Val{"rows": gb.ToGrid()})
+	assert.Equal(t, dict.ToZinc(), "{rows:<<\n  ver:\"3.0\"\n  id\n  @p1\n>>}")
 }
 
 func TestDict_MarshalJSON(t *testing.T) {
@@ -160,3 +167,94 @@ func TestDict_MarshalHayson(t *testing.T) {
 	)
 	valTest_MarshalHayson(dict, "{\"_kind\":\"dict\",\"area\":{\"_kind\":\"number\",\"val\":35000,\"unit\":\"ft²\"},\"dis\":\"Building\",\"site\":{\"_kind\":\"marker\"}}", t)
 }
+
+func TestDict_Has(t *testing.T) {
+	dict := NewDict(
+		map[string]Val{
+			"site":  NewMarker(),
+			"empty": NewNull(),
+		},
+	)
+	assert.True(t, dict.Has("site"))
+	assert.False(t, dict.Has("empty"))
+	assert.False(t, dict.Has("notHere"))
+	assert.True(t, dict.Missing("notHere"))
+	assert.False(t, dict.Missing("site"))
+}
+
+func TestDict_typedGetters(t *testing.T) {
+	ts, _ := NewDateTimeRaw(2023, 1, 1, 0, 0, 0, 0, "UTC")
+	dict := NewDict(
+		map[string]Val{
+			"area":    NewNumber(35000.0, "ft²"),
+			"siteRef": NewRef("s1", "Site"),
+			"dis":     NewStr("Building"),
+			"enabled": NewBool(true),
+			"mod":     ts,
+			"list":    NewList([]Val{NewNumber(1, "")}),
+			"dict":    NewDict(map[string]Val{"foo": NewMarker()}),
+		},
+	)
+
+	number, err := dict.GetNumber("area")
+	assert.Nil(t, err)
+	assert.Equal(t, NewNumber(35000.0, "ft²"), number)
+
+	ref, err := dict.GetRef("siteRef")
+	assert.Nil(t, err)
+	assert.Equal(t, NewRef("s1", "Site"), ref)
+
+	str, err := dict.GetStr("dis")
+	assert.Nil(t, err)
+	assert.Equal(t, NewStr("Building"), str)
+
+	b, err := dict.GetBool("enabled")
+	assert.Nil(t, err)
+	assert.True(t, b.ToBool())
+
+	dateTime, err := dict.GetDateTime("mod")
+	assert.Nil(t, err)
+	assert.Equal(t, ts, dateTime)
+
+	list, err := dict.GetList("list")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, list.Size())
+
+	dictVal, err := dict.GetDict("dict")
+	assert.Nil(t, err)
+	assert.True(t, dictVal.Has("foo"))
+}
+
+func TestDict_typedGetters_errors(t *testing.T) {
+	dict := NewDict(
+		map[string]Val{
+			"dis": NewStr("Building"),
+		},
+	)
+
+	_, err := dict.GetNumber("dis")
+	assert.Equal(t, NewTagTypeError("dis", "Number", NewStr("")), err)
+	assert.EqualError(t, err, "Tag type error: dis is Str, not Number")
+
+	_, err = dict.GetRef("siteRef")
+	assert.Equal(t, NewMissingTagError("siteRef"), err)
+	assert.EqualError(t, err, "Missing tag: siteRef")
+}
+
+func TestDict_GetDuration(t *testing.T) {
+	dict := NewDict(map[string]Val{
+		"hisInterval": NewNumber(15, "min"),
+		"curVal":      NewNumber(72, "°F"),
+		"dis":         NewStr("Point"),
+	})
+	duration, err := dict.GetDuration("hisInterval")
+	assert.Nil(t, err)
+	assert.Equal(t, 15*time.Minute, duration)
+
+	_, err = dict.GetDuration("curVal")
+	assert.NotNil(t, err)
+	_, err = dict.GetDuration("dis")
+	assert.IsType(t, TagTypeError{}, err)
+	_, err = dict.GetDuration("missing")
+	assert.IsType(t, MissingTagError{}, err)
+}
@@ -0,0 +1,264 @@
+package haystack
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxDisDepth limits how many Refs are followed when expanding nested disMacros, which protects against cycles.
+const maxDisDepth = 8
+
+// DisResolver computes the display text of entities, following Refs to the entities they point to. Entities are
+// taken from an index, and those that are missing are read with the loader, if one is given. Entities that were
+// loaded are kept in the index, so a DisResolver should not be shared between unrelated record sets.
+type DisResolver struct {
+	entities map[string]Dict
+	load     func(ids []Ref) (Grid, error)
+}
+
+// NewDisResolver creates a DisResolver that indexes the entities by their `id` tag. Entities without an id are ignored.
+func NewDisResolver(entities []Dict) *DisResolver {
+	resolver := &DisResolver{entities: map[string]Dict{}}
+	resolver.Add(entities...)
+	return resolver
+}
+
+// NewDisResolverLoader creates a DisResolver that reads unknown entities in batches with the loader. Typically, this
+// is client.ReadByIds.
+func NewDisResolverLoader(load func(ids []Ref) (Grid, error)) *DisResolver {
+	return &DisResolver{entities: map[string]Dict{}, load: load}
+}
+
+// Add adds the entities to the index, replacing any with the same id.
+func (resolver *DisResolver) Add(entities ...Dict) {
+	for _, entity := range entities {
+		id, err := entity.GetRef("id")
+		if err != nil {
+			continue
+		}
+		resolver.entities[id.Id()] = entity
+	}
+}
+
+// Resolve returns the entity that the Ref points to, reading it with the loader if it is not yet indexed.
+func (resolver *DisResolver) Resolve(ref Ref) (Dict, error) {
+	if err := resolver.fetch([]Ref{ref}); err != nil {
+		return EmptyDict(), err
+	}
+	entity, ok := resolver.entities[ref.Id()]
+	if !ok || entity.IsEmpty() {
+		return EmptyDict(), errors.New("unknown ref: @" + ref.Id())
+	}
+	return entity, nil
+}
+
+// Dis returns the display text of the entity, using the first of these that is present:
+//   - the `dis` tag
+//   - the `disMacro` tag, expanded with the entity's tags. See DisMacro.
+//   - the `navName` tag
+//   - the display name of the `id` Ref, or the id itself
+func (resolver *DisResolver) Dis(entity Dict) string {
+	return resolver.dis(entity, 0)
+}
+
+// RefDis returns the display text of the Ref: its own dis if present, otherwise the display text of the entity it
+// points to, otherwise its id.
+func (resolver *DisResolver) RefDis(ref Ref) string {
+	return resolver.refDis(ref, 0)
+}
+
+// DisMacro expands a disMacro pattern with the tags of the entity. Both `$tag` and `${tag}` are replaced with the
+// value of the tag. Refs are replaced with the display text of the entity they point to, so that "$equipRef $navName"
+// might become "AHU-1 Discharge Air Temp". Tags that are missing are replaced with an empty string.
+func (resolver *DisResolver) DisMacro(pattern string, entity Dict) string {
+	return resolver.disMacro(pattern, entity, 0)
+}
+
+// FillRefDis returns a copy of the grid with the empty dis of every Ref filled in from the entity it points to. The
+// rows of the grid are indexed first, and any remaining unknown entities are read with the loader in batches: one for
+// the Refs in the grid, and one for each level of Refs used by the disMacros of those entities.
+func (resolver *DisResolver) FillRefDis(grid Grid) (Grid, error) {
+	rows := make([]Dict, 0, grid.RowCount())
+	for _, row := range grid.Rows() {
+		rows = append(rows, row.ToDict())
+	}
+	resolver.Add(rows...)
+
+	refs := []Ref{}
+	MapRefs(grid, func(ref Ref) Ref {
+		if ref.Dis() == "" {
+			refs = append(refs, ref)
+		}
+		return ref
+	})
+	for depth := 0; len(refs) > 0 && depth < maxDisDepth; depth++ {
+		err := resolver.fetch(refs)
+		if err != nil {
+			return grid, err
+		}
+		refs = resolver.macroRefs(refs)
+	}
+
+	filled := MapRefs(grid, func(ref Ref) Ref {
+		if ref.Dis() != "" {
+			return ref
+		}
+		return NewRef(ref.Id(), resolver.RefDis(ref))
+	})
+	return filled.(Grid), nil
+}
+
+// Dis returns the display text of the Dict, following the rules of DisResolver.Dis. Refs in a disMacro are displayed
+// using their own dis, or their id.
+func (dict Dict) Dis() string {
+	return NewDisResolver(nil).Dis(dict)
+}
+
+func (resolver *DisResolver) dis(entity Dict, depth int) string {
+	if dis, err := entity.GetStr("dis"); err == nil {
+		return dis.String()
+	}
+	if macro, err := entity.GetStr("disMacro"); err == nil {
+		return resolver.disMacro(macro.String(), entity, depth)
+	}
+	if navName, err := entity.GetStr("navName"); err == nil {
+		return navName.String()
+	}
+	if id, err := entity.GetRef("id"); err == nil {
+		if id.Dis() != "" {
+			return id.Dis()
+		}
+		return id.Id()
+	}
+	return ""
+}
+
+func (resolver *DisResolver) refDis(ref Ref, depth int) string {
+	if ref.Dis() != "" {
+		return ref.Dis()
+	}
+	if depth < maxDisDepth {
+		if entity, ok := resolver.entities[ref.Id()]; ok && !entity.IsEmpty() {
+			return resolver.dis(entity, depth+1)
+		}
+		if resolver.load != nil {
+			if entity, err := resolver.Resolve(ref); err == nil {
+				return resolver.dis(entity, depth+1)
+			}
+		}
+	}
+	return ref.Id()
+}
+
+func (resolver *DisResolver) disMacro(pattern string, entity Dict, depth int) string {
+	var buf strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		char := pattern[i]
+		if char != '$' {
+			buf.WriteByte(char)
+			continue
+		}
+
+		var name string
+		if i+1 < len(pattern) && pattern[i+1] == '{' {
+			end := strings.IndexByte(pattern[i+2:], '}')
+			if end < 0 {
+				buf.WriteString(pattern[i:])
+				break
+			}
+			name = pattern[i+2 : i+2+end]
+			i = i + 2 + end
+		} else {
+			end := i + 1
+			for end < len(pattern) && isTagNameChar(pattern[end]) {
+				end++
+			}
+			if end == i+1 {
+				buf.WriteByte(char)
+				continue
+			}
+			name = pattern[i+1 : end]
+			i = end - 1
+		}
+		buf.WriteString(resolver.valDis(entity.Get(name), depth))
+	}
+	return buf.String()
+}
+
+func (resolver *DisResolver) valDis(val Val, depth int) string {
+	switch val := val.(type) {
+	case Null:
+		return ""
+	case Ref:
+		return resolver.refDis(val, depth)
+	case fmt.Stringer:
+		return val.String()
+	default:
+		return val.ToZinc()
+	}
+}
+
+// fetch loads the entities of the Refs that are not yet indexed. Entities that the loader does not return are
+// recorded as empty so they are not read again.
+func (resolver *DisResolver) fetch(refs []Ref) error {
+	missing := []Ref{}
+	seen := map[string]bool{}
+	for _, ref := range refs {
+		if _, ok := resolver.entities[ref.Id()]; ok || seen[ref.Id()] {
+			continue
+		}
+		seen[ref.Id()] = true
+		missing = append(missing, NewRef(ref.Id(), ""))
+	}
+	if len(missing) == 0 || resolver.load == nil {
+		return nil
+	}
+
+	grid, err := resolver.load(missing)
+	if err != nil {
+		return err
+	}
+	for _, row := range grid.Rows() {
+		resolver.Add(row.ToDict())
+	}
+	for _, ref := range missing {
+		if _, ok := resolver.entities[ref.Id()]; !ok {
+			resolver.entities[ref.Id()] = EmptyDict()
+		}
+	}
+	return nil
+}
+
+// macroRefs returns the Refs without a dis that are used by the disMacros of the entities of the Refs, and that are
+// not yet indexed.
+func (resolver *DisResolver) macroRefs(refs []Ref) []Ref {
+	result := []Ref{}
+	for _, ref := range refs {
+		entity := resolver.entities[ref.Id()]
+		if entity.Has("dis") {
+			continue
+		}
+		macro, err := entity.GetStr("disMacro")
+		if err != nil {
+			continue
+		}
+		for _, name := range entity.Names() {
+			tagRef, ok := entity.Get(name).(Ref)
+			if !ok || tagRef.Dis() != "" || !strings.Contains(macro.String(), name) {
+				continue
+			}
+			if _, ok := resolver.entities[tagRef.Id()]; !ok {
+				result = append(result, tagRef)
+			}
+		}
+	}
+	return result
+}
+
+func isTagNameChar(char byte) bool {
+	return ('a' <= char && char <= 'z') ||
+		('A' <= char && char <= 'Z') ||
+		('0' <= char && char <= '9') ||
+		char == '_'
+}
@@ -0,0 +1,93 @@
+package haystack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDict_Dis(t *testing.T) {
+	assert.Equal(t, "Site", NewDict(map[string]Val{"dis": NewStr("Site"), "navName": NewStr("Nav")}).Dis())
+	assert.Equal(t, "Nav", NewDict(map[string]Val{"navName": NewStr("Nav"), "id": NewRef("a", "A")}).Dis())
+	assert.Equal(t, "A", NewDict(map[string]Val{"id": NewRef("a", "A")}).Dis())
+	assert.Equal(t, "a", NewDict(map[string]Val{"id": NewRef("a", "")}).Dis())
+	assert.Equal(t, "", EmptyDict().Dis())
+
+	macro := NewDict(map[string]Val{
+		"disMacro": NewStr("$equipRef ${navName}-$area $ $missing"),
+		"equipRef": NewRef("e", "AHU-1"),
+		"navName":  NewStr("DAT"),
+		"area":     NewNumber(100, "ft²"),
+	})
+	assert.Equal(t, "AHU-1 DAT-100ft² $ ", macro.Dis())
+}
+
+func TestDisResolver_Dis(t *testing.T) {
+	resolver := NewDisResolver([]Dict{
+		NewDict(map[string]Val{"id": NewRef("s", ""), "dis": NewStr("Site")}),
+		NewDict(map[string]Val{"id": NewRef("e", ""), "disMacro": NewStr("$siteRef $navName"), "navName": NewStr("AHU-1"), "siteRef": NewRef("s", "")}),
+	})
+	point := NewDict(map[string]Val{
+		"id":       NewRef("p", ""),
+		"disMacro": NewStr("$equipRef $navName"),
+		"navName":  NewStr("DAT"),
+		"equipRef": NewRef("e", ""),
+	})
+	assert.Equal(t, "Site AHU-1 DAT", resolver.Dis(point))
+	assert.Equal(t, "Site AHU-1", resolver.RefDis(NewRef("e", "")))
+	assert.Equal(t, "x", resolver.RefDis(NewRef("x", "")))
+
+	_, err := resolver.Resolve(NewRef("x", ""))
+	assert.NotNil(t, err)
+}
+
+func TestDisResolver_Cycle(t *testing.T) {
+	resolver := NewDisResolver([]Dict{
+		NewDict(map[string]Val{"id": NewRef("a", ""), "disMacro": NewStr("$otherRef"), "otherRef": NewRef("b", "")}),
+		NewDict(map[string]Val{"id": NewRef("b", ""), "disMacro": NewStr("$otherRef"), "otherRef": NewRef("a", "")}),
+	})
+	assert.Contains(t, []string{"a", "b"}, resolver.RefDis(NewRef("a", "")))
+}
+
+func TestDisResolver_FillRefDis(t *testing.T) {
+	store := map[string]Dict{
+		"s": NewDict(map[string]Val{"id": NewRef("s", ""), "dis": NewStr("Site")}),
+		"e": NewDict(map[string]Val{"id": NewRef("e", ""), "disMacro": NewStr("$siteRef $navName"), "navName": NewStr("AHU-1"), "siteRef": NewRef("s", "")}),
+	}
+	batches := [][]Ref{}
+	resolver := NewDisResolverLoader(func(ids []Ref) (Grid, error) {
+		batches = append(batches, ids)
+		rows := []Dict{}
+		for _, id := range ids {
+			if entity, ok := store[id.Id()]; ok {
+				rows = append(rows, entity)
+			}
+		}
+		return NewGridFromDicts(rows), nil
+	})
+
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("navName")
+	gb.AddColNoMeta("equipRef")
+	gb.AddRow([]Val{NewRef("p1", ""), NewStr("DAT"), NewRef("e", "")})
+	gb.AddRow([]Val{NewRef("p2", "Named"), NewStr("RAT"), NewRef("gone", "")})
+	grid, err := resolver.FillRefDis(gb.ToGrid())
+	assert.Nil(t, err)
+
+	assert.Equal(t, NewRef("p1", "DAT"), grid.RowAt(0).Get("id"))
+	assert.Equal(t, NewRef("e", "Site AHU-1"), grid.RowAt(0).Get("equipRef"))
+	assert.Equal(t, NewRef("p2", "Named"), grid.RowAt(1).Get("id"))
+	assert.Equal(t, NewRef("gone", "gone"), grid.RowAt(1).Get("equipRef"))
+	assert.Equal(t, [][]Ref{
+		{NewRef("e", ""), NewRef("gone", "")},
+		{NewRef("s", "")},
+	}, batches)
+
+	failing := NewDisResolverLoader(func(ids []Ref) (Grid, error) {
+		return EmptyGrid(), errors.New("read failed")
+	})
+	_, err = failing.FillRefDis(gb.ToGrid())
+	assert.NotNil(t, err)
+}
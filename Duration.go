@@ -0,0 +1,100 @@
+package haystack
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// durationUnit is a Haystack time unit with an exact length
+type durationUnit struct {
+	symbol   string
+	duration time.Duration
+}
+
+// durationUnits are the exact time units used when normalizing, ordered from largest to smallest
+var durationUnits = []durationUnit{
+	{symbol: "day", duration: 24 * time.Hour},
+	{symbol: "h", duration: time.Hour},
+	{symbol: "min", duration: time.Minute},
+	{symbol: "s", duration: time.Second},
+	{symbol: "ms", duration: time.Millisecond},
+	{symbol: "µs", duration: time.Microsecond},
+	{symbol: "ns", duration: time.Nanosecond},
+}
+
+// durationUnitAliases maps the names and symbols of the Haystack time units to their length. Months and years are
+// not included because they have no exact length.
+var durationUnitAliases = map[string]time.Duration{
+	"week":        7 * 24 * time.Hour,
+	"wk":          7 * 24 * time.Hour,
+	"day":         24 * time.Hour,
+	"hour":        time.Hour,
+	"hr":          time.Hour,
+	"h":           time.Hour,
+	"minute":      time.Minute,
+	"min":         time.Minute,
+	"second":      time.Second,
+	"sec":         time.Second,
+	"s":           time.Second,
+	"millisecond": time.Millisecond,
+	"ms":          time.Millisecond,
+	"microsecond": time.Microsecond,
+	"µs":          time.Microsecond,
+	"us":          time.Microsecond,
+	"nanosecond":  time.Nanosecond,
+	"ns":          time.Nanosecond,
+}
+
+// IsDurationUnit returns true if the unit is a Haystack time unit with an exact length, like "min" or "h".
+func IsDurationUnit(unit string) bool {
+	_, ok := durationUnitAliases[unit]
+	return ok
+}
+
+// NewNumberFromDuration creates a Number from the Go duration, using the largest unit that represents it exactly.
+// For example, 90 minutes is "90min", 2 hours is "2h", and 1500 milliseconds is "1500ms". Durations are never
+// normalized to weeks.
+func NewNumberFromDuration(duration time.Duration) Number {
+	if duration == 0 {
+		return NewNumber(0, "s")
+	}
+	unit := durationUnits[len(durationUnits)-1]
+	for _, candidate := range durationUnits {
+		if duration%candidate.duration == 0 {
+			unit = candidate
+			break
+		}
+	}
+	return NewNumber(float64(duration/unit.duration), unit.symbol)
+}
+
+// ToDuration converts a Number with a time unit, like "5min" or "1day", to a Go duration. An error is returned if
+// the unit is not an exact time unit (see IsDurationUnit), or if the value is not finite or is out of range.
+func (number Number) ToDuration() (time.Duration, error) {
+	unitDuration, ok := durationUnitAliases[number.unit]
+	if !ok {
+		if number.unit == "" {
+			return 0, errors.New("number has no unit, expected a time unit: " + number.ToZinc())
+		}
+		return 0, errors.New("not an exact time unit: " + number.unit)
+	}
+	if math.IsNaN(number.val) || math.IsInf(number.val, 0) {
+		return 0, errors.New("duration is not finite: " + number.ToZinc())
+	}
+	nanos := math.Round(number.val * float64(unitDuration))
+	if nanos >= math.MaxInt64 || nanos < math.MinInt64 {
+		return 0, errors.New("duration out of range: " + number.ToZinc())
+	}
+	return time.Duration(nanos), nil
+}
+
+// NormalizeDuration returns the Number converted to the largest time unit that represents it exactly, like "2h"
+// for "120min". An error is returned if the Number is not a valid duration. See ToDuration.
+func (number Number) NormalizeDuration() (Number, error) {
+	duration, err := number.ToDuration()
+	if err != nil {
+		return Number{}, err
+	}
+	return NewNumberFromDuration(duration), nil
+}
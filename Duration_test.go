@@ -0,0 +1,76 @@
+package haystack
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNumberFromDuration(t *testing.T) {
+	assert.Equal(t, NewNumber(0, "s"), NewNumberFromDuration(0))
+	assert.Equal(t, NewNumber(1, "day"), NewNumberFromDuration(24*time.Hour))
+	assert.Equal(t, NewNumber(25, "h"), NewNumberFromDuration(25*time.Hour))
+	assert.Equal(t, NewNumber(90, "min"), NewNumberFromDuration(90*time.Minute))
+	assert.Equal(t, NewNumber(30, "s"), NewNumberFromDuration(30*time.Second))
+	assert.Equal(t, NewNumber(1500, "ms"), NewNumberFromDuration(1500*time.Millisecond))
+	assert.Equal(t, NewNumber(-5, "min"), NewNumberFromDuration(-5*time.Minute))
+	assert.Equal(t, NewNumber(14, "day"), NewNumberFromDuration(14*24*time.Hour))
+	assert.Equal(t, NewNumber(1001, "ns"), NewNumberFromDuration(1001))
+}
+
+func TestNumber_ToDuration(t *testing.T) {
+	duration, err := NewNumber(5, "min").ToDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Minute, duration)
+
+	duration, err = NewNumber(1.5, "hr").ToDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, 90*time.Minute, duration)
+
+	duration, err = NewNumber(1, "wk").ToDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, 7*24*time.Hour, duration)
+
+	duration, err = NewNumber(250, "µs").ToDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, 250*time.Microsecond, duration)
+
+	_, err = NewNumber(5, "").ToDuration()
+	assert.NotNil(t, err)
+	_, err = NewNumber(1, "mo").ToDuration()
+	assert.NotNil(t, err)
+	_, err = NewNumber(5, "°F").ToDuration()
+	assert.NotNil(t, err)
+	_, err = NewNumber(math.Inf(1), "s").ToDuration()
+	assert.NotNil(t, err)
+	_, err = NewNumber(1000, "yr").ToDuration()
+	assert.NotNil(t, err)
+	_, err = NewNumber(1e9, "day").ToDuration()
+	assert.NotNil(t, err)
+}
+
+func TestNumber_NormalizeDuration(t *testing.T) {
+	normalized, err := NewNumber(120, "min").NormalizeDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, NewNumber(2, "h"), normalized)
+
+	normalized, err = NewNumber(0.5, "day").NormalizeDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, NewNumber(12, "h"), normalized)
+
+	normalized, err = NewNumber(3600000, "ms").NormalizeDuration()
+	assert.Nil(t, err)
+	assert.Equal(t, NewNumber(1, "h"), normalized)
+
+	_, err = NewNumber(1, "kW").NormalizeDuration()
+	assert.NotNil(t, err)
+}
+
+func TestIsDurationUnit(t *testing.T) {
+	assert.True(t, IsDurationUnit("min"))
+	assert.True(t, IsDurationUnit("sec"))
+	assert.False(t, IsDurationUnit("mo"))
+	assert.False(t, IsDurationUnit(""))
+}
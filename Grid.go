@@ -4,14 +4,26 @@ import (
 	"bufio"
 	"encoding/json"
 	"errors"
+	"sort"
 	"strings"
 )
 
 // Grid is a two dimension data structure of cols and rows.
+//
+// Internally the values are stored by column, with a name-to-index map for column lookup. Number and DateTime
+// columns are stored unboxed, which keeps large grids (like history reads) compact and fast to access.
 type Grid struct {
 	meta Dict
 	cols []Col
-	rows []Row
+	data *gridData
+}
+
+// gridData is the columnar value storage shared by a Grid and all of its Rows.
+type gridData struct {
+	names    []string
+	colIndex map[string]int
+	columns  []gridColumn
+	rowCount int
 }
 
 // EmptyGrid creates an empty grid.
@@ -19,8 +31,35 @@ func EmptyGrid() Grid {
 	return Grid{
 		meta: Dict{},
 		cols: []Col{},
-		rows: []Row{},
+		data: &gridData{
+			names:    []string{},
+			colIndex: map[string]int{},
+			columns:  []gridColumn{},
+		},
+	}
+}
+
+// NewGridFromDicts creates a grid with a row for each Dict. The columns are the union of all the Dict tag names,
+// sorted alphabetically, and rows that are missing a tag have a null value in that column.
+func NewGridFromDicts(dicts []Dict) Grid {
+	names := map[string]bool{}
+	for _, dict := range dicts {
+		for name := range dict.items {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	gb := NewGridBuilder()
+	for _, name := range sorted {
+		gb.AddColNoMeta(name)
 	}
+	gb.AddRowDicts(dicts)
+	return gb.ToGrid()
 }
 
 // Meta returns the grid-level metadata
@@ -40,15 +79,12 @@ func (grid Grid) Cols() []Col {
 
 // Col returns the column matching the name
 func (grid Grid) Col(name string) *Col {
-	var colMatch Col
-	for i := 0; i < grid.ColCount(); i++ {
-		col := grid.ColAt(i)
-		if grid.cols[i].name == name {
-			colMatch = col
-			return &colMatch
-		}
+	index, ok := grid.colIndex(name)
+	if !ok {
+		return nil
 	}
-	return nil
+	colMatch := grid.cols[index]
+	return &colMatch
 }
 
 // ColAt returns the column at the index
@@ -56,44 +92,98 @@ func (grid Grid) ColAt(index int) Col {
 	return grid.cols[index]
 }
 
-// Meta returns the grid-level metadata
+// ColNumbers returns the values of the named column as Numbers. This is a fast path that avoids boxing each value,
+// and returns false if the column doesn't exist, or if it contains anything other than Numbers (including nulls).
+// The returned slice must not be modified.
+func (grid Grid) ColNumbers(name string) ([]Number, bool) {
+	col, ok := grid.column(name)
+	if !ok || col.kind != columnNumber || col.nulls != nil {
+		return nil, false
+	}
+	return col.numbers[:col.size:col.size], true
+}
+
+// ColDateTimes returns the values of the named column as DateTimes. This is a fast path that avoids boxing each
+// value, and returns false if the column doesn't exist, or if it contains anything other than DateTimes (including
+// nulls). The returned slice must not be modified.
+func (grid Grid) ColDateTimes(name string) ([]DateTime, bool) {
+	col, ok := grid.column(name)
+	if !ok || col.kind != columnDateTime || col.nulls != nil {
+		return nil, false
+	}
+	return col.dateTimes[:col.size:col.size], true
+}
+
+// RenameCol returns a new Grid with the column renamed. Values are shared with the original grid.
 func (grid Grid) RenameCol(from string, to string) Grid {
-	builder := NewGridBuilder()
-	builder.AddMeta(grid.meta.items)
-	for _, col := range grid.cols {
+	cols := make([]Col, len(grid.cols))
+	names := make([]string, len(grid.cols))
+	colIndex := map[string]int{}
+	for i, col := range grid.cols {
 		if col.name == from {
-			builder.AddCol(to, col.meta.items)
-		} else {
-			builder.AddCol(col.name, col.meta.items)
+			col = newCol(i, to, col.meta)
 		}
-	}
-	for _, row := range grid.rows {
-		newItems := map[string]Val{}
-		for name, val := range row.items {
-			if name == from {
-				newItems[to] = val
-			} else {
-				newItems[name] = val
-			}
+		cols[i] = col
+		names[i] = col.name
+		if _, ok := colIndex[col.name]; !ok {
+			colIndex[col.name] = i
 		}
-		builder.AddRowDict(NewDict(newItems))
 	}
-	return builder.ToGrid()
+	data := &gridData{
+		names:    names,
+		colIndex: colIndex,
+		columns:  []gridColumn{},
+	}
+	if grid.data != nil {
+		data.columns = grid.data.columns
+		data.rowCount = grid.data.rowCount
+	}
+	return Grid{
+		meta: grid.meta,
+		cols: cols,
+		data: data,
+	}
 }
 
 // RowCount returns the count of rows
 func (grid Grid) RowCount() int {
-	return len(grid.rows)
+	if grid.data == nil {
+		return 0
+	}
+	return grid.data.rowCount
 }
 
-// Rows returns the column objects
+// Rows returns the row objects
 func (grid Grid) Rows() []Row {
-	return grid.rows
+	rows := make([]Row, grid.RowCount())
+	for i := range rows {
+		rows[i] = Row{data: grid.data, index: i}
+	}
+	return rows
 }
 
 // RowAt returns the row at the index
 func (grid Grid) RowAt(index int) Row {
-	return grid.rows[index]
+	if index < 0 || index >= grid.RowCount() {
+		panic("grid row index out of range")
+	}
+	return Row{data: grid.data, index: index}
+}
+
+func (grid Grid) colIndex(name string) (int, bool) {
+	if grid.data == nil {
+		return 0, false
+	}
+	index, ok := grid.data.colIndex[name]
+	return index, ok
+}
+
+func (grid Grid) column(name string) (*gridColumn, bool) {
+	index, ok := grid.colIndex(name)
+	if !ok {
+		return nil, false
+	}
+	return &grid.data.columns[index], true
 }
 
 // MarshalJSON represents the object in a special JSON object format. See https://project-haystack.org/doc/Json#grid
@@ -116,7 +206,7 @@ func (grid Grid) MarshalJSON() ([]byte, error) {
 	buf.Write(colsJSON)
 
 	buf.WriteString(",\"rows\":")
-	rowsJSON, rowsErr := json.Marshal(grid.rows)
+	rowsJSON, rowsErr := json.Marshal(grid.Rows())
 	if rowsErr != nil {
 		return []byte{}, rowsErr
 	}
@@ -209,7 +299,7 @@ func (grid Grid) MarshalHayson() ([]byte, error) {
 	buf.WriteString("]")
 
 	buf.WriteString(",\"rows\":[")
-	for idx, row := range grid.rows {
+	for idx, row := range grid.Rows() {
 		if idx != 0 {
 			buf.WriteString(",")
 		}
@@ -269,7 +359,7 @@ func (grid Grid) WriteZincTo(buf *bufio.Writer, indentSize int) {
 		}
 		buf.WriteString("\n")
 		writeIndent(buf, indentSize)
-		for rowIdx, row := range grid.rows {
+		for rowIdx, row := range grid.Rows() {
 			if rowIdx != 0 {
 				buf.WriteString("\n")
 				writeIndent(buf, indentSize)
@@ -327,19 +417,33 @@ func (col Col) WriteZincTo(buf *bufio.Writer) {
 	}
 }
 
-// Row is a row in a Grid.
+// Row is a row in a Grid. It is a lightweight view into the grid's column storage.
 type Row struct {
-	items map[string]Val
+	data  *gridData
+	index int
 }
 
 // Get returns the Val of the given name. If the name is not found, Null is returned.
 func (row Row) Get(name string) Val {
-	return row.items[name]
+	if row.data == nil {
+		return NewNull()
+	}
+	colIndex, ok := row.data.colIndex[name]
+	if !ok {
+		return NewNull()
+	}
+	return row.data.columns[colIndex].get(row.index)
 }
 
 // ToDict returns the values in a Dict format
 func (row Row) ToDict() Dict {
-	return NewDict(row.items)
+	items := map[string]Val{}
+	if row.data != nil {
+		for colIndex, name := range row.data.names {
+			items[name] = row.data.columns[colIndex].get(row.index)
+		}
+	}
+	return NewDict(items)
 }
 
 // MarshalJSON represents the object in JSON object format: "{"<name1>":<val1>, "<name2>":<val2> ...}"
@@ -360,7 +464,7 @@ func (row Row) WriteZincTo(buf *bufio.Writer, cols []Col, indentSize int) {
 		if colIdx != 0 {
 			buf.WriteString(", ")
 		}
-		val := row.items[col.name]
+		val := row.Get(col.name)
 		switch val := val.(type) {
 		case Grid:
 			indentSize = indentSize + 1
@@ -4,27 +4,49 @@ import "errors"
 
 // GridBuilder is used to easily construct a Grid instance.
 type GridBuilder struct {
-	meta map[string]Val
-	cols []Col
-	rows []Row
+	meta     map[string]Val
+	cols     []Col
+	colIndex map[string]int
+	columns  []gridColumn
+	rowCount int
 }
 
 // NewGridBuilder creates a GridBuilder object that can be used to generate complex grids
 func NewGridBuilder() *GridBuilder {
 	return &GridBuilder{
-		meta: map[string]Val{},
-		cols: []Col{},
-		rows: []Row{},
+		meta:     map[string]Val{},
+		cols:     []Col{},
+		colIndex: map[string]int{},
+		columns:  []gridColumn{},
 	}
 }
 
-// ToGrid returns the grid representation of the builder.
+// ToGrid returns the grid representation of the builder. The builder may continue to be used afterwards without
+// affecting the returned grid.
 func (gb *GridBuilder) ToGrid() Grid {
-	meta := NewDict(gb.meta)
+	meta := NewDict(gb.meta).dup()
+	cols := make([]Col, len(gb.cols))
+	copy(cols, gb.cols)
+	names := make([]string, len(gb.cols))
+	colIndex := make(map[string]int, len(gb.colIndex))
+	for idx, col := range gb.cols {
+		names[idx] = col.name
+		if _, ok := colIndex[col.name]; !ok {
+			colIndex[col.name] = idx
+		}
+	}
+	// Columns are append-only, so copying the headers is enough to snapshot them.
+	columns := make([]gridColumn, len(gb.columns))
+	copy(columns, gb.columns)
 	return Grid{
 		meta: meta,
-		cols: gb.cols,
-		rows: gb.rows,
+		cols: cols,
+		data: &gridData{
+			names:    names,
+			colIndex: colIndex,
+			columns:  columns,
+			rowCount: gb.rowCount,
+		},
 	}
 }
 
@@ -72,15 +94,23 @@ func (gb *GridBuilder) AddColDict(name string, meta Dict) {
 	newCol := newCol(index, name, meta)
 	// TODO check that the name doesn't duplicate
 	gb.cols = append(gb.cols, newCol)
+	if gb.colIndex == nil {
+		gb.colIndex = map[string]int{}
+	}
+	if _, ok := gb.colIndex[name]; !ok {
+		gb.colIndex[name] = index
+	}
+	// Rows added before this column get null values
+	gb.columns = append(gb.columns, newEmptyColumn(gb.rowCount))
 }
 
 // AddColMeta adds the metadata to an existing column with the given name.
 func (gb *GridBuilder) AddColMeta(name string, meta map[string]Val) {
-	col, err := gb.getCol(name)
+	index, err := gb.getColIndex(name)
 	if err != nil {
 		return // If col doesn't exist, do nothing
 	}
-	col.meta = col.meta.SetAll(meta)
+	gb.cols[index].meta = gb.cols[index].meta.SetAll(meta)
 }
 
 // AddColMetaVal adds the metadata name and value to an existing column with the given name.
@@ -95,22 +125,18 @@ func (gb *GridBuilder) AddColMetaDict(name string, meta Dict) {
 
 // AddRow adds a row with the input values, according to the column order.
 func (gb *GridBuilder) AddRow(vals []Val) {
-	items := make(map[string]Val)
-	for idx, col := range gb.cols {
-		items[col.name] = vals[idx]
+	for idx := range gb.cols {
+		gb.columns[idx].append(vals[idx])
 	}
-	newRow := Row{items: items}
-	gb.rows = append(gb.rows, newRow)
+	gb.rowCount++
 }
 
 // AddRowDict adds a row from the input dict, extracting the values that correspond to the grid columns.
 func (gb *GridBuilder) AddRowDict(row Dict) {
-	items := make(map[string]Val)
-	for _, col := range gb.cols {
-		items[col.name] = row.Get(col.name)
+	for idx, col := range gb.cols {
+		gb.columns[idx].append(row.Get(col.name))
 	}
-	newRow := Row{items: items}
-	gb.rows = append(gb.rows, newRow)
+	gb.rowCount++
 }
 
 // AddRowDicts adds rows from the dicts, extracting the values that correspond to the grid columns.
@@ -120,17 +146,10 @@ func (gb *GridBuilder) AddRowDicts(rows []Dict) {
 	}
 }
 
-func (gb *GridBuilder) getCol(colName string) (Col, error) {
-	var matchCol Col
-	match := false
-	for _, col := range gb.cols {
-		if col.name == colName {
-			matchCol = col
-			match = true
-		}
-	}
-	if match {
-		return matchCol, nil
+func (gb *GridBuilder) getColIndex(colName string) (int, error) {
+	index, ok := gb.colIndex[colName]
+	if !ok {
+		return 0, errors.New("col with name not found: " + colName)
 	}
-	return newCol(0, "", EmptyDict()), errors.New("col with name not found: " + colName)
+	return index, nil
 }
@@ -2,6 +2,8 @@ package haystack
 
 import (
 	"testing"
+
+	"github.com/stretchr/testify/assert"
 )
 
 func TestGridBuilder_ToGrid(t *testing.T) {
@@ -21,3 +23,24 @@ func TestGridBuilder_ToGrid(t *testing.T) {
 		t.Error("GridBuilder.ToGrid persists grid values")
 	}
 }
+
+func TestGridBuilder_AddColMeta(t *testing.T) {
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("col1")
+	gb.AddColMetaVal("col1", "dis", NewStr("Column 1"))
+	grid := gb.ToGrid()
+
+	assert.Equal(t, NewStr("Column 1"), grid.Col("col1").Meta().Get("dis"))
+}
+
+func TestGridBuilder_AddColAfterRows(t *testing.T) {
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("col1")
+	gb.AddRow([]Val{NewStr("val1")})
+	gb.AddColNoMeta("col2")
+	gb.AddRow([]Val{NewStr("val2"), NewNumber(2, "")})
+	grid := gb.ToGrid()
+
+	assert.Equal(t, NewNull(), grid.RowAt(0).Get("col2"))
+	assert.Equal(t, NewNumber(2, ""), grid.RowAt(1).Get("col2"))
+}
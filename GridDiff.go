@@ -0,0 +1,158 @@
+package haystack
+
+import (
+	"errors"
+	"sort"
+)
+
+// GridDiff describes the differences between two Grids whose rows are matched by a key column.
+type GridDiff struct {
+	// Key is the name of the column used to match rows
+	Key string
+	// Added are the rows that are only in the target grid
+	Added []Dict
+	// Removed are the rows that are only in the original grid
+	Removed []Dict
+	// Changed are the rows that are in both grids with different tags
+	Changed []RowDiff
+}
+
+// RowDiff describes the tag-level differences of a row that exists in both compared grids.
+type RowDiff struct {
+	// Key is the value of the key column for the row
+	Key Val
+	// From is the row in the original grid
+	From Dict
+	// To is the row in the target grid
+	To Dict
+	// Changes is the minimal change Dict from From to To. See Dict.Diff
+	Changes Dict
+}
+
+// Change kinds used in the `change` column of GridDiff.ToGrid
+const (
+	GridDiffRowAdded   = "added"
+	GridDiffRowRemoved = "removed"
+	GridDiffTagAdded   = "tagAdded"
+	GridDiffTagRemoved = "tagRemoved"
+	GridDiffTagChanged = "tagChanged"
+)
+
+// Diff compares this grid to the target grid, matching rows by their `id` column.
+func (grid Grid) Diff(target Grid) (GridDiff, error) {
+	return grid.DiffBy(target, "id")
+}
+
+// DiffBy compares this grid to the target grid, matching rows by the given key column. Values are compared using
+// ValEquals, so Refs match regardless of their display names. An error is returned if any row has a null or
+// duplicate key.
+func (grid Grid) DiffBy(target Grid, key string) (GridDiff, error) {
+	fromRows, fromKeys, err := indexRows(grid, key)
+	if err != nil {
+		return GridDiff{}, err
+	}
+	toRows, toKeys, err := indexRows(target, key)
+	if err != nil {
+		return GridDiff{}, err
+	}
+
+	diff := GridDiff{
+		Key:     key,
+		Added:   []Dict{},
+		Removed: []Dict{},
+		Changed: []RowDiff{},
+	}
+	for _, keyStr := range fromKeys {
+		from := fromRows[keyStr]
+		to, ok := toRows[keyStr]
+		if !ok {
+			diff.Removed = append(diff.Removed, from)
+			continue
+		}
+		changes := from.Diff(to)
+		if !changes.IsEmpty() {
+			diff.Changed = append(diff.Changed, RowDiff{
+				Key:     to.Get(key),
+				From:    from,
+				To:      to,
+				Changes: changes,
+			})
+		}
+	}
+	for _, keyStr := range toKeys {
+		if _, ok := fromRows[keyStr]; !ok {
+			diff.Added = append(diff.Added, toRows[keyStr])
+		}
+	}
+	return diff, nil
+}
+
+// IsEmpty returns true if the compared grids had no differences.
+func (diff GridDiff) IsEmpty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// ToGrid renders the differences as a Grid with the columns: <key>, change, tag, from, to
+//
+// Added and removed rows have a single row with the full Dict in the `to` or `from` column. Changed rows have one
+// row per changed tag, with the old and new tag values. Rows are ordered removed, added, then changed.
+func (diff GridDiff) ToGrid() Grid {
+	gb := NewGridBuilder()
+	gb.AddColNoMeta(diff.Key)
+	gb.AddColNoMeta("change")
+	gb.AddColNoMeta("tag")
+	gb.AddColNoMeta("from")
+	gb.AddColNoMeta("to")
+
+	for _, row := range diff.Removed {
+		gb.AddRow([]Val{row.Get(diff.Key), NewStr(GridDiffRowRemoved), NewNull(), row, NewNull()})
+	}
+	for _, row := range diff.Added {
+		gb.AddRow([]Val{row.Get(diff.Key), NewStr(GridDiffRowAdded), NewNull(), NewNull(), row})
+	}
+	for _, rowDiff := range diff.Changed {
+		names := rowDiff.Changes.Names()
+		sort.Strings(names)
+		for _, name := range names {
+			change := GridDiffTagChanged
+			to := rowDiff.Changes.Get(name)
+			if rowDiff.From.Missing(name) {
+				change = GridDiffTagAdded
+			} else if _, isRemove := to.(Remove); isRemove {
+				change = GridDiffTagRemoved
+				to = NewNull()
+			}
+			gb.AddRow([]Val{rowDiff.Key, NewStr(change), NewStr(name), rowDiff.From.Get(name), to})
+		}
+	}
+	return gb.ToGrid()
+}
+
+// indexRows maps the rows of the grid by the string form of their key value. The keys are returned sorted.
+func indexRows(grid Grid, key string) (map[string]Dict, []string, error) {
+	rows := map[string]Dict{}
+	keys := []string{}
+	for _, row := range grid.Rows() {
+		dict := row.ToDict().withoutNulls()
+		keyVal := dict.Get(key)
+		if _, isNull := keyVal.(Null); isNull {
+			return nil, nil, errors.New("grid row is missing key column: " + key)
+		}
+		keyStr := diffKey(keyVal)
+		if _, ok := rows[keyStr]; ok {
+			return nil, nil, errors.New("grid has duplicate key: " + keyVal.ToZinc())
+		}
+		rows[keyStr] = dict
+		keys = append(keys, keyStr)
+	}
+	sort.Strings(keys)
+	return rows, keys, nil
+}
+
+// diffKey returns a string that is equal for keys that are ValEquals
+func diffKey(val Val) string {
+	if ref, ok := val.(Ref); ok {
+		return "@" + ref.Id()
+	}
+	return val.ToZinc()
+}
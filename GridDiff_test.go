@@ -0,0 +1,63 @@
+package haystack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrid_Diff(t *testing.T) {
+	fromGb := NewGridBuilder()
+	fromGb.AddColNoMeta("id")
+	fromGb.AddColNoMeta("dis")
+	fromGb.AddColNoMeta("equip")
+	fromGb.AddColNoMeta("area")
+	fromGb.AddRow([]Val{NewRef("a", "A"), NewStr("A"), NewMarker(), NewNull()})
+	fromGb.AddRow([]Val{NewRef("b", "B"), NewStr("B"), NewMarker(), NewNumber(10, "ft²")})
+	fromGb.AddRow([]Val{NewRef("c", "C"), NewStr("C"), NewMarker(), NewNull()})
+
+	toGb := NewGridBuilder()
+	toGb.AddColNoMeta("id")
+	toGb.AddColNoMeta("dis")
+	toGb.AddColNoMeta("point")
+	toGb.AddColNoMeta("area")
+	toGb.AddRow([]Val{NewRef("a", "A2"), NewStr("A"), NewNull(), NewNull()})
+	toGb.AddRow([]Val{NewRef("b", "B"), NewStr("B"), NewNull(), NewNumber(20, "ft²")})
+	toGb.AddRow([]Val{NewRef("d", "D"), NewStr("D"), NewMarker(), NewNull()})
+
+	diff, err := fromGb.ToGrid().Diff(toGb.ToGrid())
+	assert.Nil(t, err)
+	assert.False(t, diff.IsEmpty())
+	assert.Equal(t, 1, len(diff.Added))
+	assert.Equal(t, NewRef("d", "D"), diff.Added[0].Get("id"))
+	assert.Equal(t, 1, len(diff.Removed))
+	assert.Equal(t, NewRef("c", "C"), diff.Removed[0].Get("id"))
+	assert.Equal(t, 2, len(diff.Changed))
+
+	zinc := `ver:"3.0"
+id, change, tag, from, to
+@c "C", "removed", N, {dis:"C" equip id:@c "C"}, N
+@d "D", "added", N, N, {dis:"D" id:@d "D" point}
+@a "A2", "tagRemoved", "equip", M, N
+@b "B", "tagChanged", "area", 10ft², 20ft²
+@b "B", "tagRemoved", "equip", M, N`
+	assert.Equal(t, zinc, diff.ToGrid().ToZinc())
+}
+
+func TestGrid_Diff_same(t *testing.T) {
+	grid := newGridSimple()
+	diff, err := grid.DiffBy(grid, "siteName")
+	assert.Nil(t, err)
+	assert.True(t, diff.IsEmpty())
+}
+
+func TestGrid_Diff_duplicateKey(t *testing.T) {
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddRow([]Val{NewRef("a", "")})
+	gb.AddRow([]Val{NewRef("a", "")})
+	grid := gb.ToGrid()
+
+	_, err := grid.Diff(grid)
+	assert.NotNil(t, err)
+}
@@ -3,6 +3,7 @@ package haystack
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -265,6 +266,62 @@ func TestGrid_MarshalHayson_nested(t *testing.T) {
 	valTest_MarshalHayson(grid, json, t)
 }
 
+func TestGrid_RowGet_missing(t *testing.T) {
+	grid := newGridSimple()
+	assert.Equal(t, NewNull(), grid.RowAt(0).Get("notHere"))
+}
+
+func TestGrid_ColNumbers(t *testing.T) {
+	grid := newGridSimple()
+	numbers, ok := grid.ColNumbers("val")
+	assert.True(t, ok)
+	assert.Equal(t, []Number{NewNumber(356.214, "kW"), NewNumber(463.028, "kW")}, numbers)
+
+	_, ok = grid.ColNumbers("siteName")
+	assert.False(t, ok)
+	_, ok = grid.ColNumbers("notHere")
+	assert.False(t, ok)
+}
+
+func TestGrid_ColDateTimes(t *testing.T) {
+	ts1, _ := NewDateTimeRaw(2023, 1, 1, 0, 0, 0, 0, "America/New_York")
+	ts2, _ := NewDateTimeRaw(2023, 1, 1, 0, 15, 0, 0, "America/New_York")
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("ts")
+	gb.AddColNoMeta("val")
+	gb.AddRow([]Val{ts1, NewNumber(1, "kW")})
+	gb.AddRow([]Val{ts2, NewNull()})
+	grid := gb.ToGrid()
+
+	dateTimes, ok := grid.ColDateTimes("ts")
+	assert.True(t, ok)
+	assert.Equal(t, []DateTime{ts1, ts2}, dateTimes)
+
+	// Columns with nulls don't support the fast path, but still read correctly
+	_, ok = grid.ColNumbers("val")
+	assert.False(t, ok)
+	assert.Equal(t, NewNumber(1, "kW"), grid.RowAt(0).Get("val"))
+	assert.Equal(t, NewNull(), grid.RowAt(1).Get("val"))
+}
+
+func TestGrid_mixedColumn(t *testing.T) {
+	ts, _ := NewDateTimeRaw(2023, 1, 1, 0, 0, 0, 0, "UTC")
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("val")
+	gb.AddRow([]Val{NewNull()})
+	gb.AddRow([]Val{NewNumber(1, "")})
+	gb.AddRow([]Val{ts})
+	gb.AddRow([]Val{NewStr("str")})
+	grid := gb.ToGrid()
+
+	assert.Equal(t, NewNull(), grid.RowAt(0).Get("val"))
+	assert.Equal(t, NewNumber(1, ""), grid.RowAt(1).Get("val"))
+	assert.Equal(t, ts, grid.RowAt(2).Get("val"))
+	assert.Equal(t, NewStr("str"), grid.RowAt(3).Get("val"))
+	_, ok := grid.ColNumbers("val")
+	assert.False(t, ok)
+}
+
 // Zinc representation:
 //
 //	ver:"3.0" dis:"Site Energy Summary"
@@ -390,3 +447,129 @@ func newGridNested() Grid {
 	)
 	return gb.ToGrid()
 }
+
+// legacyGrid mirrors the original row-oriented Grid representation, where each row stores a map of values and
+// columns are found with a linear scan. It is only used to benchmark against the columnar representation.
+type legacyGrid struct {
+	cols []Col
+	rows []map[string]Val
+}
+
+func (grid legacyGrid) col(name string) *Col {
+	for i := range grid.cols {
+		if grid.cols[i].name == name {
+			col := grid.cols[i]
+			return &col
+		}
+	}
+	return nil
+}
+
+const benchRowCount = 100000
+
+func newBenchHisGrid() Grid {
+	start, _ := NewDateTimeRaw(2023, 1, 1, 0, 0, 0, 0, "America/New_York")
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("ts")
+	gb.AddColNoMeta("val")
+	for i := 0; i < benchRowCount; i++ {
+		ts := NewDateTimeFromGo(start.ToGo().Add(time.Duration(i) * time.Minute))
+		gb.AddRow([]Val{ts, NewNumber(float64(i), "kW")})
+	}
+	return gb.ToGrid()
+}
+
+func newBenchLegacyHisGrid() legacyGrid {
+	start, _ := NewDateTimeRaw(2023, 1, 1, 0, 0, 0, 0, "America/New_York")
+	grid := legacyGrid{
+		cols: []Col{newCol(0, "ts", EmptyDict()), newCol(1, "val", EmptyDict())},
+	}
+	for i := 0; i < benchRowCount; i++ {
+		ts := NewDateTimeFromGo(start.ToGo().Add(time.Duration(i) * time.Minute))
+		grid.rows = append(grid.rows, map[string]Val{"ts": ts, "val": NewNumber(float64(i), "kW")})
+	}
+	return grid
+}
+
+func BenchmarkGrid_build(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		newBenchHisGrid()
+	}
+}
+
+func BenchmarkGrid_buildLegacy(b *testing.B) {
+	b.ReportAllocs()
+	for n := 0; n < b.N; n++ {
+		newBenchLegacyHisGrid()
+	}
+}
+
+func BenchmarkGrid_rowGet(b *testing.B) {
+	grid := newBenchHisGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sum := 0.0
+		for _, row := range grid.Rows() {
+			sum += row.Get("val").(Number).Float()
+		}
+	}
+}
+
+func BenchmarkGrid_rowGetLegacy(b *testing.B) {
+	grid := newBenchLegacyHisGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sum := 0.0
+		for _, row := range grid.rows {
+			sum += row["val"].(Number).Float()
+		}
+	}
+}
+
+func BenchmarkGrid_colNumbers(b *testing.B) {
+	grid := newBenchHisGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		sum := 0.0
+		numbers, _ := grid.ColNumbers("val")
+		for _, number := range numbers {
+			sum += number.Float()
+		}
+	}
+}
+
+func BenchmarkGrid_col(b *testing.B) {
+	grid := newBenchHisGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		grid.Col("val")
+	}
+}
+
+func BenchmarkGrid_colLegacy(b *testing.B) {
+	grid := newBenchLegacyHisGrid()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		grid.col("val")
+	}
+}
+
+func TestNewGridFromDicts(t *testing.T) {
+	grid := NewGridFromDicts([]Dict{
+		NewDict(map[string]Val{"id": NewRef("a", ""), "site": NewMarker()}),
+		NewDict(map[string]Val{"id": NewRef("b", ""), "dis": NewStr("B")}),
+	})
+	assert.Equal(t, 3, grid.ColCount())
+	assert.Equal(t, "dis", grid.ColAt(0).Name())
+	assert.Equal(t, "id", grid.ColAt(1).Name())
+	assert.Equal(t, "site", grid.ColAt(2).Name())
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, NewNull(), grid.RowAt(0).Get("dis"))
+	assert.Equal(t, NewStr("B"), grid.RowAt(1).Get("dis"))
+}
@@ -0,0 +1,626 @@
+package haystack
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// Marshal converts a Go value into a Haystack Val using reflection. Structs become Dicts and slices of structs
+// become Grids. Struct fields are mapped to tags using the `haystack` struct tag:
+//
+//	type Point struct {
+//		Id      haystack.Ref    `haystack:"id"`
+//		Dis     string          `haystack:"dis"`
+//		Point   bool            `haystack:"point"`              // true <-> Marker
+//		Enabled bool            `haystack:"enabled,bool"`       // true/false <-> Bool
+//		SiteRef string          `haystack:"siteRef,ref"`        // string <-> Ref
+//		MinVal  float64         `haystack:"minVal,unit=°F"`     // float64 <-> Number, checking the unit
+//		Mod     time.Time       `haystack:"mod,omitempty"`      // time.Time <-> DateTime
+//		Other   haystack.Dict   `haystack:",rest"`              // collects all tags without a matching field
+//		Ignored string          `haystack:"-"`
+//	}
+//
+// Fields without a tag use the field name with a lower-case first letter. Unexported fields are ignored.
+//
+// The supported options are:
+//   - omitempty: the tag is omitted if the field has a zero value
+//   - bool: a bool field is represented as a Bool instead of a Marker
+//   - ref: a string field is represented as a Ref instead of a Str
+//   - unit=<unit>: numeric fields are given this unit, and Unmarshal rejects Numbers with a different unit
+//   - rest: a Dict field that receives all tags that aren't mapped to another field
+//
+// Marker bool fields are only written when they are true, since a missing marker means false.
+func Marshal(v interface{}) (Val, error) {
+	return marshalVal(reflect.ValueOf(v), fieldOpts{})
+}
+
+// MarshalDict converts a struct (or pointer to struct) into a Dict. See Marshal for the field mapping rules.
+func MarshalDict(v interface{}) (Dict, error) {
+	val, err := Marshal(v)
+	if err != nil {
+		return EmptyDict(), err
+	}
+	dict, ok := val.(Dict)
+	if !ok {
+		return EmptyDict(), errors.New("haystack: cannot marshal " + reflect.TypeOf(v).String() + " into a Dict")
+	}
+	return dict, nil
+}
+
+// MarshalGrid converts a slice of structs into a Grid with one row per element. Columns follow the struct field
+// order, followed by any 'rest' tags in alphabetical order. See Marshal for the field mapping rules.
+func MarshalGrid(v interface{}) (Grid, error) {
+	rv := indirect(reflect.ValueOf(v))
+	if !rv.IsValid() || (rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array) {
+		return EmptyGrid(), errors.New("haystack: MarshalGrid requires a slice")
+	}
+	return marshalGrid(rv)
+}
+
+// Unmarshal stores the Haystack Val into the value pointed to by v, which must be a non-nil pointer. Dicts may be
+// unmarshalled into structs or maps, and Grids or Lists may be unmarshalled into slices. See Marshal for the field
+// mapping rules.
+func Unmarshal(val Val, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("haystack: Unmarshal requires a non-nil pointer")
+	}
+	return unmarshalVal(val, rv.Elem(), fieldOpts{}, "")
+}
+
+// UnmarshalDict stores the Dict into the struct pointed to by v.
+func UnmarshalDict(dict Dict, v interface{}) error {
+	return Unmarshal(dict, v)
+}
+
+// UnmarshalGrid stores the rows of the Grid into the slice pointed to by v.
+func UnmarshalGrid(grid Grid, v interface{}) error {
+	return Unmarshal(grid, v)
+}
+
+// fieldOpts are the options that can be specified in a `haystack` struct tag
+type fieldOpts struct {
+	omitEmpty bool
+	asBool    bool
+	asRef     bool
+	unit      string
+	rest      bool
+}
+
+// structField is a struct field that maps to a Haystack tag
+type structField struct {
+	name  string
+	index []int
+	opts  fieldOpts
+}
+
+// structInfo is the cached field mapping of a struct type
+type structInfo struct {
+	fields []structField
+	rest   []int // index of the 'rest' Dict field, or nil if there is none
+}
+
+var structInfoCache sync.Map // map[reflect.Type]*structInfo
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	dictType = reflect.TypeOf(Dict{})
+	valType  = reflect.TypeOf((*Val)(nil)).Elem()
+)
+
+func getStructInfo(t reflect.Type) (*structInfo, error) {
+	if cached, ok := structInfoCache.Load(t); ok {
+		return cached.(*structInfo), nil
+	}
+
+	info := &structInfo{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+		tag := field.Tag.Get("haystack")
+		if tag == "-" {
+			continue
+		}
+		name, opts := parseTag(tag)
+		if opts.rest {
+			if field.Type != dictType {
+				return nil, errors.New("haystack: 'rest' field " + field.Name + " must be a haystack.Dict")
+			}
+			info.rest = field.Index
+			continue
+		}
+		if name == "" {
+			name = lowerFirst(field.Name)
+		}
+		info.fields = append(info.fields, structField{name: name, index: field.Index, opts: opts})
+	}
+
+	structInfoCache.Store(t, info)
+	return info, nil
+}
+
+func parseTag(tag string) (string, fieldOpts) {
+	opts := fieldOpts{}
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		switch {
+		case opt == "omitempty":
+			opts.omitEmpty = true
+		case opt == "bool":
+			opts.asBool = true
+		case opt == "ref":
+			opts.asRef = true
+		case opt == "rest":
+			opts.rest = true
+		case strings.HasPrefix(opt, "unit="):
+			opts.unit = strings.TrimPrefix(opt, "unit=")
+		}
+	}
+	return parts[0], opts
+}
+
+func lowerFirst(str string) string {
+	runes := []rune(str)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func indirect(rv reflect.Value) reflect.Value {
+	for rv.IsValid() && (rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return reflect.Value{}
+		}
+		rv = rv.Elem()
+	}
+	return rv
+}
+
+// Marshalling
+
+func marshalVal(rv reflect.Value, opts fieldOpts) (Val, error) {
+	// Pointers are dereferenced first, so that fields like *Ref are stored as Ref values. Nil pointers are omitted.
+	rv = indirect(rv)
+	if !rv.IsValid() {
+		return NewNull(), nil
+	}
+	if rv.Type().Implements(valType) {
+		return rv.Interface().(Val), nil
+	}
+	if rv.Type() == timeType {
+		return NewDateTimeFromGo(rv.Interface().(time.Time)), nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		if opts.asBool {
+			return NewBool(rv.Bool()), nil
+		}
+		if rv.Bool() {
+			return NewMarker(), nil
+		}
+		return NewNull(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewNumber(float64(rv.Int()), opts.unit), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NewNumber(float64(rv.Uint()), opts.unit), nil
+	case reflect.Float32, reflect.Float64:
+		return NewNumber(rv.Float(), opts.unit), nil
+	case reflect.String:
+		if opts.asRef {
+			return NewRef(rv.String(), ""), nil
+		}
+		return NewStr(rv.String()), nil
+	case reflect.Struct:
+		return marshalStruct(rv)
+	case reflect.Map:
+		return marshalMap(rv)
+	case reflect.Slice, reflect.Array:
+		if isStructSlice(rv.Type()) {
+			return marshalGrid(rv)
+		}
+		vals := make([]Val, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			val, err := marshalVal(rv.Index(i), opts)
+			if err != nil {
+				return NewNull(), err
+			}
+			vals[i] = val
+		}
+		return NewList(vals), nil
+	}
+	return NewNull(), errors.New("haystack: cannot marshal Go type " + rv.Type().String())
+}
+
+func marshalStruct(rv reflect.Value) (Dict, error) {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return EmptyDict(), err
+	}
+
+	items := map[string]Val{}
+	if info.rest != nil {
+		rest := rv.FieldByIndex(info.rest).Interface().(Dict)
+		for name, val := range rest.items {
+			items[name] = val
+		}
+	}
+	for _, field := range info.fields {
+		fieldVal := rv.FieldByIndex(field.index)
+		if field.opts.omitEmpty && isEmptyValue(fieldVal) {
+			delete(items, field.name)
+			continue
+		}
+		val, err := marshalVal(fieldVal, field.opts)
+		if err != nil {
+			return EmptyDict(), errors.New("haystack: field " + field.name + ": " + err.Error())
+		}
+		if _, isNull := val.(Null); isNull {
+			delete(items, field.name)
+			continue
+		}
+		items[field.name] = val
+	}
+	return NewDict(items), nil
+}
+
+func marshalMap(rv reflect.Value) (Dict, error) {
+	if rv.Type().Key().Kind() != reflect.String {
+		return EmptyDict(), errors.New("haystack: cannot marshal map with non-string keys: " + rv.Type().String())
+	}
+	items := map[string]Val{}
+	iter := rv.MapRange()
+	for iter.Next() {
+		val, err := marshalVal(iter.Value(), fieldOpts{})
+		if err != nil {
+			return EmptyDict(), err
+		}
+		if _, isNull := val.(Null); isNull {
+			continue
+		}
+		items[iter.Key().String()] = val
+	}
+	return NewDict(items), nil
+}
+
+func marshalGrid(rv reflect.Value) (Grid, error) {
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	if elemType.Kind() != reflect.Struct {
+		return EmptyGrid(), errors.New("haystack: cannot marshal " + rv.Type().String() + " into a Grid")
+	}
+	info, err := getStructInfo(elemType)
+	if err != nil {
+		return EmptyGrid(), err
+	}
+
+	rows := make([]Dict, 0, rv.Len())
+	restNames := map[string]bool{}
+	for i := 0; i < rv.Len(); i++ {
+		elem := indirect(rv.Index(i))
+		if !elem.IsValid() {
+			continue
+		}
+		row, err := marshalStruct(elem)
+		if err != nil {
+			return EmptyGrid(), err
+		}
+		if info.rest != nil {
+			for name := range elem.FieldByIndex(info.rest).Interface().(Dict).items {
+				restNames[name] = true
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	gb := NewGridBuilder()
+	colNames := map[string]bool{}
+	for _, field := range info.fields {
+		gb.AddColNoMeta(field.name)
+		colNames[field.name] = true
+	}
+	sortedRest := []string{}
+	for name := range restNames {
+		if !colNames[name] {
+			sortedRest = append(sortedRest, name)
+		}
+	}
+	sort.Strings(sortedRest)
+	for _, name := range sortedRest {
+		gb.AddColNoMeta(name)
+	}
+	gb.AddRowDicts(rows)
+	return gb.ToGrid(), nil
+}
+
+func isStructSlice(t reflect.Type) bool {
+	elemType := t.Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return elemType.Kind() == reflect.Struct &&
+		elemType != timeType &&
+		!elemType.Implements(valType)
+}
+
+func isEmptyValue(rv reflect.Value) bool {
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return rv.IsNil()
+	case reflect.Struct:
+		if rv.Type() == timeType {
+			return rv.Interface().(time.Time).IsZero()
+		}
+		if rv.Type() == dictType {
+			return rv.Interface().(Dict).IsEmpty()
+		}
+	}
+	return false
+}
+
+// Unmarshalling
+
+func unmarshalVal(val Val, rv reflect.Value, opts fieldOpts, path string) error {
+	if val == nil {
+		val = NewNull()
+	}
+	if ptr, ok := val.(*Bool); ok { // ValFromJSON produces Bool pointers
+		val = *ptr
+	}
+
+	// Direct assignment of Haystack types, including Val interface fields
+	if reflect.TypeOf(val).AssignableTo(rv.Type()) {
+		rv.Set(reflect.ValueOf(val))
+		return nil
+	}
+
+	if rv.Kind() == reflect.Ptr {
+		if _, isNull := val.(Null); isNull {
+			rv.Set(reflect.Zero(rv.Type()))
+			return nil
+		}
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return unmarshalVal(val, rv.Elem(), opts, path)
+	}
+
+	if _, isNull := val.(Null); isNull {
+		rv.Set(reflect.Zero(rv.Type()))
+		return nil
+	}
+
+	if rv.Type() == timeType {
+		dateTime, ok := val.(DateTime)
+		if !ok {
+			return newUnmarshalError(val, rv, path)
+		}
+		rv.Set(reflect.ValueOf(dateTime.ToGo()))
+		return nil
+	}
+
+	switch rv.Kind() {
+	case reflect.Bool:
+		switch val := val.(type) {
+		case Marker:
+			rv.SetBool(true)
+			return nil
+		case Bool:
+			rv.SetBool(val.ToBool())
+			return nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		number, err := unmarshalNumber(val, rv, opts, path)
+		if err != nil {
+			return err
+		}
+		if number != math.Trunc(number) || rv.OverflowInt(int64(number)) {
+			return errors.New("haystack: cannot unmarshal " + val.ToZinc() + " into " + describeTarget(rv, path))
+		}
+		rv.SetInt(int64(number))
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		number, err := unmarshalNumber(val, rv, opts, path)
+		if err != nil {
+			return err
+		}
+		if number < 0 || number != math.Trunc(number) || rv.OverflowUint(uint64(number)) {
+			return errors.New("haystack: cannot unmarshal " + val.ToZinc() + " into " + describeTarget(rv, path))
+		}
+		rv.SetUint(uint64(number))
+		return nil
+	case reflect.Float32, reflect.Float64:
+		number, err := unmarshalNumber(val, rv, opts, path)
+		if err != nil {
+			return err
+		}
+		rv.SetFloat(number)
+		return nil
+	case reflect.String:
+		switch val := val.(type) {
+		case Str:
+			rv.SetString(val.String())
+			return nil
+		case Ref:
+			rv.SetString(val.Id())
+			return nil
+		case Uri:
+			rv.SetString(val.String())
+			return nil
+		case Symbol:
+			rv.SetString(val.String())
+			return nil
+		}
+	case reflect.Struct:
+		if dict, ok := val.(Dict); ok {
+			return unmarshalStruct(dict, rv, path)
+		}
+	case reflect.Map:
+		if dict, ok := val.(Dict); ok {
+			return unmarshalMap(dict, rv, path)
+		}
+	case reflect.Slice:
+		switch val := val.(type) {
+		case List:
+			return unmarshalSlice(val.vals, rv, opts, path)
+		case Grid:
+			rows := make([]Val, 0, val.RowCount())
+			for _, row := range val.Rows() {
+				rows = append(rows, row.ToDict())
+			}
+			return unmarshalSlice(rows, rv, opts, path)
+		}
+	}
+	return newUnmarshalError(val, rv, path)
+}
+
+func unmarshalNumber(val Val, rv reflect.Value, opts fieldOpts, path string) (float64, error) {
+	number, ok := val.(Number)
+	if !ok {
+		return 0, newUnmarshalError(val, rv, path)
+	}
+	if opts.unit != "" && number.Unit() != "" && number.Unit() != opts.unit {
+		return 0, errors.New("haystack: unit " + number.Unit() + " does not match expected unit " + opts.unit + " for " + describeTarget(rv, path))
+	}
+	return number.Float(), nil
+}
+
+func unmarshalStruct(dict Dict, rv reflect.Value, path string) error {
+	info, err := getStructInfo(rv.Type())
+	if err != nil {
+		return err
+	}
+
+	used := map[string]bool{}
+	for _, field := range info.fields {
+		used[field.name] = true
+		err := unmarshalVal(dict.Get(field.name), rv.FieldByIndex(field.index), field.opts, joinPath(path, field.name))
+		if err != nil {
+			return err
+		}
+	}
+	if info.rest != nil {
+		rest := map[string]Val{}
+		for name, val := range dict.items {
+			if _, isNull := val.(Null); isNull || used[name] {
+				continue
+			}
+			rest[name] = val
+		}
+		rv.FieldByIndex(info.rest).Set(reflect.ValueOf(NewDict(rest)))
+	}
+	return nil
+}
+
+func unmarshalMap(dict Dict, rv reflect.Value, path string) error {
+	if rv.Type().Key().Kind() != reflect.String {
+		return errors.New("haystack: cannot unmarshal Dict into map with non-string keys: " + rv.Type().String())
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(rv.Type()))
+	}
+	for name, val := range dict.items {
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		err := unmarshalVal(val, elem, fieldOpts{}, joinPath(path, name))
+		if err != nil {
+			return err
+		}
+		rv.SetMapIndex(reflect.ValueOf(name).Convert(rv.Type().Key()), elem)
+	}
+	return nil
+}
+
+func unmarshalSlice(vals []Val, rv reflect.Value, opts fieldOpts, path string) error {
+	slice := reflect.MakeSlice(rv.Type(), len(vals), len(vals))
+	for i, val := range vals {
+		err := unmarshalVal(val, slice.Index(i), opts, path)
+		if err != nil {
+			return err
+		}
+	}
+	rv.Set(slice)
+	return nil
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func describeTarget(rv reflect.Value, path string) string {
+	if path == "" {
+		return "Go value of type " + rv.Type().String()
+	}
+	return "field " + path + " of type " + rv.Type().String()
+}
+
+func newUnmarshalError(val Val, rv reflect.Value, path string) error {
+	return errors.New("haystack: cannot unmarshal " + kindName(val) + " into " + describeTarget(rv, path))
+}
+
+// kindName returns the Haystack kind name of the val, like "Number" or "Ref"
+func kindName(val Val) string {
+	switch val.(type) {
+	case Null:
+		return "Null"
+	case Marker:
+		return "Marker"
+	case Remove:
+		return "Remove"
+	case NA:
+		return "NA"
+	case Bool, *Bool:
+		return "Bool"
+	case Number:
+		return "Number"
+	case Str:
+		return "Str"
+	case Uri:
+		return "Uri"
+	case Ref:
+		return "Ref"
+	case Symbol:
+		return "Symbol"
+	case Date:
+		return "Date"
+	case Time:
+		return "Time"
+	case DateTime:
+		return "DateTime"
+	case Coord:
+		return "Coord"
+	case XStr:
+		return "XStr"
+	case Bin:
+		return "Bin"
+	case List:
+		return "List"
+	case Dict:
+		return "Dict"
+	case Grid:
+		return "Grid"
+	default:
+		return reflect.TypeOf(val).Name()
+	}
+}
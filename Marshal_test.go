@@ -0,0 +1,177 @@
+package haystack
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testMarshalPoint struct {
+	Id       Ref       `haystack:"id"`
+	Dis      string    `haystack:"dis"`
+	Point    bool      `haystack:"point"`
+	Writable bool      `haystack:"writable"`
+	Enabled  bool      `haystack:"enabled,bool"`
+	SiteRef  string    `haystack:"siteRef,ref"`
+	MinVal   float64   `haystack:"minVal,unit=°F"`
+	Priority int       `haystack:"priority,omitempty"`
+	Mod      time.Time `haystack:"mod,omitempty"`
+	Tags     []string  `haystack:"tags,omitempty"`
+	Other    Dict      `haystack:",rest"`
+	Ignored  string    `haystack:"-"`
+	NoTag    string
+}
+
+func TestMarshal_struct(t *testing.T) {
+	mod := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	point := testMarshalPoint{
+		Id:      NewRef("p1", ""),
+		Dis:     "Zone Temp",
+		Point:   true,
+		Enabled: false,
+		SiteRef: "s1",
+		MinVal:  50,
+		Mod:     mod,
+		Other:   NewDict(map[string]Val{"custom": NewStr("value")}),
+		Ignored: "ignored",
+		NoTag:   "untagged",
+	}
+	dict, err := MarshalDict(point)
+	assert.Nil(t, err)
+	expected := NewDict(map[string]Val{
+		"id":      NewRef("p1", ""),
+		"dis":     NewStr("Zone Temp"),
+		"point":   NewMarker(),
+		"enabled": NewBool(false),
+		"siteRef": NewRef("s1", ""),
+		"minVal":  NewNumber(50, "°F"),
+		"mod":     NewDateTimeFromGo(mod),
+		"custom":  NewStr("value"),
+		"noTag":   NewStr("untagged"),
+	})
+	assert.Equal(t, expected.ToZinc(), dict.ToZinc())
+}
+
+func TestUnmarshal_struct(t *testing.T) {
+	mod := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	dict := NewDict(map[string]Val{
+		"id":       NewRef("p1", "Zone Temp"),
+		"dis":      NewStr("Zone Temp"),
+		"point":    NewMarker(),
+		"enabled":  NewBool(true),
+		"siteRef":  NewRef("s1", ""),
+		"minVal":   NewNumber(50, "°F"),
+		"priority": NewNumber(8, ""),
+		"mod":      NewDateTimeFromGo(mod),
+		"tags":     NewList([]Val{NewStr("a"), NewStr("b")}),
+		"custom":   NewStr("value"),
+	})
+	var point testMarshalPoint
+	err := UnmarshalDict(dict, &point)
+	assert.Nil(t, err)
+	assert.Equal(t, NewRef("p1", "Zone Temp"), point.Id)
+	assert.Equal(t, "Zone Temp", point.Dis)
+	assert.True(t, point.Point)
+	assert.False(t, point.Writable)
+	assert.True(t, point.Enabled)
+	assert.Equal(t, "s1", point.SiteRef)
+	assert.Equal(t, 50.0, point.MinVal)
+	assert.Equal(t, 8, point.Priority)
+	assert.True(t, mod.Equal(point.Mod))
+	assert.Equal(t, []string{"a", "b"}, point.Tags)
+	assert.Equal(t, NewDict(map[string]Val{"custom": NewStr("value")}), point.Other)
+}
+
+func TestUnmarshal_unitMismatch(t *testing.T) {
+	dict := NewDict(map[string]Val{"minVal": NewNumber(10, "°C")})
+	var point testMarshalPoint
+	err := UnmarshalDict(dict, &point)
+	assert.NotNil(t, err)
+}
+
+func TestUnmarshal_wrongKind(t *testing.T) {
+	dict := NewDict(map[string]Val{"minVal": NewStr("10")})
+	var point testMarshalPoint
+	err := UnmarshalDict(dict, &point)
+	assert.EqualError(t, err, "haystack: cannot unmarshal Str into field minVal of type float64")
+}
+
+type testMarshalEquip struct {
+	Id   Ref    `haystack:"id"`
+	Dis  string `haystack:"dis"`
+	Site struct {
+		Area float64 `haystack:"area,unit=ft²"`
+	} `haystack:"site"`
+	Points []testMarshalPoint `haystack:"points,omitempty"`
+	Ptr    *string            `haystack:"ptr"`
+}
+
+func TestMarshal_nested(t *testing.T) {
+	equip := testMarshalEquip{Id: NewRef("e1", ""), Dis: "AHU-1"}
+	equip.Site.Area = 1000
+	dict, err := MarshalDict(&equip)
+	assert.Nil(t, err)
+	assert.Equal(t, "{dis:\"AHU-1\" id:@e1 site:{area:1000ft²}}", dict.ToZinc())
+
+	var roundTrip testMarshalEquip
+	err = Unmarshal(dict, &roundTrip)
+	assert.Nil(t, err)
+	assert.Equal(t, equip, roundTrip)
+}
+
+func TestMarshalGrid(t *testing.T) {
+	points := []testMarshalPoint{
+		{Id: NewRef("p1", ""), Dis: "Point 1", Point: true, Other: NewDict(map[string]Val{"b": NewMarker()})},
+		{Id: NewRef("p2", ""), Dis: "Point 2", Point: true, Other: NewDict(map[string]Val{"a": NewMarker()})},
+	}
+	grid, err := MarshalGrid(points)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, "a", grid.ColAt(grid.ColCount()-2).Name())
+	assert.Equal(t, "b", grid.ColAt(grid.ColCount()-1).Name())
+	assert.Equal(t, NewStr("Point 2"), grid.RowAt(1).Get("dis"))
+
+	var roundTrip []testMarshalPoint
+	err = UnmarshalGrid(grid, &roundTrip)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(roundTrip))
+	assert.Equal(t, "Point 1", roundTrip[0].Dis)
+	assert.Equal(t, NewDict(map[string]Val{"b": NewMarker()}), roundTrip[0].Other)
+}
+
+func TestUnmarshal_map(t *testing.T) {
+	dict := NewDict(map[string]Val{"a": NewNumber(1, ""), "b": NewNumber(2, "")})
+	var result map[string]float64
+	err := Unmarshal(dict, &result)
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]float64{"a": 1, "b": 2}, result)
+}
+
+func TestMarshal_pointerFields(t *testing.T) {
+	type pointerFields struct {
+		SiteRef *Ref     `haystack:"siteRef"`
+		CurVal  *Number  `haystack:"curVal"`
+		Dis     *string  `haystack:"dis"`
+		MinVal  *float64 `haystack:"minVal"`
+		Missing *Ref     `haystack:"missing"`
+	}
+	siteRef := NewRef("s1", "Site")
+	curVal := NewNumber(72, "°F")
+	dis := "Zone Temp"
+	dict, err := MarshalDict(pointerFields{SiteRef: &siteRef, CurVal: &curVal, Dis: &dis})
+	assert.Nil(t, err)
+	assert.Equal(t, siteRef, dict.Get("siteRef"))
+	assert.Equal(t, curVal, dict.Get("curVal"))
+	assert.Equal(t, NewStr("Zone Temp"), dict.Get("dis"))
+	assert.False(t, dict.Has("minVal"))
+	assert.False(t, dict.Has("missing"))
+	assert.Equal(t, "s1", dict.Get("siteRef").(Ref).Id())
+	assert.Equal(t, `{curVal:72°F dis:"Zone Temp" siteRef:@s1 "Site"}`, dict.ToZinc())
+
+	var roundTrip pointerFields
+	assert.Nil(t, UnmarshalDict(dict, &roundTrip))
+	assert.Equal(t, siteRef, *roundTrip.SiteRef)
+	assert.Equal(t, curVal, *roundTrip.CurVal)
+	assert.Nil(t, roundTrip.Missing)
+}
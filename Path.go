@@ -0,0 +1,144 @@
+package haystack
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// RefResolver looks up the entity Dict that a Ref points to.
+type RefResolver interface {
+	Resolve(ref Ref) (Dict, error)
+}
+
+// RefResolverFunc adapts a function to the RefResolver interface.
+type RefResolverFunc func(ref Ref) (Dict, error)
+
+// Resolve calls the function.
+func (f RefResolverFunc) Resolve(ref Ref) (Dict, error) {
+	return f(ref)
+}
+
+// GetPath returns the Val at the given path of nested values. Path segments are separated by '.', and each segment
+// is either a tag name of a Dict, or an index of a List or Grid row. For example: "site.area" or "points.0.dis".
+// A MissingTagError is returned if any part of the path does not exist. Paths that dereference Refs ('->') are not
+// supported; use GetPathResolve instead.
+func (dict Dict) GetPath(path string) (Val, error) {
+	return dict.GetPathResolve(path, nil)
+}
+
+// GetPathResolve returns the Val at the given path, using the resolver to follow Refs. In addition to the '.'
+// separators supported by GetPath, a '->' separator indicates that the current value is a Ref that should be
+// resolved to its entity before continuing. For example: "equipRef->siteRef->dis".
+func (dict Dict) GetPathResolve(path string, resolver RefResolver) (Val, error) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return NewNull(), err
+	}
+
+	var cur Val = dict
+	traversed := ""
+	for _, segment := range segments {
+		if segment.deref {
+			ref, ok := cur.(Ref)
+			if !ok {
+				return NewNull(), NewTagTypeError(traversed, "Ref", cur)
+			}
+			if resolver == nil {
+				return NewNull(), errors.New("path '" + path + "' dereferences a Ref but no resolver was given")
+			}
+			resolved, err := resolver.Resolve(ref)
+			if err != nil {
+				return NewNull(), err
+			}
+			cur = resolved
+			traversed = traversed + "->" + segment.name
+		} else if traversed == "" {
+			traversed = segment.name
+		} else {
+			traversed = traversed + "." + segment.name
+		}
+
+		cur, err = getPathSegment(cur, segment.name, traversed)
+		if err != nil {
+			return NewNull(), err
+		}
+	}
+	return cur, nil
+}
+
+// pathSegment is a single step in a path. If deref is true, the current value must be resolved as a Ref before
+// the name is looked up.
+type pathSegment struct {
+	name  string
+	deref bool
+}
+
+func parsePath(path string) ([]pathSegment, error) {
+	segments := []pathSegment{}
+	deref := false
+	for len(path) > 0 {
+		end := len(path)
+		sepLen := 0
+		nextDeref := false
+		if dotIndex := strings.Index(path, "."); dotIndex >= 0 {
+			end = dotIndex
+			sepLen = 1
+		}
+		if arrowIndex := strings.Index(path, "->"); arrowIndex >= 0 && arrowIndex < end {
+			end = arrowIndex
+			sepLen = 2
+			nextDeref = true
+		}
+		name := path[:end]
+		if name == "" {
+			return nil, errors.New("invalid path: empty segment")
+		}
+		segments = append(segments, pathSegment{name: name, deref: deref})
+		path = path[end+sepLen:]
+		deref = nextDeref
+		if sepLen > 0 && path == "" {
+			return nil, errors.New("invalid path: trailing separator")
+		}
+	}
+	if len(segments) == 0 {
+		return nil, errors.New("invalid path: empty")
+	}
+	return segments, nil
+}
+
+func getPathSegment(cur Val, name string, traversed string) (Val, error) {
+	switch cur := cur.(type) {
+	case Dict:
+		if cur.Missing(name) {
+			return NewNull(), NewMissingTagError(traversed)
+		}
+		return cur.Get(name), nil
+	case List:
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 0 || index >= cur.Size() {
+			return NewNull(), NewMissingTagError(traversed)
+		}
+		return cur.Get(index), nil
+	case Grid:
+		index, err := strconv.Atoi(name)
+		if err != nil || index < 0 || index >= cur.RowCount() {
+			return NewNull(), NewMissingTagError(traversed)
+		}
+		return cur.RowAt(index).ToDict(), nil
+	default:
+		return NewNull(), NewTagTypeError(parentPath(traversed), "Dict, List, or Grid", cur)
+	}
+}
+
+// parentPath strips the last segment from the path
+func parentPath(path string) string {
+	index := strings.LastIndexAny(path, ".>")
+	if index < 0 {
+		return path
+	}
+	if path[index] == '>' {
+		index = index - 1
+	}
+	return path[:index]
+}
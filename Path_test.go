@@ -0,0 +1,78 @@
+package haystack
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDict_GetPath(t *testing.T) {
+	gb := NewGridBuilder()
+	gb.AddColNoMeta("dis")
+	gb.AddRow([]Val{NewStr("Row 0")})
+	dict := NewDict(map[string]Val{
+		"site": NewDict(map[string]Val{
+			"area": NewNumber(1000, "ft²"),
+		}),
+		"points": NewList([]Val{
+			NewDict(map[string]Val{"dis": NewStr("Point 0")}),
+		}),
+		"grid": gb.ToGrid(),
+	})
+
+	val, err := dict.GetPath("site.area")
+	assert.Nil(t, err)
+	assert.Equal(t, NewNumber(1000, "ft²"), val)
+
+	val, err = dict.GetPath("points.0.dis")
+	assert.Nil(t, err)
+	assert.Equal(t, NewStr("Point 0"), val)
+
+	val, err = dict.GetPath("grid.0.dis")
+	assert.Nil(t, err)
+	assert.Equal(t, NewStr("Row 0"), val)
+
+	_, err = dict.GetPath("site.dis")
+	assert.Equal(t, NewMissingTagError("site.dis"), err)
+
+	_, err = dict.GetPath("points.1")
+	assert.Equal(t, NewMissingTagError("points.1"), err)
+
+	_, err = dict.GetPath("site.area.val")
+	assert.EqualError(t, err, "Tag type error: site.area is Number, not Dict, List, or Grid")
+
+	_, err = dict.GetPath("site.")
+	assert.NotNil(t, err)
+}
+
+func TestDict_GetPathResolve(t *testing.T) {
+	entities := map[string]Dict{
+		"e1": NewDict(map[string]Val{"id": NewRef("e1", ""), "siteRef": NewRef("s1", "")}),
+		"s1": NewDict(map[string]Val{"id": NewRef("s1", ""), "dis": NewStr("Site 1")}),
+	}
+	resolver := RefResolverFunc(func(ref Ref) (Dict, error) {
+		entity, ok := entities[ref.Id()]
+		if !ok {
+			return EmptyDict(), errors.New("not found: " + ref.Id())
+		}
+		return entity, nil
+	})
+	point := NewDict(map[string]Val{
+		"equipRef": NewRef("e1", ""),
+		"dis":      NewStr("Point"),
+	})
+
+	val, err := point.GetPathResolve("equipRef->siteRef->dis", resolver)
+	assert.Nil(t, err)
+	assert.Equal(t, NewStr("Site 1"), val)
+
+	_, err = point.GetPathResolve("equipRef->area", resolver)
+	assert.Equal(t, NewMissingTagError("equipRef->area"), err)
+
+	_, err = point.GetPathResolve("dis->siteRef", resolver)
+	assert.EqualError(t, err, "Tag type error: dis is Str, not Ref")
+
+	_, err = point.GetPath("equipRef->siteRef")
+	assert.NotNil(t, err)
+}
@@ -1,9 +1,14 @@
 package haystack
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Ref wraps a string reference identifier and display name.
@@ -17,6 +22,48 @@ func NewRef(id string, dis string) Ref {
 	return Ref{id: id, dis: dis}
 }
 
+// NewRefChecked creates a new Ref, returning an error if the id is not valid. See Validate.
+func NewRefChecked(id string, dis string) (Ref, error) {
+	ref := NewRef(id, dis)
+	return ref, ref.Validate()
+}
+
+// refGenEpoch is the epoch of generated ids, 2000-01-01T00:00:00Z, in Unix seconds
+const refGenEpoch = 946684800
+
+var refGen struct {
+	sync.Mutex
+	seconds uint32
+	counter uint32
+}
+
+// GenRef creates a new Ref with a unique id in the SkySpark format: 8 hex digits of the seconds since 2000-01-01 UTC,
+// a dash, and 8 hex digits that are random for the first id of each second and incremented for the rest, like
+// @1deb31b8-7508b187. The ids generated by a process sort in the order they were generated, even if the clock moves
+// backwards.
+func GenRef() Ref {
+	refGen.Lock()
+	defer refGen.Unlock()
+
+	seconds := uint32(time.Now().Unix() - refGenEpoch)
+	if seconds > refGen.seconds {
+		refGen.seconds = seconds
+		// Start in the lower half so that incrementing doesn't overflow
+		refGen.counter = randomUint32() >> 1
+	} else {
+		refGen.counter++
+	}
+	return NewRef(fmt.Sprintf("%08x-%08x", refGen.seconds, refGen.counter), "")
+}
+
+func randomUint32() uint32 {
+	bytes := make([]byte, 4)
+	if _, err := rand.Read(bytes); err != nil {
+		return uint32(time.Now().UnixNano())
+	}
+	return binary.BigEndian.Uint32(bytes)
+}
+
 // Id returns the ref identifier
 func (ref Ref) Id() string {
 	return ref.id
@@ -27,6 +74,122 @@ func (ref Ref) Dis() string {
 	return ref.dis
 }
 
+// Validate returns an error if the id is empty or contains a character that is not allowed. See IsRefChar.
+func (ref Ref) Validate() error {
+	if ref.id == "" {
+		return errors.New("invalid ref id: empty")
+	}
+	for _, char := range ref.id {
+		if !IsRefChar(char) {
+			return errors.New("invalid ref id: " + ref.id)
+		}
+	}
+	return nil
+}
+
+// IsValid returns true if the id is a valid ref id.
+func (ref Ref) IsValid() bool {
+	return ref.Validate() == nil
+}
+
+// IsAbsolute returns true if the id is in the absolute project form used by SkySpark: "p:<proj>:r:<id>"
+func (ref Ref) IsAbsolute() bool {
+	_, _, ok := splitAbsolute(ref.id)
+	return ok
+}
+
+// Project returns the project name of an absolute id, or an empty string if the id is relative
+func (ref Ref) Project() string {
+	proj, _, _ := splitAbsolute(ref.id)
+	return proj
+}
+
+// Relativize returns the Ref with the "p:<proj>:r:" prefix removed, if the id is absolute in the project. Refs that
+// are relative or in other projects are returned unchanged.
+func (ref Ref) Relativize(proj string) Ref {
+	refProj, id, ok := splitAbsolute(ref.id)
+	if !ok || refProj != proj {
+		return ref
+	}
+	return NewRef(id, ref.dis)
+}
+
+// Absolutize returns the Ref with a relative id prefixed by "p:<proj>:r:". Absolute Refs are returned unchanged.
+func (ref Ref) Absolutize(proj string) Ref {
+	if ref.IsAbsolute() {
+		return ref
+	}
+	return NewRef("p:"+proj+":r:"+ref.id, ref.dis)
+}
+
+func splitAbsolute(id string) (string, string, bool) {
+	if !strings.HasPrefix(id, "p:") {
+		return "", "", false
+	}
+	rest := id[2:]
+	index := strings.Index(rest, ":r:")
+	if index <= 0 {
+		return "", "", false
+	}
+	return rest[:index], rest[index+3:], true
+}
+
+// MapRefs returns the value with every Ref replaced by the result of the function. Refs are replaced throughout
+// nested Dicts, Lists and Grids, including grid and column meta.
+func MapRefs(val Val, f func(ref Ref) Ref) Val {
+	switch val := val.(type) {
+	case Ref:
+		return f(val)
+	case Dict:
+		return mapDictRefs(val, f)
+	case List:
+		vals := make([]Val, 0, val.Size())
+		for i := 0; i < val.Size(); i++ {
+			vals = append(vals, MapRefs(val.Get(i), f))
+		}
+		return NewList(vals)
+	case Grid:
+		gb := NewGridBuilder()
+		gb.SetMetaDict(mapDictRefs(val.Meta(), f))
+		for _, col := range val.Cols() {
+			gb.AddColDict(col.Name(), mapDictRefs(col.Meta(), f))
+		}
+		for _, row := range val.Rows() {
+			vals := make([]Val, 0, val.ColCount())
+			for _, col := range val.Cols() {
+				vals = append(vals, MapRefs(row.Get(col.Name()), f))
+			}
+			gb.AddRow(vals)
+		}
+		return gb.ToGrid()
+	default:
+		return val
+	}
+}
+
+func mapDictRefs(dict Dict, f func(ref Ref) Ref) Dict {
+	items := make(map[string]Val, len(dict.items))
+	for name, val := range dict.items {
+		items[name] = MapRefs(val, f)
+	}
+	return NewDict(items)
+}
+
+// RelativizeRefs returns the value with every Ref that is absolute in the project made relative. See MapRefs.
+func RelativizeRefs(val Val, proj string) Val {
+	return MapRefs(val, func(ref Ref) Ref {
+		return ref.Relativize(proj)
+	})
+}
+
+// AbsolutizeRefs returns the value with every relative Ref made absolute in the project. See MapRefs. To move
+// records between projects, relativize them from the source project and then absolutize them to the target.
+func AbsolutizeRefs(val Val, proj string) Val {
+	return MapRefs(val, func(ref Ref) Ref {
+		return ref.Absolutize(proj)
+	})
+}
+
 // ToZinc representes the object as: "@<id> \"[dis]\""
 func (ref Ref) ToZinc() string {
 	result := "@" + ref.id
@@ -91,6 +254,13 @@ func (ref Ref) MarshalHayson() ([]byte, error) {
 	return []byte(buf.String()), nil
 }
 
+// IsRefChar returns true if the character is allowed in a Ref id: an ASCII letter or digit, '_', ':', '-', '.' or '~'.
+// These are the same characters that are allowed in a Symbol.
+func IsRefChar(char rune) bool {
+	return IsIdChar(char)
+}
+
+// IsIdChar returns true if the character is allowed in a Ref or Symbol id.
 func IsIdChar(char rune) bool {
 	return ('a' <= char && char <= 'z') ||
 		('A' <= char && char <= 'Z') ||
@@ -31,3 +31,62 @@ func TestRef_MarshalHayson(t *testing.T) {
 	valTest_MarshalHayson(NewRef("123-abc", ""), "{\"_kind\":\"ref\",\"val\":\"123-abc\"}", t)
 	valTest_MarshalHayson(NewRef("123-abc", "Name"), "{\"_kind\":\"ref\",\"val\":\"123-abc\",\"dis\":\"Name\"}", t)
 }
+
+func TestRef_Validate(t *testing.T) {
+	assert.Nil(t, NewRef("123-abc", "").Validate())
+	assert.Nil(t, NewRef("p:demo:r:1deb31b8-7508b187", "").Validate())
+	assert.NotNil(t, NewRef("", "").Validate())
+	assert.NotNil(t, NewRef("123 abc", "").Validate())
+	assert.NotNil(t, NewRef("123@abc", "").Validate())
+
+	_, err := NewRefChecked("123/abc", "Name")
+	assert.NotNil(t, err)
+}
+
+func TestGenRef(t *testing.T) {
+	prev := GenRef()
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{8}$", prev.Id())
+	for i := 0; i < 100; i++ {
+		ref := GenRef()
+		assert.True(t, ref.IsValid())
+		assert.True(t, ref.Id() > prev.Id())
+		prev = ref
+	}
+}
+
+func TestRef_Relativize(t *testing.T) {
+	ref := NewRef("p:demo:r:123-abc", "Name")
+	assert.True(t, ref.IsAbsolute())
+	assert.Equal(t, "demo", ref.Project())
+	assert.Equal(t, NewRef("123-abc", "Name"), ref.Relativize("demo"))
+	assert.Equal(t, ref, ref.Relativize("other"))
+
+	rel := NewRef("123-abc", "Name")
+	assert.False(t, rel.IsAbsolute())
+	assert.Equal(t, "", rel.Project())
+	assert.Equal(t, ref, rel.Absolutize("demo"))
+	assert.Equal(t, ref, ref.Absolutize("other"))
+}
+
+func TestRelativizeRefs(t *testing.T) {
+	gb := NewGridBuilder()
+	gb.AddMetaVal("view", NewRef("p:demo:r:view", ""))
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("equipRef")
+	gb.AddColNoMeta("refs")
+	gb.AddRow([]Val{
+		NewRef("p:demo:r:a", "A"),
+		NewRef("p:other:r:b", ""),
+		NewList([]Val{NewRef("p:demo:r:c", ""), NewStr("c")}),
+	})
+	grid := RelativizeRefs(gb.ToGrid(), "demo").(Grid)
+
+	assert.Equal(t, NewRef("view", ""), grid.Meta().Get("view"))
+	row := grid.RowAt(0)
+	assert.Equal(t, NewRef("a", "A"), row.Get("id"))
+	assert.Equal(t, NewRef("p:other:r:b", ""), row.Get("equipRef"))
+	assert.Equal(t, NewList([]Val{NewRef("c", ""), NewStr("c")}), row.Get("refs"))
+
+	moved := AbsolutizeRefs(NewDict(map[string]Val{"siteRef": NewRef("a", "")}), "target").(Dict)
+	assert.Equal(t, NewRef("p:target:r:a", ""), moved.Get("siteRef"))
+}
@@ -0,0 +1,101 @@
+package haystack
+
+import (
+	"errors"
+	"strings"
+)
+
+// Span is a range of time from a start DateTime, inclusive, to an end DateTime, exclusive. It is used for history
+// reads, like the 'hisRead' op's range.
+type Span struct {
+	start DateTime
+	end   DateTime
+}
+
+// NewSpan creates a new Span. An error is returned if the end is before the start.
+func NewSpan(start DateTime, end DateTime) (Span, error) {
+	if end.Before(start) {
+		return Span{}, errors.New("span end is before start: " + start.ToZinc() + "," + end.ToZinc())
+	}
+	return Span{start: start, end: end}, nil
+}
+
+// NewSpanDate creates a Span of the whole day in the timezone.
+func NewSpanDate(date Date, tz Tz) Span {
+	return Span{start: date.Midnight(tz), end: date.PlusDays(1).Midnight(tz)}
+}
+
+// NewSpanDates creates a Span from the start of the first date to the end of the last date, in the timezone. An error
+// is returned if the last date is before the first.
+func NewSpanDates(from Date, to Date, tz Tz) (Span, error) {
+	return NewSpan(from.Midnight(tz), to.PlusDays(1).Midnight(tz))
+}
+
+// ParseSpan parses a 'hisRead' range string, using the timezone for dates and now for the relative ranges. Supported
+// formats are:
+//
+//   - "today", "yesterday"
+//   - "<date>" or "<date>,<date>", like "2021-01-01,2021-01-31"
+//   - "<dateTime>" or "<dateTime>,<dateTime>", where a single DateTime spans to now
+func ParseSpan(str string, tz Tz, now DateTime) (Span, error) {
+	str = strings.TrimSpace(str)
+	today := NewDateFromGo(now.ToTimezone(tz).ToGo())
+	switch str {
+	case "today":
+		return NewSpanDate(today, tz), nil
+	case "yesterday":
+		return NewSpanDate(today.MinusDays(1), tz), nil
+	}
+
+	parts := strings.Split(str, ",")
+	if len(parts) > 2 {
+		return Span{}, errors.New("invalid span: " + str)
+	}
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+
+	if from, err := NewDateFromIso(parts[0]); err == nil {
+		to := from
+		if len(parts) == 2 {
+			to, err = NewDateFromIso(parts[1])
+			if err != nil {
+				return Span{}, errors.New("invalid span: " + str)
+			}
+		}
+		return NewSpanDates(from, to, tz)
+	}
+
+	start, err := NewDateTimeFromString(parts[0])
+	if err != nil {
+		return Span{}, errors.New("invalid span: " + str)
+	}
+	end := now
+	if len(parts) == 2 {
+		end, err = NewDateTimeFromString(parts[1])
+		if err != nil {
+			return Span{}, errors.New("invalid span: " + str)
+		}
+	}
+	return NewSpan(start, end)
+}
+
+// Start returns the first instant of the span
+func (span Span) Start() DateTime {
+	return span.start
+}
+
+// End returns the instant after the end of the span
+func (span Span) End() DateTime {
+	return span.end
+}
+
+// Contains returns true if the DateTime is in the span
+func (span Span) Contains(dateTime DateTime) bool {
+	return !dateTime.Before(span.start) && dateTime.Before(span.end)
+}
+
+// ToZinc represents the object as a 'hisRead' range string: "<start>,<end>"
+func (span Span) ToZinc() string {
+	return span.start.ToZinc() + "," + span.end.ToZinc()
+}
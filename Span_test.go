@@ -0,0 +1,45 @@
+package haystack
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpan_Contains(t *testing.T) {
+	tz, _ := NewTz("New_York")
+	span := NewSpanDate(NewDate(2021, 3, 14), tz)
+	assert.Equal(t, "2021-03-14T00:00:00-05:00 New_York", span.Start().ToZinc())
+	assert.Equal(t, "2021-03-15T00:00:00-04:00 New_York", span.End().ToZinc())
+	assert.True(t, span.Contains(span.Start()))
+	assert.False(t, span.Contains(span.End()))
+
+	_, err := NewSpan(span.End(), span.Start())
+	assert.NotNil(t, err)
+}
+
+func TestParseSpan(t *testing.T) {
+	tz, _ := NewTz("New_York")
+	now, _ := NewDateTimeRaw(2021, 6, 2, 1, 30, 0, 0, "UTC")
+
+	span, err := ParseSpan("today", tz, now)
+	assert.Nil(t, err)
+	assert.Equal(t, NewSpanDate(NewDate(2021, 6, 1), tz), span)
+
+	span, err = ParseSpan("yesterday", tz, now)
+	assert.Nil(t, err)
+	assert.Equal(t, NewSpanDate(NewDate(2021, 5, 31), tz), span)
+
+	span, err = ParseSpan("2021-01-01, 2021-01-31", tz, now)
+	assert.Nil(t, err)
+	assert.Equal(t, "2021-01-01T00:00:00-05:00 New_York,2021-02-01T00:00:00-05:00 New_York", span.ToZinc())
+
+	span, err = ParseSpan("2021-06-01T00:00:00Z UTC", tz, now)
+	assert.Nil(t, err)
+	assert.Equal(t, now, span.End())
+
+	_, err = ParseSpan("2021-01-31,2021-01-01", tz, now)
+	assert.NotNil(t, err)
+	_, err = ParseSpan("lastWeek", tz, now)
+	assert.NotNil(t, err)
+}
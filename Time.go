@@ -26,9 +26,19 @@ func NewTime(hour int, min int, sec int, ms int) Time {
 	}
 }
 
-// NewTimeFromIso creates a Time object from a string in the format: "hh:mm:ss[.mmm]"
+// NewTimeChecked creates a new Time object, returning an error if the values are not a valid time of day.
+func NewTimeChecked(hour int, min int, sec int, ms int) (Time, error) {
+	time := NewTime(hour, min, sec, ms)
+	return time, time.Validate()
+}
+
+// NewTimeFromIso creates a Time object from a string in the format: "hh:mm:ss[.mmm]". An error is returned if the
+// string is malformed or is not a valid time of day.
 func NewTimeFromIso(str string) (Time, error) {
 	parts := strings.Split(str, ":")
+	if len(parts) != 3 {
+		return Time{}, errors.New("invalid time format: " + str)
+	}
 
 	hour, hourErr := strconv.Atoi(parts[0])
 	if hourErr != nil {
@@ -76,7 +86,7 @@ func NewTimeFromIso(str string) (Time, error) {
 		sec = secVal
 	}
 
-	return NewTime(hour, min, sec, ms), nil
+	return NewTimeChecked(hour, min, sec, ms)
 }
 
 // Hour returns the hours of the object.
@@ -99,6 +109,37 @@ func (time Time) Millis() int {
 	return time.ms
 }
 
+// Validate returns an error if the time is not a valid time of day, like 24:00:00.
+func (time Time) Validate() error {
+	if time.hour < 0 || time.hour > 23 ||
+		time.min < 0 || time.min > 59 ||
+		time.sec < 0 || time.sec > 59 ||
+		time.ms < 0 || time.ms > 999 {
+		return errors.New("invalid time: " + time.toIso())
+	}
+	return nil
+}
+
+// IsValid returns true if the time is a valid time of day.
+func (time Time) IsValid() bool {
+	return time.Validate() == nil
+}
+
+// Before returns true if the time is earlier in the day than the other time.
+func (time Time) Before(other Time) bool {
+	return time.millisOfDay() < other.millisOfDay()
+}
+
+// After returns true if the time is later in the day than the other time.
+func (time Time) After(other Time) bool {
+	return time.millisOfDay() > other.millisOfDay()
+}
+
+// Equal returns true if the times are the same time of day.
+func (time Time) Equal(other Time) bool {
+	return time.equals(other)
+}
+
 // ToZinc representes the object as: "hh:mm:ss[.mmm]"
 func (time Time) ToZinc() string {
 	return time.toIso()
@@ -147,6 +188,10 @@ func (time Time) toIso() string {
 	return result
 }
 
+func (time Time) millisOfDay() int {
+	return ((time.hour*60+time.min)*60+time.sec)*1000 + time.ms
+}
+
 func (time Time) equals(otherTime Time) bool {
 	return time.hour == otherTime.hour &&
 		time.min == otherTime.min &&
@@ -100,3 +100,27 @@ func TestTime_MarshalHayson(t *testing.T) {
 	valTest_MarshalHayson(NewTime(23, 7, 10, 56), "{\"_kind\":\"time\",\"val\":\"23:07:10.056\"}", t)
 	valTest_MarshalHayson(NewTime(23, 7, 10, 957), "{\"_kind\":\"time\",\"val\":\"23:07:10.957\"}", t)
 }
+
+func TestTime_Validate(t *testing.T) {
+	assert.Nil(t, NewTime(23, 59, 59, 999).Validate())
+	assert.NotNil(t, NewTime(24, 0, 0, 0).Validate())
+	assert.NotNil(t, NewTime(12, 60, 0, 0).Validate())
+	assert.NotNil(t, NewTime(12, 0, 60, 0).Validate())
+	assert.NotNil(t, NewTime(12, 0, 0, 1000).Validate())
+	assert.False(t, NewTime(-1, 0, 0, 0).IsValid())
+
+	_, err := NewTimeChecked(25, 0, 0, 0)
+	assert.NotNil(t, err)
+	_, err = NewTimeFromIso("25:00:00")
+	assert.NotNil(t, err)
+	_, err = NewTimeFromIso("12:00")
+	assert.NotNil(t, err)
+}
+
+func TestTime_Compare(t *testing.T) {
+	time := NewTime(12, 30, 0, 0)
+	assert.True(t, time.Before(NewTime(12, 30, 0, 1)))
+	assert.False(t, time.Before(time))
+	assert.True(t, time.After(NewTime(9, 59, 59, 999)))
+	assert.True(t, time.Equal(NewTime(12, 30, 0, 0)))
+}
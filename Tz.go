@@ -0,0 +1,219 @@
+package haystack
+
+//go:generate go run tzgen.go
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tz models a Haystack timezone. Haystack timezone names are the city portion of the IANA zone name, like
+// "New_York" for "America/New_York", and are only defined for zones in the Africa, America, Antarctica, Asia,
+// Atlantic, Australia, Etc, Europe, Indian, and Pacific regions. There are a few special cases:
+//
+//   - "UTC" is Coordinated Universal Time
+//   - "GMT+N" and "GMT-N" are the fixed offset "Etc/GMT+N" zones. Note that these use the POSIX sign convention,
+//     so they are inverted from the offset: "GMT+5" is 5 hours behind UTC (-05:00).
+//   - "Rel" is the relative timezone used for timestamps that are not tied to a specific location. It has an
+//     offset of zero.
+//
+// The timezone tables are generated from the IANA database. See tzgen.go.
+type Tz struct {
+	name     string
+	fullName string
+	loc      *time.Location
+}
+
+const (
+	// TzUTC is the name of the UTC timezone
+	TzUTC = "UTC"
+	// TzRel is the name of the relative timezone
+	TzRel = "Rel"
+)
+
+var relLocation = time.FixedZone(TzRel, 0)
+
+// tzCache caches loaded timezones by the name used to look them up
+var tzCache sync.Map // map[string]Tz
+
+// NewTz returns the timezone with the given name. Either the Haystack name (like "New_York") or the IANA name (like
+// "America/New_York") may be used. Links in the Haystack regions keep their own name (like "Kralendijk" for
+// "America/Kralendijk"), and other IANA link names (like "US/Eastern") are resolved to their canonical zone. An
+// UnknownTzError is returned if the timezone doesn't exist.
+func NewTz(name string) (Tz, error) {
+	if cached, ok := tzCache.Load(name); ok {
+		return cached.(Tz), nil
+	}
+
+	tz, err := lookupTz(name)
+	if err != nil {
+		return Tz{}, err
+	}
+	tzCache.Store(name, tz)
+	return tz, nil
+}
+
+// UTCTz returns the UTC timezone.
+func UTCTz() Tz {
+	return Tz{name: TzUTC, fullName: "Etc/UTC", loc: time.UTC}
+}
+
+// RelTz returns the relative timezone.
+func RelTz() Tz {
+	return Tz{name: TzRel, fullName: TzRel, loc: relLocation}
+}
+
+// NewTzFromLocation returns the timezone of the Go location. Locations with a fixed offset of whole hours are mapped
+// to the matching "GMT+N" or "GMT-N" timezone. An UnknownTzError is returned if the location has no Haystack
+// equivalent.
+func NewTzFromLocation(loc *time.Location) (Tz, error) {
+	if loc == nil || loc == time.UTC {
+		return UTCTz(), nil
+	}
+	if loc == relLocation {
+		return RelTz(), nil
+	}
+	name := loc.String()
+	tz, err := NewTz(name)
+	if err == nil {
+		return tz, nil
+	}
+
+	// Check for a fixed offset location
+	if offset, ok := fixedOffset(loc); ok {
+		offsetTz, offsetErr := NewTzFromOffset(offset)
+		if offsetErr == nil {
+			return offsetTz, nil
+		}
+	}
+	return Tz{}, err
+}
+
+// fixedOffset returns the offset of the location if it never changes. The offset is compared at the start of the Go
+// calendar, before any zone transitions, and in the winter and summer of a far future year, after them.
+func fixedOffset(loc *time.Location) (int, bool) {
+	_, offset := time.Date(1, time.January, 1, 0, 0, 0, 0, loc).Zone()
+	for _, month := range []time.Month{time.January, time.July} {
+		if _, other := time.Date(3000, month, 1, 0, 0, 0, 0, loc).Zone(); other != offset {
+			return 0, false
+		}
+	}
+	return offset, true
+}
+
+// NewTzFromOffset returns the fixed offset timezone for the given offset in seconds east of UTC. Only whole hour
+// offsets are supported. Note that the Haystack names are inverted: an offset of -5 hours is "GMT+5".
+func NewTzFromOffset(offset int) (Tz, error) {
+	if offset == 0 {
+		return UTCTz(), nil
+	}
+	if offset%3600 != 0 {
+		return Tz{}, NewUnknownTzError("offset " + strconv.Itoa(offset) + "s")
+	}
+	hours := offset / 3600
+	if hours < 0 {
+		return NewTz("GMT+" + strconv.Itoa(-hours))
+	}
+	return NewTz("GMT-" + strconv.Itoa(hours))
+}
+
+func lookupTz(name string) (Tz, error) {
+	switch name {
+	case TzUTC, "Etc/UTC":
+		return UTCTz(), nil
+	case TzRel:
+		return RelTz(), nil
+	}
+
+	// Link zones keep their own Haystack name, so that it round-trips
+	if link, ok := tzLinkNames[name]; ok {
+		return loadLinkTz(name, link)
+	}
+	if link, ok := tzLinkNames[tzShortName(name)]; ok && link == name {
+		return loadLinkTz(tzShortName(name), link)
+	}
+
+	fullName, ok := tzNames[name]
+	if !ok {
+		if strings.Contains(name, "/") && isCanonicalTz(name) {
+			fullName = name
+		} else if alias, ok := tzAliases[name]; ok {
+			fullName = alias
+		} else {
+			return Tz{}, NewUnknownTzError(name)
+		}
+	}
+	if fullName == "Etc/UTC" {
+		return UTCTz(), nil
+	}
+
+	loc, err := time.LoadLocation(fullName)
+	if err != nil {
+		return Tz{}, NewUnknownTzError(name)
+	}
+	return Tz{name: tzShortName(fullName), fullName: fullName, loc: loc}, nil
+}
+
+// loadLinkTz loads the timezone of a link zone. The link is loaded by its own name, which Go reports as the location
+// name, but its rules are those of the canonical zone it links to.
+func loadLinkTz(name string, link string) (Tz, error) {
+	loc, err := time.LoadLocation(link)
+	if err != nil {
+		loc, err = time.LoadLocation(tzAliases[link])
+		if err != nil {
+			return Tz{}, NewUnknownTzError(name)
+		}
+	}
+	return Tz{name: name, fullName: link, loc: loc}, nil
+}
+
+func isCanonicalTz(fullName string) bool {
+	return tzNames[tzShortName(fullName)] == fullName
+}
+
+func tzShortName(fullName string) string {
+	split := strings.Split(fullName, "/")
+	return split[len(split)-1]
+}
+
+// Name returns the Haystack name of the timezone, like "New_York"
+func (tz Tz) Name() string {
+	return tz.name
+}
+
+// FullName returns the IANA name of the timezone, like "America/New_York"
+func (tz Tz) FullName() string {
+	return tz.fullName
+}
+
+// Location returns the Go location of the timezone
+func (tz Tz) Location() *time.Location {
+	if tz.loc == nil {
+		return time.UTC
+	}
+	return tz.loc
+}
+
+// String returns the Haystack name of the timezone
+func (tz Tz) String() string {
+	return tz.name
+}
+
+// TzNames returns the Haystack names of all known timezones.
+func TzNames() []string {
+	names := []string{TzRel}
+	for name := range tzNames {
+		names = append(names, name)
+	}
+	for name := range tzLinkNames {
+		names = append(names, name)
+	}
+	return names
+}
+
+// TzDatabaseVersion returns the version of the IANA timezone database that the timezone tables are generated from.
+func TzDatabaseVersion() string {
+	return tzdbVersion
+}
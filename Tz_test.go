@@ -0,0 +1,159 @@
+package haystack
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTz(t *testing.T) {
+	newYork, err := NewTz("New_York")
+	assert.Nil(t, err)
+	assert.Equal(t, "New_York", newYork.Name())
+	assert.Equal(t, "America/New_York", newYork.FullName())
+	assert.Equal(t, "America/New_York", newYork.Location().String())
+
+	fullName, err := NewTz("America/New_York")
+	assert.Nil(t, err)
+	assert.Equal(t, newYork, fullName)
+
+	link, err := NewTz("US/Eastern")
+	assert.Nil(t, err)
+	assert.Equal(t, "New_York", link.Name())
+}
+
+func TestNewTz_cityCollision(t *testing.T) {
+	buenosAires, err := NewTz("Buenos_Aires")
+	assert.Nil(t, err)
+	assert.Equal(t, "America/Argentina/Buenos_Aires", buenosAires.FullName())
+
+	// The backward-compatible link resolves to the canonical zone
+	link, err := NewTz("America/Buenos_Aires")
+	assert.Nil(t, err)
+	assert.Equal(t, "Buenos_Aires", link.Name())
+	assert.Equal(t, "America/Argentina/Buenos_Aires", link.FullName())
+}
+
+func TestNewTz_link(t *testing.T) {
+	kralendijk, err := NewTz("Kralendijk")
+	assert.Nil(t, err)
+	assert.Equal(t, "Kralendijk", kralendijk.Name())
+	assert.Equal(t, "America/Kralendijk", kralendijk.FullName())
+
+	fullName, err := NewTz("America/Kralendijk")
+	assert.Nil(t, err)
+	assert.Equal(t, kralendijk, fullName)
+
+	// The link has the rules of its canonical zone
+	puertoRico, err := NewTz("Puerto_Rico")
+	assert.Nil(t, err)
+	instant := time.Date(2023, 3, 1, 12, 0, 0, 0, time.UTC)
+	_, offset := instant.In(kralendijk.Location()).Zone()
+	_, puertoRicoOffset := instant.In(puertoRico.Location()).Zone()
+	assert.Equal(t, puertoRicoOffset, offset)
+
+	fromLocation, err := NewTzFromLocation(kralendijk.Location())
+	assert.Nil(t, err)
+	assert.Equal(t, "Kralendijk", fromLocation.Name())
+
+	vatican, err := NewTz("Vatican")
+	assert.Nil(t, err)
+	assert.Equal(t, "Europe/Vatican", vatican.FullName())
+}
+
+func TestNewTz_gmt(t *testing.T) {
+	gmtPlus5, err := NewTz("GMT+5")
+	assert.Nil(t, err)
+	assert.Equal(t, "Etc/GMT+5", gmtPlus5.FullName())
+	_, offset := time.Date(2023, 1, 1, 0, 0, 0, 0, gmtPlus5.Location()).Zone()
+	assert.Equal(t, -5*3600, offset)
+
+	gmtMinus3, err := NewTz("Etc/GMT-3")
+	assert.Nil(t, err)
+	assert.Equal(t, "GMT-3", gmtMinus3.Name())
+	_, offset = time.Date(2023, 1, 1, 0, 0, 0, 0, gmtMinus3.Location()).Zone()
+	assert.Equal(t, 3*3600, offset)
+}
+
+func TestNewTz_special(t *testing.T) {
+	utc, err := NewTz("UTC")
+	assert.Nil(t, err)
+	assert.Equal(t, UTCTz(), utc)
+	assert.Equal(t, time.UTC, utc.Location())
+
+	rel, err := NewTz("Rel")
+	assert.Nil(t, err)
+	assert.Equal(t, "Rel", rel.Name())
+	_, offset := time.Date(2023, 1, 1, 0, 0, 0, 0, rel.Location()).Zone()
+	assert.Equal(t, 0, offset)
+}
+
+func TestNewTz_unknown(t *testing.T) {
+	_, err := NewTz("Atlantis")
+	assert.Equal(t, NewUnknownTzError("Atlantis"), err)
+}
+
+func TestNewTzFromOffset(t *testing.T) {
+	tz, err := NewTzFromOffset(-5 * 3600)
+	assert.Nil(t, err)
+	assert.Equal(t, "GMT+5", tz.Name())
+
+	tz, err = NewTzFromOffset(3 * 3600)
+	assert.Nil(t, err)
+	assert.Equal(t, "GMT-3", tz.Name())
+
+	tz, err = NewTzFromOffset(0)
+	assert.Nil(t, err)
+	assert.Equal(t, "UTC", tz.Name())
+
+	_, err = NewTzFromOffset(5*3600 + 1800)
+	assert.NotNil(t, err)
+}
+
+func TestNewTzFromLocation(t *testing.T) {
+	tz, err := NewTzFromLocation(time.FixedZone("", -3*3600))
+	assert.Nil(t, err)
+	assert.Equal(t, "GMT+3", tz.Name())
+
+	loc, _ := time.LoadLocation("Asia/Kolkata")
+	tz, err = NewTzFromLocation(loc)
+	assert.Nil(t, err)
+	assert.Equal(t, "Kolkata", tz.Name())
+
+	// A location is only mapped to an offset timezone if its offset never changes
+	newYork, _ := time.LoadLocation("America/New_York")
+	data, err := ioutil.ReadFile("/usr/share/zoneinfo/America/New_York")
+	if err == nil {
+		custom, err := time.LoadLocationFromTZData("Custom", data)
+		assert.Nil(t, err)
+		_, err = NewTzFromLocation(custom)
+		assert.Equal(t, NewUnknownTzError("Custom"), err)
+	}
+	tz, err = NewTzFromLocation(newYork)
+	assert.Nil(t, err)
+	assert.Equal(t, "New_York", tz.Name())
+}
+
+func TestDateTime_tzRoundTrip(t *testing.T) {
+	for _, str := range []string{
+		"2023-03-01T12:00:00-03:00 Buenos_Aires",
+		"2023-03-01T12:00:00-05:00 GMT+5",
+		"2023-03-01T12:00:00+03:00 GMT-3",
+		"2023-03-01T12:00:00Z Rel",
+		"2023-03-01T12:00:00Z UTC",
+		"2023-03-01T12:00:00-04:00 Kralendijk",
+		"2023-03-01T12:00:00-04:00 Lower_Princes",
+		"2023-03-01T12:00:00-04:00 Marigot",
+		"2023-03-01T12:00:00-04:00 St_Barthelemy",
+		"2023-03-01T12:00:00+01:00 Vatican",
+	} {
+		dateTime, err := NewDateTimeFromString(str)
+		assert.Nil(t, err)
+		assert.Equal(t, str, dateTime.ToZinc())
+	}
+
+	_, err := NewDateTimeFromString("2023-03-01T12:00:00Z Atlantis")
+	assert.Equal(t, NewUnknownTzError("Atlantis"), err)
+}
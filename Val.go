@@ -63,3 +63,25 @@ func ValFromJSON(jsonObj interface{}) (Val, error) {
 		return nil, errors.New("JSON type doesn't correlate to any haystack type: " + reflect.TypeOf(typedObj).Name())
 	}
 }
+
+// ValEquals returns true if the two Vals are equal. Refs are compared by id only, ignoring their display names, and
+// DateTimes are equal if they are the same instant in the same timezone. Other values are compared by their Zinc
+// representation. A nil Val is considered equal to Null.
+func ValEquals(a Val, b Val) bool {
+	if a == nil {
+		a = NewNull()
+	}
+	if b == nil {
+		b = NewNull()
+	}
+	switch a := a.(type) {
+	case Ref:
+		bRef, ok := b.(Ref)
+		return ok && a.id == bRef.id
+	case DateTime:
+		bDateTime, ok := b.(DateTime)
+		return ok && a.time.Equal(bDateTime.time) && a.Tz() == bDateTime.Tz()
+	default:
+		return a.ToZinc() == b.ToZinc()
+	}
+}
@@ -23,3 +23,16 @@ func valTest_MarshalHayson(val Val, expected string, t *testing.T) {
 	actual := string(bytes)
 	assert.Equal(t, actual, expected)
 }
+
+func TestValEquals(t *testing.T) {
+	assert.True(t, ValEquals(NewNumber(1, "kW"), NewNumber(1, "kW")))
+	assert.False(t, ValEquals(NewNumber(1, "kW"), NewNumber(1, "W")))
+	assert.True(t, ValEquals(NewRef("a", "A"), NewRef("a", "Other")))
+	assert.False(t, ValEquals(NewRef("a", ""), NewStr("a")))
+	assert.True(t, ValEquals(nil, NewNull()))
+	assert.True(t, ValEquals(NewBool(true), &Bool{val: true}))
+	assert.True(t, ValEquals(
+		NewDict(map[string]Val{"a": NewMarker(), "b": NewStr("b")}),
+		NewDict(map[string]Val{"b": NewStr("b"), "a": NewMarker()}),
+	))
+}
@@ -0,0 +1,90 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Writer makes changes on a Haystack server. It is implemented by client.Client.
+type Writer interface {
+	PointWriteStatus(id haystack.Ref) (haystack.Grid, error)
+	PointWrite(id haystack.Ref, level int, val haystack.Val, who string, duration haystack.Number) (haystack.Grid, error)
+	HisWrite(id haystack.Ref, hisItems []haystack.Dict) (haystack.Grid, error)
+	InvokeAction(id haystack.Ref, action string, args map[string]haystack.Val) (haystack.Grid, error)
+}
+
+// Client records the changes made through a Writer, such as a client.Client, to a log. Entries are recorded with the
+// Client's user, and failed calls are recorded with their error. If a call succeeds but can't be recorded, its result
+// is returned with the error.
+type Client struct {
+	writer Writer
+	log    *Log
+	user   string
+}
+
+// NewClient creates a Client that records the changes made by the user through the writer.
+func NewClient(writer Writer, log *Log, user string) *Client {
+	return &Client{writer: writer, log: log, user: user}
+}
+
+// PointWrite calls the 'pointWrite' op, recording the value of the level before the write.
+func (auditClient *Client) PointWrite(
+	id haystack.Ref,
+	level int,
+	val haystack.Val,
+	who string,
+	duration haystack.Number,
+) (haystack.Grid, error) {
+	var before haystack.Val = haystack.NewNull()
+	if status, err := auditClient.writer.PointWriteStatus(id); err == nil {
+		before = statusLevelVal(status, level)
+	}
+	grid, err := auditClient.writer.PointWrite(id, level, val, who, duration)
+	entry := Entry{Op: "pointWrite", Id: id, Level: level, Before: before, After: val}
+	return grid, auditClient.record(entry, err)
+}
+
+// PointWriteDuration calls the 'pointWrite' op with a duration given as a Go duration.
+func (auditClient *Client) PointWriteDuration(
+	id haystack.Ref,
+	level int,
+	val haystack.Val,
+	who string,
+	duration time.Duration,
+) (haystack.Grid, error) {
+	return auditClient.PointWrite(id, level, val, who, haystack.NewNumberFromDuration(duration))
+}
+
+// HisWrite calls the 'hisWrite' op, recording the number of items written.
+func (auditClient *Client) HisWrite(id haystack.Ref, hisItems []haystack.Dict) (haystack.Grid, error) {
+	grid, err := auditClient.writer.HisWrite(id, hisItems)
+	entry := Entry{Op: "hisWrite", Id: id, Before: haystack.NewNull(), After: haystack.NewNumber(float64(len(hisItems)), "")}
+	return grid, auditClient.record(entry, err)
+}
+
+// InvokeAction calls the 'invokeAction' op, recording the action and its arguments.
+func (auditClient *Client) InvokeAction(id haystack.Ref, action string, args map[string]haystack.Val) (haystack.Grid, error) {
+	grid, err := auditClient.writer.InvokeAction(id, action, args)
+	entry := Entry{
+		Op:     "invokeAction",
+		Id:     id,
+		Action: action,
+		Before: haystack.NewNull(),
+		After:  haystack.EmptyDict().Patch(haystack.NewDict(args)),
+	}
+	return grid, auditClient.record(entry, err)
+}
+
+// record records the entry with the call error, returning the call error or else the recording error
+func (auditClient *Client) record(entry Entry, callErr error) error {
+	entry.User = auditClient.user
+	if callErr != nil {
+		entry.Err = callErr.Error()
+	}
+	err := auditClient.log.Record(entry)
+	if callErr != nil {
+		return callErr
+	}
+	return err
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/client"
+	"github.com/NeedleInAJayStack/haystack/point"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeWriter struct {
+	array *point.PriorityArray
+}
+
+func (writer *fakeWriter) PointWriteStatus(id haystack.Ref) (haystack.Grid, error) {
+	return writer.array.ToGrid(), nil
+}
+
+func (writer *fakeWriter) PointWrite(id haystack.Ref, level int, val haystack.Val, who string, duration haystack.Number) (haystack.Grid, error) {
+	return haystack.EmptyGrid(), writer.array.Write(level, val, who, 0)
+}
+
+func (writer *fakeWriter) HisWrite(id haystack.Ref, hisItems []haystack.Dict) (haystack.Grid, error) {
+	return haystack.EmptyGrid(), nil
+}
+
+func (writer *fakeWriter) InvokeAction(id haystack.Ref, action string, args map[string]haystack.Val) (haystack.Grid, error) {
+	return haystack.EmptyGrid(), errors.New("unknown action: " + action)
+}
+
+func TestClient(t *testing.T) {
+	var _ Writer = client.NewClient("http://localhost:8080/api/demo", "alice", "secret")
+
+	log, _ := testLog(t)
+	defer log.Close()
+	auditClient := NewClient(&fakeWriter{array: point.NewPriorityArray(haystack.NewNull())}, log, "alice")
+	id := haystack.NewRef("p1", "")
+
+	_, err := auditClient.PointWriteDuration(id, 8, haystack.NewNumber(72, "°F"), "alice", time.Hour)
+	assert.Nil(t, err)
+	_, err = auditClient.PointWrite(id, 8, haystack.NewNumber(74, "°F"), "alice", haystack.NewNumber(0, ""))
+	assert.Nil(t, err)
+	_, err = auditClient.HisWrite(id, []haystack.Dict{haystack.EmptyDict(), haystack.EmptyDict()})
+	assert.Nil(t, err)
+	_, err = auditClient.InvokeAction(id, "reset", map[string]haystack.Val{})
+	assert.NotNil(t, err)
+
+	grid, err := log.Query(allTime(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("alice"), grid.RowAt(0).Get("user"))
+	assert.Equal(t, haystack.NewNumber(72, "°F"), grid.RowAt(1).Get("before"))
+	assert.Equal(t, haystack.NewNumber(74, "°F"), grid.RowAt(1).Get("after"))
+	assert.Equal(t, haystack.NewNumber(2, ""), grid.RowAt(2).Get("after"))
+	assert.Equal(t, haystack.NewStr("unknown action: reset"), grid.RowAt(3).Get("err"))
+}
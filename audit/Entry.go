@@ -0,0 +1,109 @@
+package audit
+
+import (
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Ops that are recorded in addition to the Haystack ops 'pointWrite', 'hisWrite' and 'invokeAction'
+const (
+	// OpCommit is the op of a change to a record in a store. The entry's Action is the store.DiffOp.
+	OpCommit = "commit"
+)
+
+// Entry is a single change recorded in the audit log.
+type Entry struct {
+	Ts   haystack.DateTime
+	User string
+	Op   string
+	Id   haystack.Ref
+	// Level is the priority level of a 'pointWrite', or 0 for other ops
+	Level int
+	// Action is the name of an invoked action, or the kind of a commit
+	Action string
+	// Before and After are the values before and after the change:
+	//
+	//   - pointWrite: the value of the level
+	//   - hisWrite: Null, and the Number of items written
+	//   - invokeAction: Null, and the Dict of action arguments
+	//   - commit: the record, where Null stands for a record that doesn't exist
+	Before haystack.Val
+	After  haystack.Val
+	// Err is the error message if the change failed, or an empty string
+	Err string
+}
+
+// ToDict encodes the entry as a Dict, which is how it is stored in the log.
+func (entry Entry) ToDict() haystack.Dict {
+	items := map[string]haystack.Val{
+		"ts":   entry.Ts,
+		"user": haystack.NewStr(entry.User),
+		"op":   haystack.NewStr(entry.Op),
+		"id":   entry.Id,
+	}
+	if entry.Level > 0 {
+		items["level"] = haystack.NewNumber(float64(entry.Level), "")
+	}
+	if entry.Action != "" {
+		items["action"] = haystack.NewStr(entry.Action)
+	}
+	if entry.Before != nil {
+		items["before"] = entry.Before
+	}
+	if entry.After != nil {
+		items["after"] = entry.After
+	}
+	if entry.Err != "" {
+		items["err"] = haystack.NewStr(entry.Err)
+	}
+	return haystack.EmptyDict().Patch(haystack.NewDict(items))
+}
+
+// NewEntryFromDict decodes an entry encoded by ToDict.
+func NewEntryFromDict(dict haystack.Dict) (Entry, error) {
+	ts, err := dict.GetDateTime("ts")
+	if err != nil {
+		return Entry{}, err
+	}
+	user, err := dict.GetStr("user")
+	if err != nil {
+		return Entry{}, err
+	}
+	op, err := dict.GetStr("op")
+	if err != nil {
+		return Entry{}, err
+	}
+	id, err := dict.GetRef("id")
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{
+		Ts:     ts,
+		User:   user.String(),
+		Op:     op.String(),
+		Id:     id,
+		Before: dict.Get("before"),
+		After:  dict.Get("after"),
+	}
+	if dict.Has("level") {
+		level, err := dict.GetNumber("level")
+		if err != nil {
+			return Entry{}, err
+		}
+		entry.Level = int(level.Float())
+	}
+	if dict.Has("action") {
+		action, err := dict.GetStr("action")
+		if err != nil {
+			return Entry{}, err
+		}
+		entry.Action = action.String()
+	}
+	if dict.Has("err") {
+		msg, err := dict.GetStr("err")
+		if err != nil {
+			return Entry{}, err
+		}
+		entry.Err = msg.String()
+	}
+	return entry, nil
+}
@@ -0,0 +1,130 @@
+package audit
+
+import (
+	"sync"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/point"
+	"github.com/NeedleInAJayStack/haystack/server"
+)
+
+// Handler is a server.Handler that records the 'pointWrite', 'hisWrite' and 'invokeAction' requests passed to the next
+// Handler, including failed and denied ones. The value of a level before a 'pointWrite' is read with a 'pointWrite'
+// status request to the next Handler.
+//
+// Place it in front of a server.Authorizer to also record denials. A Handler is safe for concurrent use.
+type Handler struct {
+	lock      sync.Mutex
+	next      server.Handler
+	log       *Log
+	listeners []func(error)
+}
+
+// NewHandler creates a Handler in front of the next Handler.
+func NewHandler(next server.Handler, log *Log) *Handler {
+	return &Handler{next: next, log: log}
+}
+
+// OnError adds a listener that is called when an entry can't be recorded. The request has already been handled by
+// then, so its response is returned unchanged.
+func (handler *Handler) OnError(listener func(error)) {
+	handler.lock.Lock()
+	defer handler.lock.Unlock()
+	handler.listeners = append(handler.listeners, listener)
+}
+
+// Handle calls the next Handler and records the changes that the request makes.
+func (handler *Handler) Handle(user string, op string, req haystack.Grid) haystack.Grid {
+	switch op {
+	case "pointWrite":
+		if req.Col("level") == nil {
+			// A status request doesn't change anything
+			return handler.next.Handle(user, op, req)
+		}
+		entries := make([]Entry, 0, req.RowCount())
+		for _, row := range req.Rows() {
+			id, _ := row.Get("id").(haystack.Ref)
+			level := point.LevelDefault
+			if number, ok := row.Get("level").(haystack.Number); ok {
+				level = int(number.Float())
+			}
+			before := handler.levelVal(user, id, level)
+			entries = append(entries, Entry{User: user, Op: op, Id: id, Level: level, Before: before, After: row.Get("val")})
+		}
+		resp := handler.next.Handle(user, op, req)
+		handler.record(entries, resp)
+		return resp
+	case "hisWrite":
+		id, _ := req.Meta().Get("id").(haystack.Ref)
+		entry := Entry{
+			User:   user,
+			Op:     op,
+			Id:     id,
+			Before: haystack.NewNull(),
+			After:  haystack.NewNumber(float64(req.RowCount()), ""),
+		}
+		resp := handler.next.Handle(user, op, req)
+		handler.record([]Entry{entry}, resp)
+		return resp
+	case "invokeAction":
+		id, _ := req.Meta().Get("id").(haystack.Ref)
+		action, _ := req.Meta().Get("action").(haystack.Str)
+		args := haystack.EmptyDict()
+		if req.RowCount() > 0 {
+			args = haystack.EmptyDict().Patch(req.RowAt(0).ToDict())
+		}
+		entry := Entry{User: user, Op: op, Id: id, Action: action.String(), Before: haystack.NewNull(), After: args}
+		resp := handler.next.Handle(user, op, req)
+		handler.record([]Entry{entry}, resp)
+		return resp
+	default:
+		return handler.next.Handle(user, op, req)
+	}
+}
+
+// levelVal returns the value of the point's level, or Null if it can't be read
+func (handler *Handler) levelVal(user string, id haystack.Ref, level int) haystack.Val {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddRow([]haystack.Val{id})
+	status := handler.next.Handle(user, "pointWrite", gb.ToGrid())
+	if server.IsErrGrid(status) {
+		return haystack.NewNull()
+	}
+	return statusLevelVal(status, level)
+}
+
+// record records the entries with the error of the response, if any
+func (handler *Handler) record(entries []Entry, resp haystack.Grid) {
+	errMsg := ""
+	if server.IsErrGrid(resp) {
+		errMsg = "error"
+		if dis, ok := resp.Meta().Get("dis").(haystack.Str); ok {
+			errMsg = dis.String()
+		}
+	}
+	for _, entry := range entries {
+		entry.Err = errMsg
+		if err := handler.log.Record(entry); err != nil {
+			handler.lock.Lock()
+			listeners := handler.listeners
+			handler.lock.Unlock()
+			for _, listener := range listeners {
+				listener(err)
+			}
+		}
+	}
+}
+
+// statusLevelVal returns the value of the level in a 'pointWrite' status grid, or Null if it can't be decoded
+func statusLevelVal(status haystack.Grid, level int) haystack.Val {
+	array, err := point.NewPriorityArrayFromGrid(status)
+	if err != nil {
+		return haystack.NewNull()
+	}
+	state, err := array.Level(level)
+	if err != nil {
+		return haystack.NewNull()
+	}
+	return state.Val
+}
@@ -0,0 +1,82 @@
+package audit
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/point"
+	"github.com/NeedleInAJayStack/haystack/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler(t *testing.T) {
+	log, _ := testLog(t)
+	defer log.Close()
+	array := point.NewPriorityArray(haystack.NewNumber(70, "°F"))
+	next := server.Ops{
+		"pointWrite": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			row := req.RowAt(0)
+			if req.Col("level") != nil {
+				level := int(row.Get("level").(haystack.Number).Float())
+				err := array.Write(level, row.Get("val"), user, 0)
+				if err != nil {
+					return haystack.EmptyGrid(), err
+				}
+			}
+			return array.ToGrid(), nil
+		},
+		"invokeAction": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			return haystack.EmptyGrid(), nil
+		},
+	}
+	handler := NewHandler(next, log)
+
+	writeReq := func(level int, val haystack.Val) haystack.Grid {
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("id")
+		gb.AddColNoMeta("level")
+		gb.AddColNoMeta("val")
+		gb.AddRow([]haystack.Val{haystack.NewRef("p1", ""), haystack.NewNumber(float64(level), ""), val})
+		return gb.ToGrid()
+	}
+	handler.Handle("alice", "pointWrite", writeReq(8, haystack.NewNumber(72, "°F")))
+	handler.Handle("bob", "pointWrite", writeReq(8, haystack.NewNull()))
+	handler.Handle("bob", "pointWrite", writeReq(20, haystack.NewNumber(1, "")))
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddRow([]haystack.Val{haystack.NewRef("p1", "")})
+	handler.Handle("alice", "pointWrite", gb.ToGrid())
+
+	gb = haystack.NewGridBuilder()
+	gb.AddMetaVal("id", haystack.NewRef("p1", ""))
+	gb.AddMetaVal("action", haystack.NewStr("reset"))
+	gb.AddColNoMeta("force")
+	gb.AddRow([]haystack.Val{haystack.NewBool(true)})
+	handler.Handle("alice", "invokeAction", gb.ToGrid())
+
+	grid, err := log.Query(allTime())
+	assert.Nil(t, err)
+	assert.Equal(t, 4, grid.RowCount())
+
+	entries := []Entry{}
+	for _, row := range grid.Rows() {
+		entry, err := NewEntryFromDict(haystack.EmptyDict().Patch(row.ToDict()))
+		assert.Nil(t, err)
+		entry.Ts = haystack.DateTime{}
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, []Entry{
+		{User: "alice", Op: "pointWrite", Id: haystack.NewRef("p1", ""), Level: 8, Before: haystack.NewNull(), After: haystack.NewNumber(72, "°F")},
+		{User: "bob", Op: "pointWrite", Id: haystack.NewRef("p1", ""), Level: 8, Before: haystack.NewNumber(72, "°F"), After: haystack.NewNull()},
+		{User: "bob", Op: "pointWrite", Id: haystack.NewRef("p1", ""), Level: 20, Before: haystack.NewNull(), After: haystack.NewNumber(1, ""), Err: "invalid priority level: 20"},
+		{
+			User:   "alice",
+			Op:     "invokeAction",
+			Id:     haystack.NewRef("p1", ""),
+			Action: "reset",
+			Before: haystack.NewNull(),
+			After:  haystack.NewDict(map[string]haystack.Val{"force": haystack.NewBool(true)}),
+		},
+	}, entries)
+}
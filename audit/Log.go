@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+const (
+	currentFile   = "audit.zinc"
+	rotatedPrefix = "audit-"
+	rotatedSuffix = ".zinc"
+
+	// DefaultMaxSize is the default size in bytes at which the log file is rotated
+	DefaultMaxSize = 10 * 1024 * 1024
+)
+
+// Log is an append-only audit log kept in a directory. Each entry is encoded as a Zinc Dict, which is written on its
+// own line as a quoted Zinc Str so that values spanning several lines, like Grids, don't break the framing. Entries are
+// synced to disk before Record returns. Once the current file reaches the maximum size, it is rotated to a numbered file and
+// a new one is started. If the process stops while writing, the partial entry at the end is discarded when the log is
+// next opened.
+//
+// A Log is safe for concurrent use.
+type Log struct {
+	lock     sync.Mutex
+	dir      string
+	file     *os.File
+	size     int64
+	rotated  int // number of the last rotated file
+	maxSize  int64
+	maxFiles int
+	now      func() time.Time
+}
+
+// OpenLog opens the log in the directory, creating it if it doesn't exist.
+func OpenLog(dir string) (*Log, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	log := &Log{dir: dir, maxSize: DefaultMaxSize, now: time.Now}
+	files, err := log.rotatedFiles()
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		log.rotated = files[len(files)-1]
+	}
+	err = log.openCurrent()
+	if err != nil {
+		return nil, err
+	}
+	return log, nil
+}
+
+// Close closes the log file. The log must not be used afterwards.
+func (log *Log) Close() error {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	return log.file.Close()
+}
+
+// SetMaxSize sets the size in bytes at which the log file is rotated. The default is DefaultMaxSize.
+func (log *Log) SetMaxSize(bytes int64) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	log.maxSize = bytes
+}
+
+// SetMaxFiles sets the number of rotated files that are kept. Older files are deleted when the log is rotated. The
+// default of 0 keeps every file.
+func (log *Log) SetMaxFiles(files int) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	log.maxFiles = files
+}
+
+// SetClock sets the function used to timestamp entries, for tests. The default is time.Now.
+func (log *Log) SetClock(now func() time.Time) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+	log.now = now
+}
+
+// Record timestamps the entry with the current time and appends it to the log.
+func (log *Log) Record(entry Entry) error {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+
+	entry.Ts = haystack.NewDateTimeFromGo(log.now())
+	line := []byte(encodeEntry(entry) + "\n")
+	if log.size > 0 && log.size+int64(len(line)) > log.maxSize {
+		err := log.rotate()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := log.file.Write(line)
+	if err != nil {
+		return err
+	}
+	log.size += int64(len(line))
+	return log.file.Sync()
+}
+
+// Query returns the entries in the span as a grid, oldest first. If ids are given, only the entries that target one
+// of them are returned.
+func (log *Log) Query(span haystack.Span, ids ...haystack.Ref) (haystack.Grid, error) {
+	log.lock.Lock()
+	defer log.lock.Unlock()
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id.Id()] = true
+	}
+	files, err := log.rotatedFiles()
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	paths := make([]string, 0, len(files)+1)
+	for _, number := range files {
+		paths = append(paths, log.rotatedPath(number))
+	}
+	paths = append(paths, filepath.Join(log.dir, currentFile))
+
+	entries := []haystack.Dict{}
+	for _, path := range paths {
+		err := readEntries(path, func(entry Entry) {
+			if span.Contains(entry.Ts) && (len(wanted) == 0 || wanted[entry.Id.Id()]) {
+				entries = append(entries, entry.ToDict())
+			}
+		})
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+	}
+	return haystack.NewGridFromDicts(entries), nil
+}
+
+// openCurrent opens the current file for appending, truncating a partial entry at its end
+func (log *Log) openCurrent() error {
+	path := filepath.Join(log.dir, currentFile)
+	content, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	size := int64(strings.LastIndexByte(string(content), '\n') + 1)
+	if size < int64(len(content)) {
+		err = os.Truncate(path, size)
+		if err != nil {
+			return err
+		}
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	log.file = file
+	log.size = size
+	return nil
+}
+
+// rotate renames the current file to the next number, deletes old files and opens a new current file. The caller must
+// hold the lock.
+func (log *Log) rotate() error {
+	err := log.file.Close()
+	if err != nil {
+		return err
+	}
+	err = os.Rename(filepath.Join(log.dir, currentFile), log.rotatedPath(log.rotated+1))
+	if err != nil {
+		return err
+	}
+	log.rotated++
+
+	if log.maxFiles > 0 {
+		files, err := log.rotatedFiles()
+		if err != nil {
+			return err
+		}
+		for len(files) > log.maxFiles {
+			err = os.Remove(log.rotatedPath(files[0]))
+			if err != nil {
+				return err
+			}
+			files = files[1:]
+		}
+	}
+	return log.openCurrent()
+}
+
+// rotatedFiles returns the numbers of the rotated files, in ascending order
+func (log *Log) rotatedFiles() ([]int, error) {
+	infos, err := ioutil.ReadDir(log.dir)
+	if err != nil {
+		return nil, err
+	}
+	numbers := []int{}
+	for _, info := range infos {
+		name := info.Name()
+		if !strings.HasPrefix(name, rotatedPrefix) || !strings.HasSuffix(name, rotatedSuffix) {
+			continue
+		}
+		var number int
+		_, err := fmt.Sscanf(strings.TrimSuffix(strings.TrimPrefix(name, rotatedPrefix), rotatedSuffix), "%d", &number)
+		if err == nil {
+			numbers = append(numbers, number)
+		}
+	}
+	sort.Ints(numbers)
+	return numbers, nil
+}
+
+func (log *Log) rotatedPath(number int) string {
+	return filepath.Join(log.dir, fmt.Sprintf("%s%06d%s", rotatedPrefix, number, rotatedSuffix))
+}
+
+// encodeEntry returns the entry's Dict as Zinc, quoted as a Zinc Str so that it contains no newlines
+func encodeEntry(entry Entry) string {
+	return haystack.NewStr(entry.ToDict().ToZinc()).ToZinc()
+}
+
+// decodeEntry decodes a line written by encodeEntry
+func decodeEntry(line string) (Entry, error) {
+	var reader io.ZincReader
+	reader.InitString(line)
+	val, err := reader.ReadVal()
+	if err != nil {
+		return Entry{}, err
+	}
+	str, ok := val.(haystack.Str)
+	if !ok {
+		return Entry{}, errors.New("audit entry is not a quoted dict: " + line)
+	}
+	reader.InitString(str.String())
+	val, err = reader.ReadVal()
+	if err != nil {
+		return Entry{}, err
+	}
+	dict, ok := val.(haystack.Dict)
+	if !ok {
+		return Entry{}, errors.New("audit entry is not a dict: " + str.String())
+	}
+	return NewEntryFromDict(dict)
+}
+
+// readEntries decodes each line of the file
+func readEntries(path string, each func(Entry)) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		entry, err := decodeEntry(line)
+		if err != nil {
+			return err
+		}
+		each(entry)
+	}
+	return scanner.Err()
+}
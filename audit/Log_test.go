@@ -0,0 +1,150 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+var start = time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+func testLog(t *testing.T) (*Log, string) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.Nil(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	log, err := OpenLog(dir)
+	assert.Nil(t, err)
+	now := start
+	log.SetClock(func() time.Time {
+		now = now.Add(time.Minute)
+		return now
+	})
+	return log, dir
+}
+
+func allTime() haystack.Span {
+	span, _ := haystack.NewSpan(
+		haystack.NewDateTimeFromGo(start),
+		haystack.NewDateTimeFromGo(start.Add(24*time.Hour)),
+	)
+	return span
+}
+
+func TestEntry_ToDict(t *testing.T) {
+	entry := Entry{
+		Ts:     haystack.NewDateTimeFromGo(start),
+		User:   "alice",
+		Op:     "pointWrite",
+		Id:     haystack.NewRef("p1", ""),
+		Level:  8,
+		Before: haystack.NewNull(),
+		After:  haystack.NewNumber(72, "°F"),
+		Err:    "failed",
+	}
+	decoded, err := NewEntryFromDict(entry.ToDict())
+	assert.Nil(t, err)
+	assert.Equal(t, entry, decoded)
+	assert.False(t, entry.ToDict().Has("before"))
+	assert.False(t, entry.ToDict().Has("action"))
+
+	_, err = NewEntryFromDict(haystack.EmptyDict())
+	assert.NotNil(t, err)
+}
+
+func TestLog_Query(t *testing.T) {
+	log, dir := testLog(t)
+	for _, id := range []string{"a", "b", "a"} {
+		err := log.Record(Entry{User: "alice", Op: "hisWrite", Id: haystack.NewRef(id, ""), After: haystack.NewNumber(1, "")})
+		assert.Nil(t, err)
+	}
+
+	grid, err := log.Query(allTime())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("alice"), grid.RowAt(0).Get("user"))
+
+	grid, err = log.Query(allTime(), haystack.NewRef("a", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, grid.RowCount())
+
+	span, _ := haystack.NewSpan(
+		haystack.NewDateTimeFromGo(start.Add(2*time.Minute)),
+		haystack.NewDateTimeFromGo(start.Add(3*time.Minute)),
+	)
+	grid, err = log.Query(span)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, grid.RowCount())
+	assert.Equal(t, haystack.NewRef("b", ""), grid.RowAt(0).Get("id"))
+
+	// A partial entry at the end is discarded on open
+	assert.Nil(t, log.Close())
+	file, err := os.OpenFile(filepath.Join(dir, currentFile), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.Nil(t, err)
+	_, err = file.WriteString(`"{ts:2021-03-01T`)
+	assert.Nil(t, err)
+	assert.Nil(t, file.Close())
+
+	log, err = OpenLog(dir)
+	assert.Nil(t, err)
+	defer log.Close()
+	grid, err = log.Query(allTime())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, grid.RowCount())
+}
+
+// Values that span several lines in Zinc, like Grids, are kept on one line
+func TestLog_Query_multiLine(t *testing.T) {
+	log, _ := testLog(t)
+	defer log.Close()
+
+	var gb haystack.GridBuilder
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("dis")
+	gb.AddRow([]haystack.Val{haystack.NewRef("p1", ""), haystack.NewStr("DAT")})
+	gb.AddRow([]haystack.Val{haystack.NewRef("p2", ""), haystack.NewStr("RAT")})
+	grid := gb.ToGrid()
+	args := haystack.NewDict(map[string]haystack.Val{
+		"note": haystack.NewStr("first line\nsecond line\r\n\"quoted\""),
+		"rows": grid,
+	})
+	err := log.Record(Entry{User: "alice", Op: "invokeAction", Id: haystack.NewRef("a", ""), Action: "import", After: args})
+	assert.Nil(t, err)
+	err = log.Record(Entry{User: "alice", Op: "hisWrite", Id: haystack.NewRef("b", ""), After: grid})
+	assert.Nil(t, err)
+
+	result, err := log.Query(allTime())
+	assert.Nil(t, err)
+	assert.Equal(t, 2, result.RowCount())
+	after := result.RowAt(0).Get("after").(haystack.Dict)
+	assert.Equal(t, haystack.NewStr("first line\nsecond line\r\n\"quoted\""), after.Get("note"))
+	assert.Equal(t, grid.ToZinc(), after.Get("rows").ToZinc())
+	assert.Equal(t, grid.ToZinc(), result.RowAt(1).Get("after").ToZinc())
+}
+
+func TestLog_Rotate(t *testing.T) {
+	log, dir := testLog(t)
+	defer log.Close()
+	log.SetMaxSize(1)
+	log.SetMaxFiles(2)
+	for i := 0; i < 5; i++ {
+		err := log.Record(Entry{User: "alice", Op: "hisWrite", Id: haystack.NewRef("a", ""), After: haystack.NewNumber(float64(i), "")})
+		assert.Nil(t, err)
+	}
+
+	files, err := log.rotatedFiles()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{3, 4}, files)
+	_, err = os.Stat(filepath.Join(dir, "audit-000004.zinc"))
+	assert.Nil(t, err)
+
+	grid, err := log.Query(allTime())
+	assert.Nil(t, err)
+	assert.Equal(t, 3, grid.RowCount())
+	assert.Equal(t, haystack.NewNumber(2, ""), grid.RowAt(0).Get("after"))
+	assert.Equal(t, haystack.NewNumber(4, ""), grid.RowAt(2).Get("after"))
+}
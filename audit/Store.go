@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/store"
+)
+
+// Committer is a record store with atomic commits. It is implemented by store.FileStore.
+type Committer interface {
+	ReadById(id haystack.Ref) (haystack.Dict, error)
+	Commit(diffs ...store.Diff) ([]haystack.Dict, error)
+}
+
+// Store records the commits made to a Committer, with an entry for each changed record.
+type Store struct {
+	recs Committer
+	log  *Log
+}
+
+// NewStore creates a Store that records the commits made through it to the log.
+func NewStore(recs Committer, log *Log) *Store {
+	return &Store{recs: recs, log: log}
+}
+
+// Commit commits the diffs on behalf of the user, and records the records before and after each change. Failed commits
+// are recorded with their error. If the commit succeeds but can't be recorded, its result is returned with the error.
+func (auditStore *Store) Commit(user string, diffs ...store.Diff) ([]haystack.Dict, error) {
+	befores := make([]haystack.Val, 0, len(diffs))
+	for _, diff := range diffs {
+		var before haystack.Val = haystack.NewNull()
+		if rec, err := auditStore.recs.ReadById(diff.Id); err == nil {
+			before = rec
+		}
+		befores = append(befores, before)
+	}
+
+	result, commitErr := auditStore.recs.Commit(diffs...)
+	for i, diff := range diffs {
+		entry := Entry{User: user, Op: OpCommit, Id: diff.Id, Action: string(diff.Op), Before: befores[i]}
+		entry.After = haystack.NewNull()
+		if commitErr != nil {
+			entry.Err = commitErr.Error()
+		} else if diff.Op != store.DiffRemove {
+			entry.After = result[i]
+		}
+		if err := auditStore.log.Record(entry); err != nil && commitErr == nil {
+			return result, err
+		}
+	}
+	return result, commitErr
+}
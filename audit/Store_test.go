@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore_Commit(t *testing.T) {
+	log, _ := testLog(t)
+	defer log.Close()
+	dir, err := ioutil.TempDir("", "recs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	recs, err := store.OpenFileStore(dir)
+	assert.Nil(t, err)
+	defer recs.Close()
+	auditStore := NewStore(recs, log)
+
+	id := haystack.NewRef("s1", "")
+	added, err := auditStore.Commit("alice", store.NewAddDiff(haystack.NewDict(map[string]haystack.Val{
+		"id":   id,
+		"site": haystack.NewMarker(),
+	})))
+	assert.Nil(t, err)
+	updated, err := auditStore.Commit("bob", store.NewUpdateDiff(added[0], haystack.NewDict(map[string]haystack.Val{
+		"dis": haystack.NewStr("HQ"),
+	})))
+	assert.Nil(t, err)
+	_, err = auditStore.Commit("bob", store.NewRemoveDiff(added[0]))
+	assert.NotNil(t, err)
+	_, err = auditStore.Commit("bob", store.NewRemoveDiff(updated[0]))
+	assert.Nil(t, err)
+
+	grid, err := log.Query(allTime(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, grid.RowCount())
+
+	entries := []Entry{}
+	for _, row := range grid.Rows() {
+		entry, err := NewEntryFromDict(haystack.EmptyDict().Patch(row.ToDict()))
+		assert.Nil(t, err)
+		entries = append(entries, entry)
+	}
+	assert.Equal(t, "add", entries[0].Action)
+	assert.Equal(t, haystack.NewNull(), entries[0].Before)
+	assert.Equal(t, added[0], entries[0].After)
+	assert.Equal(t, added[0], entries[1].Before)
+	assert.Equal(t, updated[0], entries[1].After)
+	assert.NotEqual(t, "", entries[2].Err)
+	assert.Equal(t, "remove", entries[3].Action)
+	assert.Equal(t, updated[0], entries[3].Before)
+	assert.Equal(t, haystack.NewNull(), entries[3].After)
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/defs"
 	"github.com/NeedleInAJayStack/haystack/io"
+	"github.com/NeedleInAJayStack/haystack/point"
 )
 
 // Client models a client connection to a server using the Haystack API.
@@ -92,12 +94,21 @@ func (client *Client) Close() error {
 func (client *Client) Defs() (haystack.Grid, error) {
 	switch client.method {
 	case Get:
-		return client.get("about", map[string]haystack.Val{})
+		return client.get("defs", map[string]haystack.Val{})
 	default:
-		return client.post("about", haystack.EmptyGrid())
+		return client.post("defs", haystack.EmptyGrid())
 	}
 }
 
+// Namespace calls the 'defs' op and builds a def Namespace from the result.
+func (client *Client) Namespace() (*defs.Namespace, error) {
+	grid, err := client.Defs()
+	if err != nil {
+		return nil, err
+	}
+	return defs.NewNamespaceFromGrid(grid)
+}
+
 // DefsWithFilter calls the 'defs' op with a filter grid.
 func (client *Client) DefsWithFilter(filter string, limit int) (haystack.Grid, error) {
 	switch client.method {
@@ -269,6 +280,27 @@ func (client *Client) WatchSubAdd(
 	}
 }
 
+// WatchSubCreateDuration calls the 'watchSub' op to create a new subscription with a lease given as a Go duration.
+// The lease is sent using the largest time unit that represents it exactly. If `lease` is 0 or less, no lease is
+// added to the subscription.
+func (client *Client) WatchSubCreateDuration(
+	watchDis string,
+	lease time.Duration,
+	ids []haystack.Ref,
+) (haystack.Grid, error) {
+	return client.WatchSubCreate(watchDis, haystack.NewNumberFromDuration(lease), ids)
+}
+
+// WatchSubAddDuration calls the 'watchSub' op to add to an existing subscription with a lease given as a Go
+// duration. If `lease` is 0 or less, no lease is added to the subscription.
+func (client *Client) WatchSubAddDuration(
+	watchId string,
+	lease time.Duration,
+	ids []haystack.Ref,
+) (haystack.Grid, error) {
+	return client.WatchSubAdd(watchId, haystack.NewNumberFromDuration(lease), ids)
+}
+
 // WatchUnsub calls the 'watchUnsub' op to delete or remove entities from a existing subscription. If `lease` is 0
 // or less, no lease is added to the subscription.
 func (client *Client) WatchUnsub(
@@ -327,6 +359,16 @@ func (client *Client) PointWriteStatus(id haystack.Ref) (haystack.Grid, error) {
 	}
 }
 
+// PointWriteArray calls the 'pointWrite' op to query the point write priority array status for the input id, and
+// decodes the result.
+func (client *Client) PointWriteArray(id haystack.Ref) (*point.PriorityArray, error) {
+	grid, err := client.PointWriteStatus(id)
+	if err != nil {
+		return nil, err
+	}
+	return point.NewPriorityArrayFromGrid(grid)
+}
+
 // PointWrite calls the 'pointWrite' op to write the val to the given point.
 func (client *Client) PointWrite(
 	id haystack.Ref,
@@ -356,6 +398,18 @@ func (client *Client) PointWrite(
 	}
 }
 
+// PointWriteDuration calls the 'pointWrite' op to write the val to the given point for a duration given as a Go
+// duration. This is typically used for timed overrides at level 8.
+func (client *Client) PointWriteDuration(
+	id haystack.Ref,
+	level int,
+	val haystack.Val,
+	who string,
+	duration time.Duration,
+) (haystack.Grid, error) {
+	return client.PointWrite(id, level, val, who, haystack.NewNumberFromDuration(duration))
+}
+
 // HisReadAbsDate calls the 'hisRead' op with an input absolute Date range.
 func (client *Client) HisReadAbsDate(id haystack.Ref, from haystack.Date, to haystack.Date) (haystack.Grid, error) {
 	rangeString := from.ToZinc() + "," + to.ToZinc()
@@ -434,6 +488,18 @@ func (client *Client) Eval(expr string) (haystack.Grid, error) {
 	}
 }
 
+// AuthHeader returns the Authorization header of the open connection, so that requests that the Client doesn't make
+// itself, like push subscriptions, can be authenticated. It is empty until the Client is opened.
+func (client *Client) AuthHeader() string {
+	return client.auth
+}
+
+// Call calls an op with the request grid, which is always posted. It can be used for ops that the Client has no
+// method for, or to forward requests.
+func (client *Client) Call(op string, reqGrid haystack.Grid) (haystack.Grid, error) {
+	return client.post(op, reqGrid)
+}
+
 // post executes the given operation. The request grid is posted to the client URI and the response is parsed as a grid.
 func (client *Client) post(op string, reqGrid haystack.Grid) (haystack.Grid, error) {
 	reqBody := reqGrid.ToZinc()
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/NeedleInAJayStack/haystack"
 	"github.com/NeedleInAJayStack/haystack/io"
@@ -48,10 +49,12 @@ func TestClientAuth_BasicAuth(t *testing.T) {
 		username:   "test",
 		password:   "test",
 	}
+	assert.Equal(t, "", client.AuthHeader())
 	openErr := client.Open()
 	if openErr != nil {
 		t.Error(openErr)
 	}
+	assert.True(t, strings.HasPrefix(client.AuthHeader(), "Basic "))
 }
 
 // clientHTTPBasicAuth validates the basic authentication
@@ -145,6 +148,13 @@ func TestClient_Close(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+func TestClient_Namespace(t *testing.T) {
+	ns, err := testPostClient().Namespace()
+	assert.Nil(t, err)
+	assert.True(t, ns.Is("ahu", "equip"))
+	assert.False(t, ns.Is("site", "equip"))
+}
+
 func TestClient_Filetypes(t *testing.T) {
 	actual, err := testPostClient().Filetypes()
 	assert.Nil(t, err)
@@ -267,6 +277,42 @@ func TestClient_WatchSubAdd(t *testing.T) {
 	testClient_ValZinc(actual, emptyRes, t)
 }
 
+func TestClient_WatchSubCreateDuration(t *testing.T) {
+	actual, err := testPostClient().WatchSubCreateDuration(
+		"abc",
+		90*time.Second,
+		[]haystack.Ref{haystack.NewRef("abc-123", "")},
+	)
+	assert.Nil(t, err)
+	testClient_ValZinc(actual, emptyRes, t)
+}
+
+func TestClient_PointWriteDuration(t *testing.T) {
+	actual, err := testPostClient().PointWriteDuration(
+		haystack.NewRef("abc-123", ""),
+		8,
+		haystack.NewNumber(72, "°F"),
+		"test",
+		2*time.Hour,
+	)
+	assert.Nil(t, err)
+	testClient_ValZinc(actual, emptyRes, t)
+}
+
+func TestClient_PointWriteArray(t *testing.T) {
+	array, err := testPostClient().PointWriteArray(haystack.NewRef("abc-123", ""))
+	assert.Nil(t, err)
+	val, level := array.Effective()
+	assert.Equal(t, haystack.NewNumber(72, "°F"), val)
+	assert.Equal(t, 8, level)
+
+	manual, _ := array.Level(8)
+	assert.Equal(t, "test", manual.Who)
+	assert.Equal(t, "2021-01-03T02:00:00-07:00 Denver", manual.Expires.ToZinc())
+	def, _ := array.Level(17)
+	assert.Equal(t, haystack.NewNumber(70, "°F"), def.Val)
+}
+
 func TestClient_WatchUnsub(t *testing.T) {
 	actual, err := testPostClient().WatchUnsub("abc", []haystack.Ref{haystack.NewRef("abc-123", "")})
 	assert.Nil(t, err)
@@ -279,6 +325,18 @@ func TestClient_Eval(t *testing.T) {
 	testClient_ValZinc(actual, clientHTTPMock_readPoint, t)
 }
 
+func TestClient_Call(t *testing.T) {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("expr")
+	gb.AddRow([]haystack.Val{haystack.NewStr("read(point)")})
+	actual, err := testGetClient().Call("eval", gb.ToGrid())
+	assert.Nil(t, err)
+	testClient_ValZinc(actual, clientHTTPMock_readPoint, t)
+
+	_, err = testPostClient().Call("unknownOp", gb.ToGrid())
+	assert.NotNil(t, err)
+}
+
 func testClient_ValZinc(actual haystack.Val, expectedZinc string, t *testing.T) {
 	var reader io.ZincReader
 	reader.InitString(expectedZinc)
@@ -355,6 +413,8 @@ func (clientHTTPMock *clientHTTPMock) postResponse(op string, reqBody string) (s
 		return clientHTTPMock_filetypes, nil
 	case "ops":
 		return clientHTTPMock_ops, nil
+	case "defs":
+		return clientHTTPMock_defs, nil
 	case "read":
 		if reqBody == "ver:\"3.0\"\nfilter, limit\n\"site\", N" { // readAll sites
 			return clientHTTPMock_readSites, nil
@@ -377,6 +437,13 @@ func (clientHTTPMock *clientHTTPMock) postResponse(op string, reqBody string) (s
 		return emptyRes, nil
 	case "watchUnsub":
 		return emptyRes, nil
+	case "pointWrite":
+		if reqBody == "ver:\"3.0\"\nid, level, val, who, duration\n@abc-123, 8, 72°F, \"test\", 2h" { // pointWrite with duration
+			return emptyRes, nil
+		} else if reqBody == "ver:\"3.0\"\nid\n@abc-123" { // pointWrite status
+			return clientHTTPMock_pointWriteStatus, nil
+		}
+		return emptyRes, errors.New("'pointWrite' argument not supported by mock class")
 	case "eval":
 		if reqBody == "ver:\"3.0\"\nexpr\n\"read(point)\"" { // eval read(point)
 			return clientHTTPMock_readPoint, nil
@@ -430,6 +497,21 @@ func (clientHTTPMock *clientHTTPMock) getResponse(op string, params map[string]s
 }
 
 const (
+	clientHTTPMock_pointWriteStatus string = `ver:"3.0"
+		level,levelDis,val,who,expires
+		1,"1 (Emergency)",,,
+		8,"8 (Manual Override)",72°F,"test",2021-01-03T02:00:00-07:00 Denver
+		16,"16",68°F,"schedule",
+		17,"def",70°F,,
+		`
+	clientHTTPMock_defs string = `ver:"3.0"
+		def,is,mandatory,doc
+		^marker,,,"Marker tag"
+		^entity,^marker,,"Top level entity"
+		^site,^entity,M,"Site"
+		^equip,^entity,M,"Equipment"
+		^ahu,^equip,,"Air handling unit"
+		`
 	clientHTTPMock_about string = "ver:\"3.0\"\n" + // Can't use string literal because of Uri backticks
 		"haystackVersion,projName,serverName,serverBootTime,serverTime,productName,productUri,productVersion,moduleName,moduleVersion,tz,whoami,hostDis,hostModel,hostId\n" +
 		"\"3.0\",\"demo\",\"JaysDesktop\",2021-01-03T00:21:01.588-07:00 Denver,2021-01-03T00:21:43.799-07:00 Denver,\"SkySpark\",`http://skyfoundry.com/skyspark`,\"3.0.26\",\"skyarcd\",\"3.0.26\",\"Denver\",\"test\",\"Linux amd64 5.4.0-58-generic\",\"Linux amd64 5.4.0-58-generic\",NA\n"
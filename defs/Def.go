@@ -0,0 +1,91 @@
+package defs
+
+import (
+	"strings"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Def is a single definition in a Namespace, identified by its symbol.
+type Def struct {
+	symbol string
+	dict   haystack.Dict
+}
+
+// Symbol returns the symbol of the def, like ^site
+func (def Def) Symbol() haystack.Symbol {
+	return haystack.NewSymbol(def.symbol)
+}
+
+// Name returns the symbol name of the def, like "site"
+func (def Def) Name() string {
+	return def.symbol
+}
+
+// Dict returns the meta tags of the def
+func (def Def) Dict() haystack.Dict {
+	return def.dict
+}
+
+// Lib returns the name of the library that declares the def, or an empty string if it is unknown
+func (def Def) Lib() string {
+	return symbolName(def.dict.Get("lib"))
+}
+
+// Doc returns the documentation of the def
+func (def Def) Doc() string {
+	doc, err := def.dict.GetStr("doc")
+	if err != nil {
+		return ""
+	}
+	return doc.String()
+}
+
+// IsConjunct returns true if the def is a conjunct of marker tags, like ^hot-water
+func (def Def) IsConjunct() bool {
+	return isConjunct(def.symbol)
+}
+
+// IsFeatureKey returns true if the def is a feature key, like ^filetype:zinc
+func (def Def) IsFeatureKey() bool {
+	return strings.Contains(def.symbol, ":")
+}
+
+// Parts returns the tag names that make up a conjunct, like ["hot", "water"] for ^hot-water. Non-conjunct defs
+// return their own name.
+func (def Def) Parts() []string {
+	if !def.IsConjunct() {
+		return []string{def.symbol}
+	}
+	return strings.Split(def.symbol, "-")
+}
+
+func isConjunct(symbol string) bool {
+	return strings.Contains(symbol, "-") && !strings.Contains(symbol, ":")
+}
+
+// symbolName returns the name of a Symbol val, or an empty string if it is not a Symbol
+func symbolName(val haystack.Val) string {
+	if symbol, ok := val.(haystack.Symbol); ok {
+		return symbol.String()
+	}
+	return ""
+}
+
+// symbolNames returns the names of a Symbol or List of Symbols. Other values are ignored.
+func symbolNames(val haystack.Val) []string {
+	switch val := val.(type) {
+	case haystack.Symbol:
+		return []string{val.String()}
+	case haystack.List:
+		names := []string{}
+		for i := 0; i < val.Size(); i++ {
+			if name := symbolName(val.Get(i)); name != "" {
+				names = append(names, name)
+			}
+		}
+		return names
+	default:
+		return []string{}
+	}
+}
@@ -0,0 +1,435 @@
+package defs
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+// Namespace is a set of defs indexed for Haystack 4 reasoning: inheritance via the `is` tag, conjuncts like
+// ^hot-water, entity tags via `tagOn`, relationships via `of` and `containedBy`, choices, and protos.
+//
+// A Namespace is immutable once created, and safe for concurrent use.
+type Namespace struct {
+	defs        map[string]Def
+	names       []string            // sorted def names
+	subtypes    map[string][]string // def name -> direct subtype names
+	inheritance map[string][]string // def name -> itself and all supertype names, depth-first
+	conjuncts   []string            // conjunct def names
+	tagOns      map[string][]string // entity type name -> names of tags declared on it
+}
+
+// NewNamespace creates a Namespace from def Dicts. Each Dict must have a `def` Symbol tag. An error is returned if a
+// def is duplicated, if an `is` tag refers to an unknown def, or if the inheritance has a cycle.
+func NewNamespace(dicts []haystack.Dict) (*Namespace, error) {
+	ns := &Namespace{
+		defs:        map[string]Def{},
+		names:       []string{},
+		subtypes:    map[string][]string{},
+		inheritance: map[string][]string{},
+		conjuncts:   []string{},
+		tagOns:      map[string][]string{},
+	}
+	for _, dict := range dicts {
+		name := symbolName(dict.Get("def"))
+		if name == "" {
+			return nil, errors.New("def is missing 'def' symbol tag: " + dict.ToZinc())
+		}
+		if _, ok := ns.defs[name]; ok {
+			return nil, errors.New("duplicate def: ^" + name)
+		}
+		ns.defs[name] = Def{symbol: name, dict: dict}
+		ns.names = append(ns.names, name)
+	}
+	sort.Strings(ns.names)
+
+	for _, name := range ns.names {
+		def := ns.defs[name]
+		for _, supertype := range ns.supertypeNames(def) {
+			if _, ok := ns.defs[supertype]; !ok {
+				return nil, NewUnknownDefError(supertype)
+			}
+			ns.subtypes[supertype] = append(ns.subtypes[supertype], name)
+		}
+		for _, entityType := range symbolNames(def.dict.Get("tagOn")) {
+			ns.tagOns[entityType] = append(ns.tagOns[entityType], name)
+		}
+		if def.IsConjunct() {
+			ns.conjuncts = append(ns.conjuncts, name)
+		}
+	}
+	for _, name := range ns.names {
+		_, err := ns.computeInheritance(name, map[string]bool{})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ns, nil
+}
+
+// NewNamespaceFromGrid creates a Namespace from a grid with a row per def, like the response of the `defs` op.
+func NewNamespaceFromGrid(grid haystack.Grid) (*Namespace, error) {
+	dicts := make([]haystack.Dict, 0, grid.RowCount())
+	for _, row := range grid.Rows() {
+		dicts = append(dicts, withoutNulls(row.ToDict()))
+	}
+	return NewNamespace(dicts)
+}
+
+// NewNamespaceFromFiles creates a Namespace from the defs in the given Trio (.trio) and Zinc (.zinc) files.
+func NewNamespaceFromFiles(paths ...string) (*Namespace, error) {
+	dicts := []haystack.Dict{}
+	for _, path := range paths {
+		fileDicts, err := readDefFile(path)
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+		dicts = append(dicts, fileDicts...)
+	}
+	return NewNamespace(dicts)
+}
+
+func readDefFile(path string) ([]haystack.Dict, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	switch filepath.Ext(path) {
+	case ".trio":
+		var reader io.TrioReader
+		reader.InitString(string(content))
+		return reader.ReadDicts()
+	case ".zinc":
+		grid, err := io.GridFromZinc(string(content))
+		if err != nil {
+			return nil, err
+		}
+		dicts := make([]haystack.Dict, 0, grid.RowCount())
+		for _, row := range grid.Rows() {
+			dicts = append(dicts, withoutNulls(row.ToDict()))
+		}
+		return dicts, nil
+	default:
+		return nil, errors.New("unsupported def file type: " + filepath.Ext(path))
+	}
+}
+
+// Def returns the def with the given symbol name
+func (ns *Namespace) Def(name string) (Def, bool) {
+	def, ok := ns.defs[name]
+	return def, ok
+}
+
+// Has returns true if the namespace contains a def with the given symbol name
+func (ns *Namespace) Has(name string) bool {
+	_, ok := ns.defs[name]
+	return ok
+}
+
+// Defs returns all the defs, sorted by name
+func (ns *Namespace) Defs() []Def {
+	return ns.lookup(ns.names)
+}
+
+// Libs returns the library defs, like ^lib:ph, sorted by name
+func (ns *Namespace) Libs() []Def {
+	return ns.AllSubtypes("lib")
+}
+
+// ToGrid returns a grid with a row per def, sorted by name, like the response of the `defs` op. Use Grid.DiffBy
+// with the `def` column to compare namespaces.
+func (ns *Namespace) ToGrid() haystack.Grid {
+	return ns.defsGrid(ns.Defs())
+}
+
+// LibsGrid returns a grid with a row per library def, like the response of the `libs` op
+func (ns *Namespace) LibsGrid() haystack.Grid {
+	return ns.defsGrid(ns.Libs())
+}
+
+func (ns *Namespace) defsGrid(defs []Def) haystack.Grid {
+	dicts := make([]haystack.Dict, 0, len(defs))
+	for _, def := range defs {
+		dicts = append(dicts, def.dict)
+	}
+	return haystack.NewGridFromDicts(dicts)
+}
+
+// Supertypes returns the direct supertypes of the def, as declared by its `is` tag
+func (ns *Namespace) Supertypes(name string) []Def {
+	def, ok := ns.defs[name]
+	if !ok {
+		return []Def{}
+	}
+	return ns.lookup(ns.supertypeNames(def))
+}
+
+// Subtypes returns the defs that directly declare the def as a supertype, sorted by name
+func (ns *Namespace) Subtypes(name string) []Def {
+	return ns.lookup(ns.subtypes[name])
+}
+
+// AllSubtypes returns all the transitive subtypes of the def, sorted by name
+func (ns *Namespace) AllSubtypes(name string) []Def {
+	seen := map[string]bool{}
+	queue := append([]string{}, ns.subtypes[name]...)
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		queue = append(queue, ns.subtypes[next]...)
+	}
+	return ns.lookup(sortedKeys(seen))
+}
+
+// Inheritance returns the def followed by all of its transitive supertypes, in depth-first order of the `is` tags
+func (ns *Namespace) Inheritance(name string) []Def {
+	return ns.lookup(ns.inheritance[name])
+}
+
+// Is returns true if the def is the supertype, or inherits from it
+func (ns *Namespace) Is(name string, supertype string) bool {
+	for _, inherited := range ns.inheritance[name] {
+		if inherited == supertype {
+			return true
+		}
+	}
+	return false
+}
+
+// Reflect returns the defs implemented by the entity, sorted by name. These are the defs of the entity's tags, and
+// the conjuncts whose parts are all tags of the entity.
+func (ns *Namespace) Reflect(dict haystack.Dict) []Def {
+	names := []string{}
+	for _, tag := range dict.Names() {
+		if dict.Missing(tag) {
+			continue
+		}
+		if _, ok := ns.defs[tag]; ok {
+			names = append(names, tag)
+		}
+	}
+	for _, conjunct := range ns.conjuncts {
+		if hasAllTags(dict, ns.defs[conjunct].Parts()) {
+			names = append(names, conjunct)
+		}
+	}
+	sort.Strings(names)
+	return ns.lookup(names)
+}
+
+// Fits returns true if the entity implements the def, directly or through a subtype. For example, an entity with
+// the `ahu` tag fits both ^ahu and ^equip.
+func (ns *Namespace) Fits(dict haystack.Dict, name string) bool {
+	for _, def := range ns.Reflect(dict) {
+		if ns.Is(def.symbol, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Implementation returns the marker tags an entity must have to implement the def: the def itself (or its parts if
+// it is a conjunct), and any supertypes tagged `mandatory`. For example, ^ahu is implemented by the tags `ahu` and
+// `equip`.
+func (ns *Namespace) Implementation(name string) []Def {
+	return ns.lookup(ns.implementationNames(name))
+}
+
+func (ns *Namespace) implementationNames(name string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, inherited := range ns.inheritance[name] {
+		def := ns.defs[inherited]
+		if inherited != name && def.dict.Missing("mandatory") {
+			continue
+		}
+		for _, part := range def.Parts() {
+			if !seen[part] {
+				seen[part] = true
+				names = append(names, part)
+			}
+		}
+	}
+	return names
+}
+
+// TagOn returns the entity types that the tag is declared on by its `tagOn` tag
+func (ns *Namespace) TagOn(name string) []Def {
+	def, ok := ns.defs[name]
+	if !ok {
+		return []Def{}
+	}
+	return ns.lookup(symbolNames(def.dict.Get("tagOn")))
+}
+
+// Tags returns the tags that apply to the entity type, which are those declared `tagOn` it or any of its
+// supertypes. The result is sorted by name.
+func (ns *Namespace) Tags(entityType string) []Def {
+	seen := map[string]bool{}
+	for _, inherited := range ns.inheritance[entityType] {
+		for _, tag := range ns.tagOns[inherited] {
+			seen[tag] = true
+		}
+	}
+	return ns.lookup(sortedKeys(seen))
+}
+
+// Of returns the def that the values of the ref or choice tag must implement, as declared by its `of` tag. The
+// nearest `of` in the tag's inheritance is used. For example, ^siteRef is of ^site.
+func (ns *Namespace) Of(name string) (Def, bool) {
+	for _, inherited := range ns.inheritance[name] {
+		of := symbolName(ns.defs[inherited].dict.Get("of"))
+		if of != "" {
+			return ns.Def(of)
+		}
+	}
+	return Def{}, false
+}
+
+// ContainedBy returns the entity types that may contain the entity type, as declared by the `containedBy` tags of
+// it and its supertypes
+func (ns *Namespace) ContainedBy(entityType string) []Def {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, inherited := range ns.inheritance[entityType] {
+		for _, container := range symbolNames(ns.defs[inherited].dict.Get("containedBy")) {
+			if !seen[container] {
+				seen[container] = true
+				names = append(names, container)
+			}
+		}
+	}
+	return ns.lookup(names)
+}
+
+// Choices returns the options of a choice def, which are all of its subtypes. If the def is a tag with an `of` that
+// is a choice, like ^ductSection, the options of that choice are returned.
+func (ns *Namespace) Choices(name string) []Def {
+	if !ns.Is(name, "choice") {
+		return []Def{}
+	}
+	if of, ok := ns.Of(name); ok {
+		return ns.AllSubtypes(of.symbol)
+	}
+	return ns.AllSubtypes(name)
+}
+
+// ChoiceOf returns the options of the choice that the entity implements
+func (ns *Namespace) ChoiceOf(dict haystack.Dict, name string) []Def {
+	options := []Def{}
+	for _, option := range ns.Choices(name) {
+		if hasAllTags(dict, ns.implementationNames(option.symbol)) {
+			options = append(options, option)
+		}
+	}
+	return options
+}
+
+// Protos returns the prototype children of the entity, which are the Dicts in the `children` tags of the defs it
+// implements and their supertypes. Duplicates are removed.
+func (ns *Namespace) Protos(dict haystack.Dict) []haystack.Dict {
+	seenDefs := map[string]bool{}
+	seenProtos := map[string]bool{}
+	protos := []haystack.Dict{}
+	for _, def := range ns.Reflect(dict) {
+		for _, inherited := range ns.inheritance[def.symbol] {
+			if seenDefs[inherited] {
+				continue
+			}
+			seenDefs[inherited] = true
+			children, ok := ns.defs[inherited].dict.Get("children").(haystack.List)
+			if !ok {
+				continue
+			}
+			for i := 0; i < children.Size(); i++ {
+				proto, ok := children.Get(i).(haystack.Dict)
+				if !ok || seenProtos[proto.ToZinc()] {
+					continue
+				}
+				seenProtos[proto.ToZinc()] = true
+				protos = append(protos, proto)
+			}
+		}
+	}
+	return protos
+}
+
+func (ns *Namespace) supertypeNames(def Def) []string {
+	return symbolNames(def.dict.Get("is"))
+}
+
+// computeInheritance computes and caches the inheritance of the def. Visiting tracks the defs on the current path
+// to detect cycles.
+func (ns *Namespace) computeInheritance(name string, visiting map[string]bool) ([]string, error) {
+	if inheritance, ok := ns.inheritance[name]; ok {
+		return inheritance, nil
+	}
+	if visiting[name] {
+		return nil, errors.New("def inheritance cycle: ^" + name)
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+
+	seen := map[string]bool{name: true}
+	inheritance := []string{name}
+	for _, supertype := range ns.supertypeNames(ns.defs[name]) {
+		supertypeInheritance, err := ns.computeInheritance(supertype, visiting)
+		if err != nil {
+			return nil, err
+		}
+		for _, inherited := range supertypeInheritance {
+			if !seen[inherited] {
+				seen[inherited] = true
+				inheritance = append(inheritance, inherited)
+			}
+		}
+	}
+	ns.inheritance[name] = inheritance
+	return inheritance, nil
+}
+
+func (ns *Namespace) lookup(names []string) []Def {
+	defs := make([]Def, 0, len(names))
+	for _, name := range names {
+		if def, ok := ns.defs[name]; ok {
+			defs = append(defs, def)
+		}
+	}
+	return defs
+}
+
+func hasAllTags(dict haystack.Dict, names []string) bool {
+	for _, name := range names {
+		if dict.Missing(name) {
+			return false
+		}
+	}
+	return true
+}
+
+func withoutNulls(dict haystack.Dict) haystack.Dict {
+	items := map[string]haystack.Val{}
+	for _, name := range dict.Names() {
+		if dict.Has(name) {
+			items[name] = dict.Get(name)
+		}
+	}
+	return haystack.NewDict(items)
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,253 @@
+package defs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+	"github.com/stretchr/testify/assert"
+)
+
+const testDefs = `def: ^marker
+---
+def: ^ref
+---
+def: ^str
+---
+def: ^choice
+---
+def: ^entity
+is: ^marker
+---
+def: ^mandatory
+is: ^marker
+---
+def: ^id
+is: ^ref
+tagOn: ^entity
+---
+def: ^dis
+is: ^str
+tagOn: ^entity
+---
+def: ^site
+is: ^entity
+mandatory
+---
+def: ^equip
+is: ^entity
+mandatory
+containedBy: [^site, ^equip]
+---
+def: ^point
+is: ^entity
+mandatory
+containedBy: [^equip, ^site]
+---
+def: ^ahu
+is: ^equip
+lib: ^lib:phIoT
+doc: Air handling unit
+children: Zinc:
+  [{discharge air temp sensor point}, {return air temp sensor point}]
+---
+def: ^rtu
+is: ^ahu
+children: Zinc:
+  [{discharge air temp sensor point}, {zone air temp sensor point}]
+---
+def: ^siteRef
+is: ^ref
+of: ^site
+tagOn: [^equip, ^point]
+---
+def: ^area
+is: ^marker
+tagOn: ^site
+---
+def: ^hot
+is: ^marker
+---
+def: ^water
+is: ^marker
+---
+def: ^hot-water
+is: ^water
+---
+def: ^ductSection
+is: ^choice
+of: ^airSection
+tagOn: ^point
+---
+def: ^airSection
+is: ^marker
+---
+def: ^discharge
+is: ^airSection
+---
+def: ^return
+is: ^airSection
+---
+def: ^filetype:zinc
+is: ^marker
+`
+
+func testNamespace(t *testing.T) *Namespace {
+	var reader io.TrioReader
+	reader.InitString(testDefs)
+	dicts, err := reader.ReadDicts()
+	assert.Nil(t, err)
+	ns, err := NewNamespace(dicts)
+	assert.Nil(t, err)
+	return ns
+}
+
+func TestNamespace_Def(t *testing.T) {
+	ns := testNamespace(t)
+	ahu, ok := ns.Def("ahu")
+	assert.True(t, ok)
+	assert.Equal(t, haystack.NewSymbol("ahu"), ahu.Symbol())
+	assert.Equal(t, "lib:phIoT", ahu.Lib())
+	assert.Equal(t, "Air handling unit", ahu.Doc())
+	assert.False(t, ahu.IsConjunct())
+
+	hotWater, _ := ns.Def("hot-water")
+	assert.True(t, hotWater.IsConjunct())
+	assert.Equal(t, []string{"hot", "water"}, hotWater.Parts())
+
+	zinc, _ := ns.Def("filetype:zinc")
+	assert.True(t, zinc.IsFeatureKey())
+	assert.False(t, zinc.IsConjunct())
+
+	assert.False(t, ns.Has("foo"))
+}
+
+func TestNamespace_inheritance(t *testing.T) {
+	ns := testNamespace(t)
+	assert.Equal(t, []string{"ahu"}, defNames(ns.Supertypes("rtu")))
+	assert.Equal(t, []string{"ahu"}, defNames(ns.Subtypes("equip")))
+	assert.Equal(t, []string{"ahu", "equip", "point", "rtu", "site"}, defNames(ns.AllSubtypes("entity")))
+	assert.Equal(t, []string{"rtu", "ahu", "equip", "entity", "marker"}, defNames(ns.Inheritance("rtu")))
+	assert.True(t, ns.Is("rtu", "equip"))
+	assert.True(t, ns.Is("equip", "equip"))
+	assert.False(t, ns.Is("equip", "ahu"))
+}
+
+func TestNamespace_Reflect(t *testing.T) {
+	ns := testNamespace(t)
+	entity := haystack.NewDict(map[string]haystack.Val{
+		"id":      haystack.NewRef("a", ""),
+		"dis":     haystack.NewStr("Hot water pump"),
+		"equip":   haystack.NewMarker(),
+		"hot":     haystack.NewMarker(),
+		"water":   haystack.NewMarker(),
+		"custom":  haystack.NewMarker(),
+		"siteRef": haystack.NewRef("s", ""),
+	})
+	assert.Equal(
+		t,
+		[]string{"dis", "equip", "hot", "hot-water", "id", "siteRef", "water"},
+		defNames(ns.Reflect(entity)),
+	)
+}
+
+func TestNamespace_Fits(t *testing.T) {
+	ns := testNamespace(t)
+	rtu := haystack.NewDict(map[string]haystack.Val{"rtu": haystack.NewMarker(), "equip": haystack.NewMarker()})
+	assert.True(t, ns.Fits(rtu, "rtu"))
+	assert.True(t, ns.Fits(rtu, "ahu"))
+	assert.True(t, ns.Fits(rtu, "equip"))
+	assert.False(t, ns.Fits(rtu, "point"))
+
+	hotWater := haystack.NewDict(map[string]haystack.Val{"hot": haystack.NewMarker(), "water": haystack.NewMarker()})
+	assert.True(t, ns.Fits(hotWater, "hot-water"))
+	assert.False(t, ns.Fits(haystack.NewDict(map[string]haystack.Val{"hot": haystack.NewMarker()}), "hot-water"))
+}
+
+func TestNamespace_Implementation(t *testing.T) {
+	ns := testNamespace(t)
+	assert.Equal(t, []string{"rtu", "equip"}, defNames(ns.Implementation("rtu")))
+	assert.Equal(t, []string{"equip"}, defNames(ns.Implementation("equip")))
+	assert.Equal(t, []string{"hot", "water"}, defNames(ns.Implementation("hot-water")))
+	assert.Equal(t, []string{}, defNames(ns.Implementation("foo")))
+}
+
+func TestNamespace_tags(t *testing.T) {
+	ns := testNamespace(t)
+	assert.Equal(t, []string{"equip", "point"}, defNames(ns.TagOn("siteRef")))
+	assert.Equal(t, []string{"dis", "id", "siteRef"}, defNames(ns.Tags("rtu")))
+	assert.Equal(t, []string{"area", "dis", "id"}, defNames(ns.Tags("site")))
+
+	of, ok := ns.Of("siteRef")
+	assert.True(t, ok)
+	assert.Equal(t, "site", of.Name())
+	_, ok = ns.Of("dis")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"site", "equip"}, defNames(ns.ContainedBy("ahu")))
+}
+
+func TestNamespace_Choices(t *testing.T) {
+	ns := testNamespace(t)
+	assert.Equal(t, []string{"discharge", "return"}, defNames(ns.Choices("ductSection")))
+	assert.Equal(t, []string{}, defNames(ns.Choices("ahu")))
+
+	point := haystack.NewDict(map[string]haystack.Val{"point": haystack.NewMarker(), "discharge": haystack.NewMarker()})
+	assert.Equal(t, []string{"discharge"}, defNames(ns.ChoiceOf(point, "ductSection")))
+}
+
+func TestNamespace_Protos(t *testing.T) {
+	ns := testNamespace(t)
+	rtu := haystack.NewDict(map[string]haystack.Val{"rtu": haystack.NewMarker(), "equip": haystack.NewMarker()})
+	protos := ns.Protos(rtu)
+	zinc := []string{}
+	for _, proto := range protos {
+		zinc = append(zinc, proto.ToZinc())
+	}
+	assert.Equal(t, []string{
+		"{air discharge point sensor temp}",
+		"{air point sensor temp zone}",
+		"{air point return sensor temp}",
+	}, zinc)
+}
+
+func TestNewNamespace_errors(t *testing.T) {
+	_, err := NewNamespace([]haystack.Dict{haystack.NewDict(map[string]haystack.Val{"dis": haystack.NewStr("x")})})
+	assert.NotNil(t, err)
+
+	def := haystack.NewDict(map[string]haystack.Val{"def": haystack.NewSymbol("a")})
+	_, err = NewNamespace([]haystack.Dict{def, def})
+	assert.EqualError(t, err, "duplicate def: ^a")
+
+	_, err = NewNamespace([]haystack.Dict{
+		haystack.NewDict(map[string]haystack.Val{"def": haystack.NewSymbol("a"), "is": haystack.NewSymbol("b")}),
+	})
+	assert.Equal(t, NewUnknownDefError("b"), err)
+
+	_, err = NewNamespace([]haystack.Dict{
+		haystack.NewDict(map[string]haystack.Val{"def": haystack.NewSymbol("a"), "is": haystack.NewSymbol("b")}),
+		haystack.NewDict(map[string]haystack.Val{"def": haystack.NewSymbol("b"), "is": haystack.NewSymbol("a")}),
+	})
+	assert.NotNil(t, err)
+}
+
+func TestNewNamespaceFromFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "defs")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	trioPath := filepath.Join(dir, "defs.trio")
+	assert.Nil(t, ioutil.WriteFile(trioPath, []byte("def: ^marker\n---\ndef: ^site\nis: ^marker\n"), 0644))
+	zincPath := filepath.Join(dir, "defs.zinc")
+	assert.Nil(t, ioutil.WriteFile(zincPath, []byte("ver:\"3.0\"\ndef,is\n^equip,^marker\n"), 0644))
+
+	ns, err := NewNamespaceFromFiles(trioPath, zincPath)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"equip", "marker", "site"}, defNames(ns.Defs()))
+
+	_, err = NewNamespaceFromFiles(filepath.Join(dir, "defs.json"))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,68 @@
+package defs
+
+//go:generate go run libgen.go
+
+import (
+	"sync"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+// StandardLibs are the names of the embedded standard def libraries, in dependency order. The embedded Project Haystack
+// libraries are a curated core subset, generated from the Trio sources in src/, and haystackExt holds the defs this
+// module adds to them. See libgen.go.
+var StandardLibs = []string{"ph", "phScience", "phIoT", "phIct", "haystackExt"}
+
+var standardNamespace struct {
+	once sync.Once
+	ns   *Namespace
+	err  error
+}
+
+// StandardVersion returns the version of the embedded Project Haystack libraries. While the embedded libraries are a
+// subset of a release, the version has a "-subset" suffix, like "3.9.10-subset".
+func StandardVersion() string {
+	return stdlibVersions["ph"]
+}
+
+// StandardLibDefs returns the defs of the embedded standard library with the given name, like "phIoT". Each def is
+// tagged with the `lib` it belongs to. An UnknownDefError is returned if the library doesn't exist.
+func StandardLibDefs(lib string) ([]haystack.Dict, error) {
+	source, ok := stdlibSources[lib]
+	if !ok {
+		return nil, NewUnknownDefError("lib:" + lib)
+	}
+	var reader io.TrioReader
+	reader.InitString(source)
+	dicts, err := reader.ReadDicts()
+	if err != nil {
+		return nil, err
+	}
+
+	libSymbol := haystack.NewSymbol("lib:" + lib)
+	for i, dict := range dicts {
+		if dict.Missing("lib") {
+			dicts[i] = dict.Set("lib", libSymbol)
+		}
+	}
+	return dicts, nil
+}
+
+// StandardNamespace returns a Namespace of all the embedded standard libraries. The namespace is built on first use
+// and shared.
+func StandardNamespace() (*Namespace, error) {
+	standardNamespace.once.Do(func() {
+		dicts := []haystack.Dict{}
+		for _, lib := range StandardLibs {
+			libDicts, err := StandardLibDefs(lib)
+			if err != nil {
+				standardNamespace.err = err
+				return
+			}
+			dicts = append(dicts, libDicts...)
+		}
+		standardNamespace.ns, standardNamespace.err = NewNamespace(dicts)
+	})
+	return standardNamespace.ns, standardNamespace.err
+}
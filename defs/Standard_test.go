@@ -0,0 +1,79 @@
+package defs
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStandardNamespace(t *testing.T) {
+	ns, err := StandardNamespace()
+	assert.Nil(t, err)
+	assert.Equal(t, "3.9.10-subset", StandardVersion())
+	assert.Equal(t, []string{"lib:haystackExt", "lib:ph", "lib:phIct", "lib:phIoT", "lib:phScience"}, defNames(ns.Libs()))
+
+	ahu, ok := ns.Def("ahu")
+	assert.True(t, ok)
+	assert.Equal(t, "lib:phIoT", ahu.Lib())
+	assert.Equal(t, []string{"ahu", "equip"}, defNames(ns.Implementation("ahu")))
+	assert.Equal(t, []string{"site", "space", "equip"}, defNames(ns.ContainedBy("rtu")))
+
+	delivery, ok := ns.Def("ahuZoneDelivery")
+	assert.True(t, ok)
+	assert.Equal(t, "lib:haystackExt", delivery.Lib())
+
+	rtu := haystack.NewDict(map[string]haystack.Val{
+		"rtu":   haystack.NewMarker(),
+		"equip": haystack.NewMarker(),
+		"hot":   haystack.NewMarker(),
+		"water": haystack.NewMarker(),
+	})
+	assert.True(t, ns.Fits(rtu, "airHandlingEquip"))
+	assert.True(t, ns.Fits(rtu, "hot-water"))
+	assert.False(t, ns.Fits(rtu, "point"))
+	assert.Equal(t, 5, len(ns.Protos(rtu)))
+
+	again, _ := StandardNamespace()
+	assert.True(t, ns == again)
+}
+
+// Every symbol referenced by a standard def must be defined
+func TestStandardNamespace_references(t *testing.T) {
+	ns, err := StandardNamespace()
+	assert.Nil(t, err)
+	for _, def := range ns.Defs() {
+		for _, tag := range []string{"is", "tagOn", "of", "containedBy", "depends", "lib"} {
+			for _, name := range symbolNames(def.Dict().Get(tag)) {
+				assert.True(t, ns.Has(name), "^"+def.Name()+" "+tag+" references unknown ^"+name)
+			}
+		}
+	}
+}
+
+func TestStandardLibDefs(t *testing.T) {
+	dicts, err := StandardLibDefs("phIct")
+	assert.Nil(t, err)
+	for _, dict := range dicts {
+		assert.Equal(t, haystack.NewSymbol("lib:phIct"), dict.Get("lib"))
+	}
+
+	_, err = StandardLibDefs("foo")
+	assert.Equal(t, NewUnknownDefError("lib:foo"), err)
+}
+
+func TestNamespace_ToGrid(t *testing.T) {
+	ns, _ := StandardNamespace()
+	grid := ns.ToGrid()
+	assert.Equal(t, len(ns.Defs()), grid.RowCount())
+	assert.Equal(t, 5, ns.LibsGrid().RowCount())
+
+	diff, err := grid.DiffBy(ns.ToGrid(), "def")
+	assert.Nil(t, err)
+	assert.True(t, diff.IsEmpty())
+
+	// A namespace built from the grid is equivalent
+	fromGrid, err := NewNamespaceFromGrid(grid)
+	assert.Nil(t, err)
+	assert.Equal(t, defNames(ns.Defs()), defNames(fromGrid.Defs()))
+}
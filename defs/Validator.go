@@ -0,0 +1,402 @@
+package defs
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Validation rules reported in Findings
+const (
+	// RuleUnknownTag is a tag that has no def in the namespace
+	RuleUnknownTag = "unknownTag"
+	// RuleWrongKind is a tag value that is not the kind required by the tag's def
+	RuleWrongKind = "wrongKind"
+	// RuleRefTarget is a ref tag that points to an entity of the wrong type, as declared by the tag's `of`
+	RuleRefTarget = "refTarget"
+	// RuleMissingTag is a tag that is required by the entity's types, but not present
+	RuleMissingTag = "missingTag"
+	// RuleChoice is an entity that implements more than one option of a choice
+	RuleChoice = "choice"
+	// RulePointKind is a point tag that is inconsistent with the point's `kind`
+	RulePointKind = "pointKind"
+)
+
+// Finding severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// Finding is a single validation problem with an entity.
+type Finding struct {
+	// Id is the id of the entity, or Null if it has none
+	Id haystack.Val
+	// Tag is the name of the tag the finding is about
+	Tag string
+	// Rule is the validation rule that failed, like RuleUnknownTag
+	Rule string
+	// Severity is SeverityError or SeverityWarning
+	Severity string
+	// Msg describes the problem
+	Msg string
+}
+
+// Findings is a list of validation problems.
+type Findings []Finding
+
+// ToGrid renders the findings as a Grid with the columns: id, tag, rule, severity, msg
+func (findings Findings) ToGrid() haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("tag")
+	gb.AddColNoMeta("rule")
+	gb.AddColNoMeta("severity")
+	gb.AddColNoMeta("msg")
+	for _, finding := range findings {
+		gb.AddRow([]haystack.Val{
+			finding.Id,
+			haystack.NewStr(finding.Tag),
+			haystack.NewStr(finding.Rule),
+			haystack.NewStr(finding.Severity),
+			haystack.NewStr(finding.Msg),
+		})
+	}
+	return gb.ToGrid()
+}
+
+// HasErrors returns true if any finding has an error severity
+func (findings Findings) HasErrors() bool {
+	for _, finding := range findings {
+		if finding.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator checks that entities are well-formed according to a Namespace. It reports unknown tags, values of the
+// wrong kind, refs to entities of the wrong type, missing required tags, choice violations, and point tags that are
+// inconsistent with the point's kind.
+type Validator struct {
+	ns       *Namespace
+	resolver haystack.RefResolver
+	required map[string][]string
+}
+
+// NewValidator creates a Validator for the namespace. By default, entities are required to have an `id` and points
+// are required to have a `kind`. Use Require to add more.
+func NewValidator(ns *Namespace) *Validator {
+	return &Validator{
+		ns: ns,
+		required: map[string][]string{
+			"entity": {"id"},
+			"point":  {"kind"},
+		},
+	}
+}
+
+// SetResolver sets a resolver used to look up ref targets that are not in the validated entities. If no resolver is
+// set, refs to entities outside of the validated set are not checked.
+func (validator *Validator) SetResolver(resolver haystack.RefResolver) {
+	validator.resolver = resolver
+}
+
+// Require adds tags that are required on all entities that fit the entity type
+func (validator *Validator) Require(entityType string, tags ...string) {
+	validator.required[entityType] = append(validator.required[entityType], tags...)
+}
+
+// ValidateGrid validates each row of the grid, like the result of Client.Read
+func (validator *Validator) ValidateGrid(grid haystack.Grid) Findings {
+	dicts := make([]haystack.Dict, 0, grid.RowCount())
+	for _, row := range grid.Rows() {
+		dicts = append(dicts, row.ToDict())
+	}
+	return validator.Validate(dicts)
+}
+
+// Validate validates the entities. Refs between the entities are checked against each other, and other refs are
+// checked using the resolver, if set.
+func (validator *Validator) Validate(dicts []haystack.Dict) Findings {
+	entities := map[string]haystack.Dict{}
+	for _, dict := range dicts {
+		if id, ok := dict.Get("id").(haystack.Ref); ok {
+			entities[id.Id()] = dict
+		}
+	}
+	findings := Findings{}
+	for _, dict := range dicts {
+		findings = append(findings, validator.validate(dict, entities)...)
+	}
+	return findings
+}
+
+// ValidateDict validates a single entity
+func (validator *Validator) ValidateDict(dict haystack.Dict) Findings {
+	return validator.Validate([]haystack.Dict{dict})
+}
+
+func (validator *Validator) validate(dict haystack.Dict, entities map[string]haystack.Dict) Findings {
+	ns := validator.ns
+	id := dict.Get("id")
+	if _, ok := id.(haystack.Ref); !ok {
+		id = haystack.NewNull()
+	}
+	findings := Findings{}
+	add := func(tag string, rule string, severity string, msg string) {
+		findings = append(findings, Finding{Id: id, Tag: tag, Rule: rule, Severity: severity, Msg: msg})
+	}
+
+	names := dict.Names()
+	sort.Strings(names)
+	for _, name := range names {
+		val := dict.Get(name)
+		if dict.Missing(name) {
+			continue
+		}
+		def, ok := ns.Def(name)
+		if !ok {
+			msg := "Unknown tag: " + name
+			if suggestion := validator.suggest(name); suggestion != "" {
+				msg = msg + " (did you mean '" + suggestion + "'?)"
+			}
+			add(name, RuleUnknownTag, SeverityWarning, msg)
+			continue
+		}
+
+		expected := validator.expectedKind(def.symbol)
+		actual := valKind(val)
+		if expected != "" && actual != "na" && ns.Has(actual) && !ns.Is(actual, expected) {
+			add(name, RuleWrongKind, SeverityError, "Tag '"+name+"' must be "+expected+", not "+actual)
+			continue
+		}
+
+		if of, ok := ns.Of(name); ok && !ns.Is(name, "choice") {
+			if ref, isRef := val.(haystack.Ref); isRef {
+				target, found := validator.resolve(ref, entities)
+				if found && !ns.Fits(target, of.symbol) {
+					add(name, RuleRefTarget, SeverityError, "Tag '"+name+"' must reference a "+of.symbol+": "+ref.ToZinc())
+				}
+			}
+		}
+	}
+
+	reflection := ns.Reflect(dict)
+	missing := map[string]bool{}
+	for _, def := range reflection {
+		for _, tag := range ns.implementationNames(def.symbol) {
+			if dict.Missing(tag) && !missing[tag] {
+				missing[tag] = true
+				add(tag, RuleMissingTag, SeverityError, "Missing tag '"+tag+"' required by "+def.symbol)
+			}
+		}
+	}
+	entityTypes := sortedRequiredTypes(validator.required)
+	for _, entityType := range entityTypes {
+		if !ns.Fits(dict, entityType) {
+			continue
+		}
+		for _, tag := range validator.required[entityType] {
+			if dict.Missing(tag) && !missing[tag] {
+				missing[tag] = true
+				add(tag, RuleMissingTag, SeverityError, "Missing tag '"+tag+"' required by "+entityType)
+			}
+		}
+	}
+
+	for _, choice := range ns.Subtypes("choice") {
+		if !validator.choiceApplies(dict, choice) {
+			continue
+		}
+		options := ns.ChoiceOf(dict, choice.symbol)
+		if len(options) > 1 {
+			add(choice.symbol, RuleChoice, SeverityError, "Multiple "+choice.symbol+" choices: "+strings.Join(defNames(options), ", "))
+		}
+	}
+
+	if ns.Fits(dict, "point") {
+		findings = append(findings, validator.validatePointKind(dict, id)...)
+	}
+	return findings
+}
+
+// validatePointKind checks that the value tags of a point are consistent with its kind
+func (validator *Validator) validatePointKind(dict haystack.Dict, id haystack.Val) Findings {
+	kind, err := dict.GetStr("kind")
+	if err != nil {
+		return Findings{}
+	}
+	findings := Findings{}
+	add := func(tag string, msg string) {
+		findings = append(findings, Finding{Id: id, Tag: tag, Rule: RulePointKind, Severity: SeverityError, Msg: msg})
+	}
+	switch kind.String() {
+	case "Number":
+		if dict.Has("enum") {
+			add("enum", "Tag 'enum' is not valid on Number points")
+		}
+	case "Bool", "Str":
+		for _, tag := range []string{"unit", "minVal", "maxVal"} {
+			if dict.Has(tag) {
+				add(tag, "Tag '"+tag+"' is only valid on Number points, not "+kind.String())
+			}
+		}
+	default:
+		add("kind", "Point kind must be Number, Bool, or Str, not "+kind.String())
+	}
+	return findings
+}
+
+// kinds are the defs of the value kinds, including the abstract kinds
+var kinds = map[string]bool{
+	"val": true, "scalar": true, "collection": true, "marker": true, "na": true, "remove": true, "bool": true,
+	"number": true, "str": true, "uri": true, "ref": true, "symbol": true, "date": true, "time": true,
+	"dateTime": true, "coord": true, "xstr": true, "dict": true, "list": true, "grid": true,
+}
+
+// expectedKind returns the name of the nearest value kind in the def's inheritance
+func (validator *Validator) expectedKind(name string) string {
+	for _, inherited := range validator.ns.inheritance[name] {
+		if inherited != name && kinds[inherited] {
+			return inherited
+		}
+	}
+	return ""
+}
+
+// choiceApplies returns true if the entity is one of the types the choice is declared on, or the choice has no tagOn
+func (validator *Validator) choiceApplies(dict haystack.Dict, choice Def) bool {
+	entityTypes := validator.ns.TagOn(choice.symbol)
+	if len(entityTypes) == 0 {
+		return true
+	}
+	for _, entityType := range entityTypes {
+		if validator.ns.Fits(dict, entityType.symbol) {
+			return true
+		}
+	}
+	return false
+}
+
+func (validator *Validator) resolve(ref haystack.Ref, entities map[string]haystack.Dict) (haystack.Dict, bool) {
+	if target, ok := entities[ref.Id()]; ok {
+		return target, true
+	}
+	if validator.resolver == nil {
+		return haystack.EmptyDict(), false
+	}
+	target, err := validator.resolver.Resolve(ref)
+	if err != nil {
+		return haystack.EmptyDict(), false
+	}
+	return target, true
+}
+
+// suggest returns the name of a def that is a close misspelling of the tag, or an empty string if there is none
+func (validator *Validator) suggest(tag string) string {
+	best := ""
+	bestDistance := 3
+	for _, name := range validator.ns.names {
+		if strings.ContainsAny(name, ":-") {
+			continue
+		}
+		distance := editDistance(strings.ToLower(tag), strings.ToLower(name))
+		if distance < bestDistance {
+			best = name
+			bestDistance = distance
+		}
+	}
+	return best
+}
+
+// valKind returns the def name of the value's kind, like "number"
+func valKind(val haystack.Val) string {
+	switch val.(type) {
+	case haystack.Marker:
+		return "marker"
+	case haystack.NA:
+		return "na"
+	case haystack.Remove:
+		return "remove"
+	case haystack.Bool, *haystack.Bool:
+		return "bool"
+	case haystack.Number:
+		return "number"
+	case haystack.Str:
+		return "str"
+	case haystack.Uri:
+		return "uri"
+	case haystack.Ref:
+		return "ref"
+	case haystack.Symbol:
+		return "symbol"
+	case haystack.Date:
+		return "date"
+	case haystack.Time:
+		return "time"
+	case haystack.DateTime:
+		return "dateTime"
+	case haystack.Coord:
+		return "coord"
+	case haystack.XStr:
+		return "xstr"
+	case haystack.Dict:
+		return "dict"
+	case haystack.List:
+		return "list"
+	case haystack.Grid:
+		return "grid"
+	default:
+		return ""
+	}
+}
+
+func sortedRequiredTypes(required map[string][]string) []string {
+	types := make([]string, 0, len(required))
+	for entityType := range required {
+		types = append(types, entityType)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func defNames(defs []Def) []string {
+	names := make([]string, 0, len(defs))
+	for _, def := range defs {
+		names = append(names, def.symbol)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between the strings
+func editDistance(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
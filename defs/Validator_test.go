@@ -0,0 +1,177 @@
+package defs
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func testValidator(t *testing.T) *Validator {
+	ns, err := StandardNamespace()
+	assert.Nil(t, err)
+	return NewValidator(ns)
+}
+
+func findingRules(findings Findings) []string {
+	rules := []string{}
+	for _, finding := range findings {
+		rules = append(rules, finding.Tag+":"+finding.Rule)
+	}
+	return rules
+}
+
+func TestValidator_valid(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.Validate([]haystack.Dict{
+		haystack.NewDict(map[string]haystack.Val{
+			"id":   haystack.NewRef("site", "Site"),
+			"dis":  haystack.NewStr("Site"),
+			"site": haystack.NewMarker(),
+			"area": haystack.NewNumber(1000, "ft²"),
+		}),
+		haystack.NewDict(map[string]haystack.Val{
+			"id":         haystack.NewRef("ahu", "AHU"),
+			"ahu":        haystack.NewMarker(),
+			"equip":      haystack.NewMarker(),
+			"directZone": haystack.NewMarker(),
+			"siteRef":    haystack.NewRef("site", ""),
+		}),
+		haystack.NewDict(map[string]haystack.Val{
+			"id":        haystack.NewRef("point", ""),
+			"discharge": haystack.NewMarker(),
+			"air":       haystack.NewMarker(),
+			"temp":      haystack.NewMarker(),
+			"sensor":    haystack.NewMarker(),
+			"point":     haystack.NewMarker(),
+			"kind":      haystack.NewStr("Number"),
+			"unit":      haystack.NewStr("°F"),
+			"equipRef":  haystack.NewRef("ahu", ""),
+			"siteRef":   haystack.NewRef("site", ""),
+		}),
+	})
+	assert.Equal(t, Findings{}, findings)
+	assert.False(t, findings.HasErrors())
+}
+
+func TestValidator_unknownTag(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":     haystack.NewRef("a", ""),
+		"site":   haystack.NewMarker(),
+		"geoCty": haystack.NewStr("Richmond"),
+		"custom": haystack.NewMarker(),
+	}))
+	assert.Equal(t, []string{"custom:unknownTag", "geoCty:unknownTag"}, findingRules(findings))
+	assert.Equal(t, "Unknown tag: geoCty (did you mean 'geoCity'?)", findings[1].Msg)
+	assert.Equal(t, SeverityWarning, findings[1].Severity)
+	assert.False(t, findings.HasErrors())
+}
+
+func TestValidator_wrongKind(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":   haystack.NewRef("a", ""),
+		"site": haystack.NewStr("yes"),
+		"area": haystack.NewStr("1000ft²"),
+		"tz":   haystack.NewNA(),
+	}))
+	assert.Equal(t, []string{"area:wrongKind", "site:wrongKind"}, findingRules(findings))
+	assert.Equal(t, "Tag 'area' must be number, not str", findings[0].Msg)
+}
+
+func TestValidator_refTarget(t *testing.T) {
+	validator := testValidator(t)
+	equip := haystack.NewDict(map[string]haystack.Val{
+		"id":    haystack.NewRef("equip", ""),
+		"equip": haystack.NewMarker(),
+	})
+	point := haystack.NewDict(map[string]haystack.Val{
+		"id":       haystack.NewRef("point", ""),
+		"point":    haystack.NewMarker(),
+		"kind":     haystack.NewStr("Bool"),
+		"siteRef":  haystack.NewRef("equip", ""),
+		"equipRef": haystack.NewRef("other", ""),
+	})
+	findings := validator.Validate([]haystack.Dict{equip, point})
+	assert.Equal(t, []string{"siteRef:refTarget"}, findingRules(findings))
+
+	// Refs outside the set are checked with the resolver
+	validator.SetResolver(haystack.RefResolverFunc(func(ref haystack.Ref) (haystack.Dict, error) {
+		if ref.Id() == "other" {
+			return haystack.NewDict(map[string]haystack.Val{"site": haystack.NewMarker()}), nil
+		}
+		return haystack.EmptyDict(), errors.New("not found")
+	}))
+	findings = validator.Validate([]haystack.Dict{equip, point})
+	assert.Equal(t, []string{"equipRef:refTarget", "siteRef:refTarget"}, findingRules(findings))
+}
+
+func TestValidator_missingTag(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"ahu":    haystack.NewMarker(),
+		"sensor": haystack.NewMarker(),
+	}))
+	assert.Equal(t, []string{"equip:missingTag", "point:missingTag", "id:missingTag", "kind:missingTag"}, findingRules(findings))
+	assert.Equal(t, haystack.NewNull(), findings[0].Id)
+
+	validator.Require("site", "tz")
+	findings = validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":   haystack.NewRef("a", ""),
+		"site": haystack.NewMarker(),
+	}))
+	assert.Equal(t, []string{"tz:missingTag"}, findingRules(findings))
+}
+
+func TestValidator_choice(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":         haystack.NewRef("a", ""),
+		"ahu":        haystack.NewMarker(),
+		"equip":      haystack.NewMarker(),
+		"directZone": haystack.NewMarker(),
+		"vavZone":    haystack.NewMarker(),
+	}))
+	assert.Equal(t, []string{"ahuZoneDelivery:choice"}, findingRules(findings))
+	assert.Equal(t, "Multiple ahuZoneDelivery choices: directZone, vavZone", findings[0].Msg)
+
+	// Choices only apply to the entity types they are declared on
+	findings = validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":        haystack.NewRef("a", ""),
+		"equip":     haystack.NewMarker(),
+		"discharge": haystack.NewMarker(),
+		"return":    haystack.NewMarker(),
+	}))
+	assert.Equal(t, []string{}, findingRules(findings))
+}
+
+func TestValidator_pointKind(t *testing.T) {
+	validator := testValidator(t)
+	findings := validator.ValidateDict(haystack.NewDict(map[string]haystack.Val{
+		"id":     haystack.NewRef("a", ""),
+		"point":  haystack.NewMarker(),
+		"kind":   haystack.NewStr("Bool"),
+		"unit":   haystack.NewStr("°F"),
+		"minVal": haystack.NewNumber(0, ""),
+	}))
+	assert.Equal(t, []string{"unit:pointKind", "minVal:pointKind"}, findingRules(findings))
+}
+
+func TestFindings_ToGrid(t *testing.T) {
+	validator := testValidator(t)
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("site")
+	gb.AddColNoMeta("bogus")
+	gb.AddRow([]haystack.Val{haystack.NewRef("a", ""), haystack.NewMarker(), haystack.NewMarker()})
+	gb.AddRow([]haystack.Val{haystack.NewRef("b", ""), haystack.NewMarker(), haystack.NewNull()})
+
+	grid := validator.ValidateGrid(gb.ToGrid()).ToGrid()
+	assert.Equal(t, 1, grid.RowCount())
+	assert.Equal(t, haystack.NewRef("a", ""), grid.RowAt(0).Get("id"))
+	assert.Equal(t, haystack.NewStr("bogus"), grid.RowAt(0).Get("tag"))
+	assert.Equal(t, haystack.NewStr(RuleUnknownTag), grid.RowAt(0).Get("rule"))
+	assert.Equal(t, haystack.NewStr(SeverityWarning), grid.RowAt(0).Get("severity"))
+}
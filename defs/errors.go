@@ -0,0 +1,15 @@
+package defs
+
+// UnknownDefError occurs when a symbol does not refer to a def in the namespace.
+type UnknownDefError struct {
+	Name string
+}
+
+// NewUnknownDefError creates a new UnknownDefError object.
+func NewUnknownDefError(name string) UnknownDefError {
+	return UnknownDefError{Name: name}
+}
+
+func (err UnknownDefError) Error() string {
+	return "Unknown def: ^" + err.Name
+}
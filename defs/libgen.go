@@ -0,0 +1,127 @@
+//go:build ignore
+// +build ignore
+
+// libgen generates the embedded standard def libraries in stdlib.go from Trio sources. Each library is a directory
+// of .trio files, and must include a lib.trio that declares its `^lib:<name>` def with a `version` tag.
+//
+// The bundled Project Haystack sources in src/ are a curated core subset, versioned with a "-subset" suffix. To embed
+// the complete libraries, copy the ph, phScience, phIoT and phIct directories of a Project Haystack defs release over
+// the ones in src/ as is. The haystackExt library holds the defs this module adds on top, and is kept separate so
+// that the vendored libraries are never edited.
+//
+// Usage:
+//
+//	go run libgen.go [-src src] [-out stdlib.go]
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+// The standard libraries, in dependency order
+var libs = []string{"ph", "phScience", "phIoT", "phIct", "haystackExt"}
+
+func main() {
+	src := flag.String("src", "src", "directory containing a sub-directory of Trio files per library")
+	out := flag.String("out", "stdlib.go", "path of the generated Go file")
+	flag.Parse()
+
+	sources := map[string]string{}
+	versions := map[string]string{}
+	for _, lib := range libs {
+		source, version, err := readLib(filepath.Join(*src, lib), lib)
+		if err != nil {
+			log.Fatalf("%s: %v", lib, err)
+		}
+		sources[lib] = source
+		versions[lib] = version
+	}
+
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "// Code generated by \"go run libgen.go\"; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package defs\n\n")
+	fmt.Fprintf(&buf, "// stdlibVersions maps the standard library names to their versions.\n")
+	fmt.Fprintf(&buf, "var stdlibVersions = map[string]string{\n")
+	for _, lib := range libs {
+		fmt.Fprintf(&buf, "\t%q: %q,\n", lib, versions[lib])
+	}
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// stdlibSources maps the standard library names to their Trio source.\n")
+	fmt.Fprintf(&buf, "var stdlibSources = map[string]string{\n")
+	for _, lib := range libs {
+		fmt.Fprintf(&buf, "\t%q: %q,\n", lib, sources[lib])
+	}
+	fmt.Fprintf(&buf, "}\n")
+
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, formatted, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// readLib concatenates the Trio files of the library, with comments removed, and returns the library version. The
+// files are parsed to check that they are valid.
+func readLib(dir string, lib string) (string, string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.trio"))
+	if err != nil {
+		return "", "", err
+	}
+	if len(paths) == 0 {
+		return "", "", fmt.Errorf("no trio files in %s", dir)
+	}
+	sort.Strings(paths)
+
+	records := []string{}
+	version := ""
+	for _, path := range paths {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return "", "", err
+		}
+		text := stripComments(string(content))
+
+		var reader io.TrioReader
+		reader.InitString(text)
+		dicts, err := reader.ReadDicts()
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %v", path, err)
+		}
+		for _, dict := range dicts {
+			if dict.Get("def").ToZinc() == "^lib:"+lib {
+				if str, ok := dict.Get("version").(haystack.Str); ok {
+					version = str.String()
+				}
+			}
+		}
+		records = append(records, strings.TrimSpace(text))
+	}
+	if version == "" {
+		return "", "", fmt.Errorf("missing ^lib:%s def with a version", lib)
+	}
+	return strings.Join(records, "\n---\n") + "\n", version, nil
+}
+
+func stripComments(text string) string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, "//") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,21 @@
+// Code generated by "go run libgen.go"; DO NOT EDIT.
+
+package defs
+
+// stdlibVersions maps the standard library names to their versions.
+var stdlibVersions = map[string]string{
+	"ph":          "3.9.10-subset",
+	"phScience":   "3.9.10-subset",
+	"phIoT":       "3.9.10-subset",
+	"phIct":       "3.9.10-subset",
+	"haystackExt": "1.0.0",
+}
+
+// stdlibSources maps the standard library names to their Trio source.
+var stdlibSources = map[string]string{
+	"ph":          "def: ^entity\nis: ^marker\ndoc: \"Top level dict with an id tag\"\n---\ndef: ^id\nis: ^ref\ntagOn: ^entity\ndoc: \"Unique identifier for an entity\"\n---\ndef: ^dis\nis: ^str\ntagOn: ^entity\ndoc: \"Display text for an entity\"\n---\ndef: ^disMacro\nis: ^str\ntagOn: ^entity\ndoc: \"Display text macro that substitutes tag values\"\n---\ndef: ^navName\nis: ^str\ntagOn: ^entity\ndoc: \"Name of an entity within the navigation tree\"\n---\ndef: ^mod\nis: ^dateTime\ntagOn: ^entity\ndoc: \"Timestamp of the last modification\"\n---\ndef: ^site\nis: ^entity\nmandatory\ndoc: \"Site is a geographic location of the built environment\"\n---\ndef: ^space\nis: ^entity\nmandatory\ncontainedBy: ^site\ndoc: \"Space is a three dimensional volume in the built environment\"\n---\ndef: ^floor\nis: ^space\ndoc: \"Floor of a building\"\n---\ndef: ^room\nis: ^space\ndoc: \"Enclosed space within a building\"\n---\ndef: ^zone\nis: ^space\ndoc: \"Space that is served by common equipment\"\n---\ndef: ^equip\nis: ^entity\nmandatory\ncontainedBy: [^site, ^space, ^equip]\ndoc: \"Equipment asset\"\n---\ndef: ^point\nis: ^entity\nmandatory\ncontainedBy: [^equip, ^space, ^site]\ndoc: \"Data point such as a sensor, command, or setpoint\"\n---\ndef: ^siteRef\nis: ^ref\nof: ^site\ntagOn: [^space, ^equip, ^point]\ndoc: \"Site which contains the entity\"\n---\ndef: ^spaceRef\nis: ^ref\nof: ^space\ntagOn: [^space, ^equip, ^point]\ndoc: \"Space which contains the entity\"\n---\ndef: ^equipRef\nis: ^ref\nof: ^equip\ntagOn: [^equip, ^point]\ndoc: \"Equipment which contains the entity\"\n---\ndef: ^area\nis: ^number\ntagOn: ^site\ndoc: \"Area of a site or space\"\n---\ndef: ^tz\nis: ^str\ntagOn: [^site, ^point]\ndoc: \"Timezone name of the entity\"\n---\ndef: ^geoAddr\nis: ^str\ntagOn: ^site\ndoc: \"Free form street address\"\n---\ndef: ^geoCity\nis: ^str\ntagOn: ^site\ndoc: \"Geographic city name\"\n---\ndef: ^geoState\nis: ^str\ntagOn: ^site\ndoc: \"State or province name\"\n---\ndef: ^geoCountry\nis: ^str\ntagOn: ^site\ndoc: \"Geographic country as ISO 3166-1 two letter code\"\n---\ndef: ^geoPostalCode\nis: ^str\ntagOn: ^site\ndoc: \"Postal code\"\n---\ndef: ^geoCoord\nis: ^coord\ntagOn: ^site\ndoc: \"Geographic coordinate of the entity\"\n---\ndef: ^primaryFunction\nis: ^str\ntagOn: ^site\ndoc: \"Primary function of the building\"\n---\ndef: ^yearBuilt\nis: ^number\ntagOn: ^site\ndoc: \"Year the building was constructed\"\n---\ndef: ^kind\nis: ^str\ntagOn: ^point\ndoc: \"Kind of the point's current and historized values\"\n---\ndef: ^unit\nis: ^str\ntagOn: ^point\ndoc: \"Unit symbol of the point's values\"\n---\ndef: ^enum\nis: ^str\ntagOn: ^point\ndoc: \"Range of the point's enumerated values\"\n---\ndef: ^minVal\nis: ^number\ntagOn: ^point\ndoc: \"Minimum value of a numeric point\"\n---\ndef: ^maxVal\nis: ^number\ntagOn: ^point\ndoc: \"Maximum value of a numeric point\"\n---\ndef: ^cur\nis: ^marker\ntagOn: ^point\ndoc: \"Point which supports a current value\"\n---\ndef: ^curVal\nis: ^val\ntagOn: ^point\ndoc: \"Current value of a point\"\n---\ndef: ^curStatus\nis: ^str\ntagOn: ^point\ndoc: \"Status of the current value of a point\"\n---\ndef: ^curErr\nis: ^str\ntagOn: ^point\ndoc: \"Error description when the current status is fault\"\n---\ndef: ^his\nis: ^marker\ntagOn: ^point\ndoc: \"Point which is historized\"\n---\ndef: ^hisInterval\nis: ^number\ntagOn: ^point\ndoc: \"Sample interval of a historized point\"\n---\ndef: ^hisMode\nis: ^str\ntagOn: ^point\ndoc: \"Whether historized data is sampled or consumption\"\n---\ndef: ^hisTotalized\nis: ^marker\ntagOn: ^point\ndoc: \"Point whose historized values are a running total\"\n---\ndef: ^writable\nis: ^marker\ntagOn: ^point\ndoc: \"Point which supports writes through a priority array\"\n---\ndef: ^writeVal\nis: ^val\ntagOn: ^point\ndoc: \"Current effective value of a writable point\"\n---\ndef: ^writeLevel\nis: ^number\ntagOn: ^point\ndoc: \"Priority level of the current effective write\"\n---\ndef: ^writeStatus\nis: ^str\ntagOn: ^point\ndoc: \"Status of the last write to a point\"\n---\ndef: ^writeErr\nis: ^str\ntagOn: ^point\ndoc: \"Error description when the write status is fault\"\n---\ndef: ^weatherStation\nis: ^entity\nmandatory\ndoc: \"Source of weather data\"\n---\ndef: ^weatherStationRef\nis: ^ref\nof: ^weatherStation\ntagOn: [^site, ^point]\ndoc: \"Weather station used by the entity\"\n---\ndef: ^val\ndoc: \"Root type for all values\"\n---\ndef: ^scalar\nis: ^val\ndoc: \"Atomic value kind\"\n---\ndef: ^collection\nis: ^val\ndoc: \"Collection value kind\"\n---\ndef: ^marker\nis: ^scalar\ndoc: \"Label on a dict which indicates an \\\"is-a\\\" relationship\"\n---\ndef: ^na\nis: ^scalar\ndoc: \"Not available singleton for missing data\"\n---\ndef: ^remove\nis: ^scalar\ndoc: \"Remove value used to indicate a tag removal in a diff\"\n---\ndef: ^bool\nis: ^scalar\ndoc: \"Boolean value of true or false\"\n---\ndef: ^number\nis: ^scalar\ndoc: \"Floating point number with optional unit\"\n---\ndef: ^str\nis: ^scalar\ndoc: \"String of Unicode characters\"\n---\ndef: ^uri\nis: ^scalar\ndoc: \"Universal resource identifier\"\n---\ndef: ^ref\nis: ^scalar\ndoc: \"Reference to an entity\"\n---\ndef: ^symbol\nis: ^scalar\ndoc: \"Name of a def\"\n---\ndef: ^date\nis: ^scalar\ndoc: \"ISO 8601 calendar date\"\n---\ndef: ^time\nis: ^scalar\ndoc: \"ISO 8601 time of day\"\n---\ndef: ^dateTime\nis: ^scalar\ndoc: \"ISO 8601 timestamp with timezone\"\n---\ndef: ^coord\nis: ^scalar\ndoc: \"Geographic coordinate as latitude and longitude in decimal degrees\"\n---\ndef: ^xstr\nis: ^scalar\ndoc: \"Extended typed string\"\n---\ndef: ^dict\nis: ^collection\ndoc: \"Dictionary of name/value pairs\"\n---\ndef: ^list\nis: ^collection\ndoc: \"Ordered sequence of values\"\n---\ndef: ^grid\nis: ^collection\ndoc: \"Two dimensional table of columns and rows\"\n---\ndef: ^lib:ph\nis: ^lib\nversion: \"3.9.10-subset\"\nbaseUri: `https://project-haystack.org/def/ph/`\ndoc: \"Project Haystack core library\"\n---\ndef: ^def\nis: ^symbol\ndoc: \"Defines a new symbol in the namespace\"\n---\ndef: ^is\nis: ^symbol\ntagOn: ^def\ndoc: \"Declares the supertypes of a def\"\n---\ndef: ^lib\nis: ^feature\ndoc: \"Library of defs\"\n---\ndef: ^depends\nis: ^list\ntagOn: ^lib\ndoc: \"Libraries that a library depends on\"\n---\ndef: ^version\nis: ^str\ntagOn: ^lib\ndoc: \"Version of a library\"\n---\ndef: ^baseUri\nis: ^uri\ntagOn: ^lib\ndoc: \"Base URI for the library's defs\"\n---\ndef: ^doc\nis: ^str\ntagOn: ^def\ndoc: \"Documentation for a def\"\n---\ndef: ^tagOn\nis: ^list\ntagOn: ^def\ndoc: \"Declares the entity types a tag applies to\"\n---\ndef: ^of\nis: ^symbol\ntagOn: ^def\ndoc: \"Declares the def that the value of a ref or choice tag implements\"\n---\ndef: ^containedBy\nis: ^symbol\ntagOn: ^def\ndoc: \"Declares the entity types that contain an entity type\"\n---\ndef: ^tags\nis: ^list\ntagOn: ^def\ndoc: \"Tags that are expected on an entity type\"\n---\ndef: ^children\nis: ^list\ntagOn: ^def\ndoc: \"Prototype children of an entity type\"\n---\ndef: ^mandatory\nis: ^marker\ntagOn: ^def\ndoc: \"Marks a def as required to implement its subtypes\"\n---\ndef: ^choice\nis: ^marker\ndoc: \"Exclusive choice between the subtypes of its 'of' def\"\n---\ndef: ^feature\nis: ^def\ndoc: \"Namespace of defs with a feature key prefix\"\n---\ndef: ^filetype\nis: ^feature\ndoc: \"File format for encoding data\"\n---\ndef: ^filetype:zinc\nis: ^filetype\ndoc: \"Zinc text format\"\n---\ndef: ^filetype:json\nis: ^filetype\ndoc: \"JSON format\"\n---\ndef: ^filetype:trio\nis: ^filetype\ndoc: \"Trio text format\"\n---\ndef: ^filetype:csv\nis: ^filetype\ndoc: \"Comma separated values\"\n---\ndef: ^op\nis: ^feature\ndoc: \"Operation of the HTTP API\"\n---\ndef: ^op:about\nis: ^op\ndoc: \"Query basic information about the server\"\n---\ndef: ^op:close\nis: ^op\ndoc: \"Close the current session\"\n---\ndef: ^op:defs\nis: ^op\ndoc: \"Query the def namespace\"\n---\ndef: ^op:libs\nis: ^op\ndoc: \"Query the libraries of the def namespace\"\n---\ndef: ^op:ops\nis: ^op\ndoc: \"Query the operations supported by the server\"\n---\ndef: ^op:filetypes\nis: ^op\ndoc: \"Query the file types supported by the server\"\n---\ndef: ^op:read\nis: ^op\ndoc: \"Read entity records\"\n---\ndef: ^op:nav\nis: ^op\ndoc: \"Navigate the entity tree\"\n---\ndef: ^op:watchSub\nis: ^op\ndoc: \"Subscribe to entities\"\n---\ndef: ^op:watchUnsub\nis: ^op\ndoc: \"Unsubscribe from entities\"\n---\ndef: ^op:watchPoll\nis: ^op\ndoc: \"Poll a watch for changes\"\n---\ndef: ^op:pointWrite\nis: ^op\ndoc: \"Write to a point's priority array\"\n---\ndef: ^op:hisRead\nis: ^op\ndoc: \"Read time series data\"\n---\ndef: ^op:hisWrite\nis: ^op\ndoc: \"Write time series data\"\n---\ndef: ^op:invokeAction\nis: ^op\ndoc: \"Invoke an action on an entity\"\n",
+	"phScience":   "def: ^lib:phScience\nis: ^lib\nversion: \"3.9.10-subset\"\nbaseUri: `https://project-haystack.org/def/phScience/`\ndepends: [^lib:ph]\ndoc: \"Project Haystack definitions for basic science\"\n---\ndef: ^phenomenon\nis: ^marker\ndoc: \"Observable occurrence\"\n---\ndef: ^quantity\nis: ^phenomenon\ndoc: \"Measurable property of a phenomenon\"\n---\ndef: ^temp\nis: ^quantity\ndoc: \"Temperature\"\n---\ndef: ^humidity\nis: ^quantity\ndoc: \"Relative humidity\"\n---\ndef: ^dewPoint\nis: ^quantity\ndoc: \"Dew point temperature\"\n---\ndef: ^enthalpy\nis: ^quantity\ndoc: \"Total heat content\"\n---\ndef: ^pressure\nis: ^quantity\ndoc: \"Force per unit area\"\n---\ndef: ^flow\nis: ^quantity\ndoc: \"Volumetric flow rate\"\n---\ndef: ^speed\nis: ^quantity\ndoc: \"Rate of motion\"\n---\ndef: ^power\nis: ^quantity\ndoc: \"Rate of energy transfer\"\n---\ndef: ^energy\nis: ^quantity\ndoc: \"Quantity of work or heat\"\n---\ndef: ^volume\nis: ^quantity\ndoc: \"Three dimensional quantity of space\"\n---\ndef: ^current\nis: ^quantity\ndoc: \"Electrical current\"\n---\ndef: ^voltage\nis: ^quantity\ndoc: \"Electrical potential difference\"\n---\ndef: ^frequency\nis: ^quantity\ndoc: \"Rate of occurrence per unit of time\"\n---\ndef: ^co2\nis: ^quantity\ndoc: \"Carbon dioxide concentration\"\n---\ndef: ^co\nis: ^quantity\ndoc: \"Carbon monoxide concentration\"\n---\ndef: ^illuminance\nis: ^quantity\ndoc: \"Luminous flux per unit area\"\n---\ndef: ^occupancy\nis: ^quantity\ndoc: \"Number of occupants\"\n---\ndef: ^quality\nis: ^marker\ndoc: \"Property that describes the state of a quantity\"\n---\ndef: ^delta\nis: ^quality\ndoc: \"Difference between two measurements\"\n---\ndef: ^unocc\nis: ^marker\ndoc: \"Associated with the unoccupied mode\"\n---\ndef: ^occ\nis: ^marker\ndoc: \"Associated with the occupied mode\"\n---\ndef: ^substance\nis: ^marker\ndoc: \"Matter in a specific form\"\n---\ndef: ^fluid\nis: ^substance\ndoc: \"Liquid or gas substance\"\n---\ndef: ^liquid\nis: ^fluid\ndoc: \"Fluid with a definite volume\"\n---\ndef: ^gas\nis: ^fluid\ndoc: \"Fluid with no definite shape or volume\"\n---\ndef: ^air\nis: ^gas\ndoc: \"Mixture of gases that make up the atmosphere\"\n---\ndef: ^water\nis: ^liquid\ndoc: \"Water substance\"\n---\ndef: ^steam\nis: ^gas\ndoc: \"Water in the gas phase\"\n---\ndef: ^refrig\nis: ^fluid\ndoc: \"Refrigerant used in a vapor compression cycle\"\n---\ndef: ^naturalGas\nis: ^gas\ndoc: \"Fossil fuel gas composed primarily of methane\"\n---\ndef: ^fuelOil\nis: ^liquid\ndoc: \"Petroleum based liquid fuel\"\n---\ndef: ^elec\nis: ^substance\ndoc: \"Electricity\"\n---\ndef: ^hot\nis: ^marker\ndoc: \"Hot temperature of a substance\"\n---\ndef: ^cool\nis: ^marker\ndoc: \"Cool temperature of a substance\"\n---\ndef: ^chilled\nis: ^marker\ndoc: \"Chilled temperature of a substance\"\n---\ndef: ^condenser\nis: ^marker\ndoc: \"Associated with the condenser side of a refrigeration cycle\"\n---\ndef: ^domestic\nis: ^marker\ndoc: \"Associated with potable water for human use\"\n---\ndef: ^hot-water\nis: ^water\ndoc: \"Hot water used for heating or domestic use\"\n---\ndef: ^chilled-water\nis: ^water\ndoc: \"Chilled water used for cooling\"\n---\ndef: ^condenser-water\nis: ^water\ndoc: \"Water used to reject heat from a condenser\"\n---\ndef: ^domestic-water\nis: ^water\ndoc: \"Potable water for human use\"\n---\ndef: ^outside\nis: ^marker\ndoc: \"Associated with the outside environment\"\n---\ndef: ^outside-air\nis: ^air\ndoc: \"Air from the outside environment\"\n",
+	"phIoT":       "def: ^airHandlingEquip\nis: ^equip\ndoc: \"Equipment that conditions and delivers air\"\n---\ndef: ^ahu\nis: ^airHandlingEquip\ndoc: \"Air handling unit\"\nchildren: Zinc:\n  [{discharge air temp sensor point}, {discharge air temp sp point}, {return air temp sensor point}, {outside air temp sensor point}, {discharge fan run cmd point}]\n---\ndef: ^rtu\nis: ^ahu\ndoc: \"Roof top unit\"\n---\ndef: ^mau\nis: ^ahu\ndoc: \"Makeup air unit\"\n---\ndef: ^doas\nis: ^ahu\ndoc: \"Dedicated outdoor air system\"\n---\ndef: ^fcu\nis: ^airHandlingEquip\ndoc: \"Fan coil unit\"\n---\ndef: ^airTerminalUnit\nis: ^equip\ndoc: \"Equipment at the end of an air distribution system\"\n---\ndef: ^vav\nis: ^airTerminalUnit\ndoc: \"Variable air volume terminal unit\"\nchildren: Zinc:\n  [{discharge air temp sensor point}, {discharge air flow sensor point}, {zone air temp sensor point}, {zone air temp sp point}, {damper cmd point}]\n---\ndef: ^cav\nis: ^airTerminalUnit\ndoc: \"Constant air volume terminal unit\"\n---\ndef: ^plant\nis: ^equip\ndoc: \"Central plant that produces a fluid\"\n---\ndef: ^chilledWaterPlant\nis: ^plant\ndoc: \"Central plant that produces chilled water\"\n---\ndef: ^hotWaterPlant\nis: ^plant\ndoc: \"Central plant that produces hot water\"\n---\ndef: ^steamPlant\nis: ^plant\ndoc: \"Central plant that produces steam\"\n---\ndef: ^chiller\nis: ^equip\ndoc: \"Equipment that removes heat from a liquid\"\nchildren: Zinc:\n  [{leaving chilled water temp sensor point}, {entering chilled water temp sensor point}, {run cmd point}]\n---\ndef: ^boiler\nis: ^equip\ndoc: \"Equipment that heats water or produces steam\"\n---\ndef: ^coolingTower\nis: ^equip\ndoc: \"Equipment that rejects heat to the atmosphere\"\n---\ndef: ^heatExchanger\nis: ^equip\ndoc: \"Equipment that transfers heat between two fluids\"\n---\ndef: ^motor\nis: ^equip\ndoc: \"Equipment that converts electrical energy into mechanical energy\"\n---\ndef: ^fan\nis: ^motor\ndoc: \"Motor that moves air\"\n---\ndef: ^pump\nis: ^motor\ndoc: \"Motor that moves a liquid\"\n---\ndef: ^actuator\nis: ^equip\ndoc: \"Equipment that controls a mechanism\"\n---\ndef: ^damper\nis: ^actuator\ndoc: \"Actuator that regulates the flow of air\"\n---\ndef: ^valve\nis: ^actuator\ndoc: \"Actuator that regulates the flow of a fluid\"\n---\ndef: ^meter\nis: ^equip\ndoc: \"Equipment that meters a substance\"\n---\ndef: ^elec-meter\nis: ^meter\ndoc: \"Meter for electricity\"\n---\ndef: ^water-meter\nis: ^meter\ndoc: \"Meter for water\"\n---\ndef: ^naturalGas-meter\nis: ^meter\ndoc: \"Meter for natural gas\"\n---\ndef: ^tank\nis: ^equip\ndoc: \"Equipment that stores a fluid\"\n---\ndef: ^vfd\nis: ^equip\ndoc: \"Variable frequency drive\"\n---\ndef: ^elecPanel\nis: ^equip\ndoc: \"Electrical panel that distributes power\"\n---\ndef: ^luminaire\nis: ^equip\ndoc: \"Lighting fixture\"\n---\ndef: ^thermostat\nis: ^equip\ndoc: \"Equipment that controls the temperature of a space\"\n---\ndef: ^lib:phIoT\nis: ^lib\nversion: \"3.9.10-subset\"\nbaseUri: `https://project-haystack.org/def/phIoT/`\ndepends: [^lib:ph, ^lib:phScience]\ndoc: \"Project Haystack definitions for Internet of Things\"\n---\ndef: ^sensor\nis: ^point\ndoc: \"Point which is an input measurement\"\n---\ndef: ^cmd\nis: ^point\ndoc: \"Point which is an output command\"\n---\ndef: ^sp\nis: ^point\ndoc: \"Point which is a setpoint\"\n---\ndef: ^run\nis: ^marker\ndoc: \"Associated with the running state of equipment\"\n---\ndef: ^enable\nis: ^marker\ndoc: \"Associated with enabling equipment\"\n---\ndef: ^alarm\nis: ^marker\ndoc: \"Associated with an alarm condition\"\n---\ndef: ^effective\nis: ^marker\ndoc: \"Current effective value of a setpoint\"\n---\ndef: ^heating\nis: ^marker\ndoc: \"Associated with heating\"\n---\ndef: ^cooling\nis: ^marker\ndoc: \"Associated with cooling\"\n---\ndef: ^economizing\nis: ^marker\ndoc: \"Associated with economizer operation\"\n---\ndef: ^airSection\nis: ^marker\ndoc: \"Section of an air handling system\"\n---\ndef: ^discharge\nis: ^airSection\ndoc: \"Air leaving equipment\"\n---\ndef: ^return\nis: ^airSection\ndoc: \"Air returning from a space\"\n---\ndef: ^mixed\nis: ^airSection\ndoc: \"Mixture of return and outside air\"\n---\ndef: ^exhaust\nis: ^airSection\ndoc: \"Air exhausted to the outside\"\n---\ndef: ^inlet\nis: ^airSection\ndoc: \"Air entering equipment\"\n---\ndef: ^ductSection\nis: ^choice\nof: ^airSection\ntagOn: ^point\ndoc: \"Section of the air duct a point is located in\"\n---\ndef: ^waterSection\nis: ^marker\ndoc: \"Side of equipment that a water point is located on\"\n---\ndef: ^entering\nis: ^waterSection\ndoc: \"Fluid entering equipment\"\n---\ndef: ^leaving\nis: ^waterSection\ndoc: \"Fluid leaving equipment\"\n---\ndef: ^pipeSection\nis: ^choice\nof: ^waterSection\ntagOn: ^point\ndoc: \"Section of the pipe a point is located in\"\n---\ndef: ^zone-air\nis: ^air\ndoc: \"Air in a zone\"\n---\ndef: ^discharge-air\nis: ^air\ndoc: \"Air leaving air handling equipment\"\n---\ndef: ^return-air\nis: ^air\ndoc: \"Air returning to air handling equipment\"\n---\ndef: ^mixed-air\nis: ^air\ndoc: \"Mixture of return and outside air\"\n---\ndef: ^exhaust-air\nis: ^air\ndoc: \"Air exhausted to the outside\"\n---\ndef: ^airRef\nis: ^ref\nof: ^airHandlingEquip\ntagOn: [^equip, ^space]\ndoc: \"Air handling equipment that supplies air to the entity\"\n---\ndef: ^chilledWaterRef\nis: ^ref\nof: ^chilledWaterPlant\ntagOn: ^equip\ndoc: \"Plant that supplies chilled water to the equipment\"\n---\ndef: ^hotWaterRef\nis: ^ref\nof: ^hotWaterPlant\ntagOn: ^equip\ndoc: \"Plant that supplies hot water to the equipment\"\n---\ndef: ^elecRef\nis: ^ref\nof: ^elec-meter\ntagOn: [^equip, ^space]\ndoc: \"Meter that supplies electricity to the entity\"\n---\ndef: ^submeterOf\nis: ^ref\nof: ^meter\ntagOn: ^meter\ndoc: \"Parent meter of a submeter\"\n---\ndef: ^siteMeter\nis: ^marker\ntagOn: ^meter\ndoc: \"Main meter for a site\"\n",
+	"phIct":       "def: ^device\nis: ^equip\ndoc: \"Microprocessor based hardware device\"\n---\ndef: ^controller\nis: ^device\ndoc: \"Device that controls equipment\"\n---\ndef: ^computer\nis: ^device\ndoc: \"General purpose computing device\"\n---\ndef: ^server\nis: ^computer\ndoc: \"Computer that provides services to clients\"\n---\ndef: ^router\nis: ^device\ndoc: \"Device that forwards packets between networks\"\n---\ndef: ^networkSwitch\nis: ^device\ndoc: \"Device that connects devices on a network\"\n---\ndef: ^phone\nis: ^device\ndoc: \"Telephone device\"\n---\ndef: ^network\nis: ^entity\nmandatory\ndoc: \"Communications network\"\n---\ndef: ^networkRef\nis: ^ref\nof: ^network\ntagOn: ^device\ndoc: \"Network the device communicates on\"\n---\ndef: ^deviceRef\nis: ^ref\nof: ^device\ntagOn: ^point\ndoc: \"Device that hosts the point\"\n---\ndef: ^protocol\nis: ^marker\ndoc: \"Communication protocol\"\n---\ndef: ^bacnet\nis: ^protocol\ndoc: \"BACnet protocol\"\n---\ndef: ^modbus\nis: ^protocol\ndoc: \"Modbus protocol\"\n---\ndef: ^ip\nis: ^protocol\ndoc: \"Internet protocol\"\n---\ndef: ^ipAddr\nis: ^str\ntagOn: ^device\ndoc: \"IP address of a device\"\n---\ndef: ^macAddr\nis: ^str\ntagOn: ^device\ndoc: \"MAC address of a device\"\n---\ndef: ^firmwareVersion\nis: ^str\ntagOn: ^device\ndoc: \"Version of the device firmware\"\n---\ndef: ^hardwareVersion\nis: ^str\ntagOn: ^device\ndoc: \"Version of the device hardware\"\n---\ndef: ^serialNum\nis: ^str\ntagOn: ^device\ndoc: \"Serial number of the device\"\n---\ndef: ^lib:phIct\nis: ^lib\nversion: \"3.9.10-subset\"\nbaseUri: `https://project-haystack.org/def/phIct/`\ndepends: [^lib:ph, ^lib:phIoT]\ndoc: \"Project Haystack definitions for information and communication technology\"\n",
+	"haystackExt": "def: ^ahuZoneDelivery\nis: ^choice\ntagOn: ^ahu\ndoc: \"How an air handling unit delivers air to zones\"\n---\ndef: ^directZone\nis: ^ahuZoneDelivery\ndoc: \"Air handling unit that supplies air directly to a zone\"\n---\ndef: ^vavZone\nis: ^ahuZoneDelivery\ndoc: \"Air handling unit that supplies air to variable air volume terminal units\"\n---\ndef: ^lib:haystackExt\nis: ^lib\nversion: \"1.0.0\"\ndepends: [^lib:ph, ^lib:phIoT]\ndoc: \"Extensions to the Project Haystack definitions\"\n",
+}
@@ -0,0 +1,61 @@
+package haystack
+
+import "strings"
+
+// MissingTagError occurs when a required tag is not present, or is null.
+type MissingTagError struct {
+	Name string
+}
+
+// NewMissingTagError creates a new MissingTagError object.
+func NewMissingTagError(name string) MissingTagError {
+	return MissingTagError{Name: name}
+}
+
+func (err MissingTagError) Error() string {
+	return "Missing tag: " + err.Name
+}
+
+// TagTypeError occurs when a tag is present, but its value is not of the expected kind.
+type TagTypeError struct {
+	Name     string
+	Expected string
+	Actual   string
+}
+
+// NewTagTypeError creates a new TagTypeError object. The actual kind is taken from the input Val.
+func NewTagTypeError(name string, expected string, actual Val) TagTypeError {
+	return TagTypeError{Name: name, Expected: expected, Actual: kindName(actual)}
+}
+
+func (err TagTypeError) Error() string {
+	return "Tag type error: " + err.Name + " is " + err.Actual + ", not " + err.Expected
+}
+
+// MergeConflictError occurs when a Dict merge finds tags with conflicting values.
+type MergeConflictError struct {
+	Names []string
+}
+
+// NewMergeConflictError creates a new MergeConflictError object.
+func NewMergeConflictError(names []string) MergeConflictError {
+	return MergeConflictError{Names: names}
+}
+
+func (err MergeConflictError) Error() string {
+	return "Merge conflict: " + strings.Join(err.Names, ", ")
+}
+
+// UnknownTzError occurs when a timezone name has no Haystack equivalent.
+type UnknownTzError struct {
+	Name string
+}
+
+// NewUnknownTzError creates a new UnknownTzError object.
+func NewUnknownTzError(name string) UnknownTzError {
+	return UnknownTzError{Name: name}
+}
+
+func (err UnknownTzError) Error() string {
+	return "Unknown timezone: " + err.Name
+}
@@ -0,0 +1,366 @@
+package filter
+
+import (
+	"strings"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Filter is a Haystack filter that can be evaluated against entities locally. Use Parse to create one from a filter
+// string, like `ahu and siteRef->dis == "HQ"`.
+type Filter interface {
+	// Include returns true if the entity matches the filter
+	Include(dict haystack.Dict, ctx Context) bool
+	// String returns the filter in Haystack filter syntax
+	String() string
+}
+
+// Reasoner matches entities against defs. It is implemented by *defs.Namespace.
+type Reasoner interface {
+	// Fits returns true if the entity implements the def, directly or through a subtype
+	Fits(dict haystack.Dict, name string) bool
+}
+
+// Context provides what a Filter needs to evaluate an entity, beyond the entity itself. The zero Context is valid.
+type Context struct {
+	// Resolver resolves the Refs in paths like `equipRef->siteRef`. If nil, paths that dereference a Ref don't match.
+	Resolver haystack.RefResolver
+	// Namespace matches symbol terms like `^ahu` by def reflection, so that a `doas` entity matches `^ahu` and a
+	// `hot water` entity matches `^hot-water`. If nil, a symbol term matches entities that have all of the tags in
+	// the symbol name.
+	Namespace Reasoner
+}
+
+// Select returns the entities that match the filter, in order
+func Select(filter Filter, dicts []haystack.Dict, ctx Context) []haystack.Dict {
+	result := []haystack.Dict{}
+	for _, dict := range dicts {
+		if filter.Include(dict, ctx) {
+			result = append(result, dict)
+		}
+	}
+	return result
+}
+
+// Path is a sequence of tag names separated by '->', where each name but the last is a Ref to dereference.
+type Path []string
+
+// Get returns the value at the path, or Null if any part of the path is missing or can't be resolved.
+func (path Path) Get(dict haystack.Dict, ctx Context) haystack.Val {
+	var val haystack.Val = dict
+	for i, name := range path {
+		if i > 0 {
+			ref, ok := val.(haystack.Ref)
+			if !ok || ctx.Resolver == nil {
+				return haystack.NewNull()
+			}
+			resolved, err := ctx.Resolver.Resolve(ref)
+			if err != nil {
+				return haystack.NewNull()
+			}
+			dict = resolved
+		}
+		val = dict.Get(name)
+	}
+	return val
+}
+
+// String returns the path joined by '->'
+func (path Path) String() string {
+	return strings.Join(path, "->")
+}
+
+type has struct {
+	path Path
+}
+
+// Has returns a filter that matches entities with a value at the path
+func Has(path Path) Filter {
+	return has{path: path}
+}
+
+func (filter has) Include(dict haystack.Dict, ctx Context) bool {
+	return !isNull(filter.path.Get(dict, ctx))
+}
+
+func (filter has) String() string {
+	return filter.path.String()
+}
+
+type missing struct {
+	path Path
+}
+
+// Missing returns a filter that matches entities without a value at the path
+func Missing(path Path) Filter {
+	return missing{path: path}
+}
+
+func (filter missing) Include(dict haystack.Dict, ctx Context) bool {
+	return isNull(filter.path.Get(dict, ctx))
+}
+
+func (filter missing) String() string {
+	return "not " + filter.path.String()
+}
+
+// Comparison operators
+const (
+	OpEq    = "=="
+	OpNotEq = "!="
+	OpLt    = "<"
+	OpLtEq  = "<="
+	OpGt    = ">"
+	OpGtEq  = ">="
+)
+
+type cmp struct {
+	path Path
+	op   string
+	val  haystack.Val
+}
+
+// Cmp returns a filter that compares the value at the path to val using the operator, like OpEq. Entities without
+// a value at the path never match. The ordering operators only match values of the same type, and Numbers of the
+// same unit.
+func Cmp(path Path, op string, val haystack.Val) Filter {
+	return cmp{path: path, op: op, val: val}
+}
+
+func (filter cmp) Include(dict haystack.Dict, ctx Context) bool {
+	val := filter.path.Get(dict, ctx)
+	if isNull(val) {
+		return false
+	}
+	switch filter.op {
+	case OpEq:
+		return haystack.ValEquals(val, filter.val)
+	case OpNotEq:
+		return !haystack.ValEquals(val, filter.val)
+	}
+	order, ok := compare(val, filter.val)
+	if !ok {
+		return false
+	}
+	switch filter.op {
+	case OpLt:
+		return order < 0
+	case OpLtEq:
+		return order <= 0
+	case OpGt:
+		return order > 0
+	case OpGtEq:
+		return order >= 0
+	default:
+		return false
+	}
+}
+
+func (filter cmp) String() string {
+	return filter.path.String() + " " + filter.op + " " + valString(filter.val)
+}
+
+// valString renders a value the way the filter grammar parses it: Bools are keywords, and Refs have no dis
+func valString(val haystack.Val) string {
+	switch val := val.(type) {
+	case haystack.Bool:
+		if val.ToBool() {
+			return "true"
+		}
+		return "false"
+	case haystack.Ref:
+		return haystack.NewRef(val.Id(), "").ToZinc()
+	default:
+		return val.ToZinc()
+	}
+}
+
+type symbol struct {
+	name string
+}
+
+// Symbol returns a filter that matches entities that implement the def, like "ahu" or "elec-meter". See
+// Context.Namespace.
+func Symbol(name string) Filter {
+	return symbol{name: name}
+}
+
+func (filter symbol) Include(dict haystack.Dict, ctx Context) bool {
+	if ctx.Namespace != nil {
+		return ctx.Namespace.Fits(dict, filter.name)
+	}
+	for _, part := range strings.Split(filter.name, "-") {
+		if dict.Missing(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func (filter symbol) String() string {
+	return "^" + filter.name
+}
+
+type and struct {
+	a Filter
+	b Filter
+}
+
+// And returns a filter that matches entities that match both filters
+func And(a Filter, b Filter) Filter {
+	return and{a: a, b: b}
+}
+
+func (filter and) Include(dict haystack.Dict, ctx Context) bool {
+	return filter.a.Include(dict, ctx) && filter.b.Include(dict, ctx)
+}
+
+func (filter and) String() string {
+	return wrap(filter.a) + " and " + wrap(filter.b)
+}
+
+type or struct {
+	a Filter
+	b Filter
+}
+
+// Or returns a filter that matches entities that match either filter
+func Or(a Filter, b Filter) Filter {
+	return or{a: a, b: b}
+}
+
+func (filter or) Include(dict haystack.Dict, ctx Context) bool {
+	return filter.a.Include(dict, ctx) || filter.b.Include(dict, ctx)
+}
+
+func (filter or) String() string {
+	return wrap(filter.a) + " or " + wrap(filter.b)
+}
+
+// wrap parenthesizes compound filters so that String output parses back to the same filter
+func wrap(filter Filter) string {
+	switch filter.(type) {
+	case and, or:
+		return "(" + filter.String() + ")"
+	default:
+		return filter.String()
+	}
+}
+
+func isNull(val haystack.Val) bool {
+	if val == nil {
+		return true
+	}
+	_, ok := val.(haystack.Null)
+	return ok
+}
+
+// compare returns the order of a relative to b, and false if the values can't be ordered
+func compare(a haystack.Val, b haystack.Val) (int, bool) {
+	switch a := a.(type) {
+	case haystack.Number:
+		bNumber, ok := b.(haystack.Number)
+		if !ok || a.Unit() != bNumber.Unit() {
+			return 0, false
+		}
+		return compareFloats(a.Float(), bNumber.Float()), true
+	case haystack.Str:
+		bStr, ok := b.(haystack.Str)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a.String(), bStr.String()), true
+	case haystack.Uri:
+		bUri, ok := b.(haystack.Uri)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a.String(), bUri.String()), true
+	case haystack.Ref:
+		bRef, ok := b.(haystack.Ref)
+		if !ok {
+			return 0, false
+		}
+		return strings.Compare(a.Id(), bRef.Id()), true
+	case haystack.Date:
+		bDate, ok := b.(haystack.Date)
+		if !ok {
+			return 0, false
+		}
+		return order(a.Before(bDate), a.After(bDate)), true
+	case haystack.Time:
+		bTime, ok := b.(haystack.Time)
+		if !ok {
+			return 0, false
+		}
+		return order(a.Before(bTime), a.After(bTime)), true
+	case haystack.DateTime:
+		bDateTime, ok := b.(haystack.DateTime)
+		if !ok {
+			return 0, false
+		}
+		return order(a.Before(bDateTime), a.After(bDateTime)), true
+	default:
+		return 0, false
+	}
+}
+
+func compareFloats(a float64, b float64) int {
+	return order(a < b, a > b)
+}
+
+func order(before bool, after bool) int {
+	if before {
+		return -1
+	} else if after {
+		return 1
+	}
+	return 0
+}
+
+// Required returns the conditions that every entity matching the filter must meet, so that stores can use indexes
+// instead of scanning every entity: the names of tags that must be present, and the Refs that tags must equal.
+// Only terms on the entity's own tags that are required through `and` are returned; `or`, `not`, symbols and paths
+// through Refs are not, so the filter must still be applied to the candidates.
+func Required(filter Filter) (tags []string, refs map[string]haystack.Ref) {
+	tags = []string{}
+	refs = map[string]haystack.Ref{}
+	collectRequired(filter, &tags, refs)
+	return tags, refs
+}
+
+func collectRequired(filter Filter, tags *[]string, refs map[string]haystack.Ref) {
+	switch filter := filter.(type) {
+	case has:
+		if len(filter.path) == 1 {
+			*tags = append(*tags, filter.path[0])
+		}
+	case cmp:
+		if len(filter.path) != 1 {
+			return
+		}
+		*tags = append(*tags, filter.path[0])
+		if ref, ok := filter.val.(haystack.Ref); ok && filter.op == OpEq {
+			refs[filter.path[0]] = ref
+		}
+	case and:
+		collectRequired(filter.a, tags, refs)
+		collectRequired(filter.b, tags, refs)
+	}
+}
+
+// MapRefs returns the filter with the Ref of every comparison replaced by the result of the function. See
+// haystack.MapRefs.
+func MapRefs(filter Filter, f func(ref haystack.Ref) haystack.Ref) Filter {
+	switch filter := filter.(type) {
+	case cmp:
+		if ref, ok := filter.val.(haystack.Ref); ok {
+			return Cmp(filter.path, filter.op, f(ref))
+		}
+	case and:
+		return And(MapRefs(filter.a, f), MapRefs(filter.b, f))
+	case or:
+		return Or(MapRefs(filter.a, f), MapRefs(filter.b, f))
+	}
+	return filter
+}
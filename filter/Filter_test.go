@@ -0,0 +1,202 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/defs"
+	"github.com/stretchr/testify/assert"
+)
+
+var testEntities = map[string]haystack.Dict{
+	"site": haystack.NewDict(map[string]haystack.Val{
+		"id":   haystack.NewRef("site", ""),
+		"site": haystack.NewMarker(),
+		"dis":  haystack.NewStr("HQ"),
+		"area": haystack.NewNumber(5000, "ft²"),
+	}),
+	"doas": haystack.NewDict(map[string]haystack.Val{
+		"id":      haystack.NewRef("doas", ""),
+		"doas":    haystack.NewMarker(),
+		"equip":   haystack.NewMarker(),
+		"siteRef": haystack.NewRef("site", ""),
+	}),
+	"meter": haystack.NewDict(map[string]haystack.Val{
+		"id":      haystack.NewRef("meter", ""),
+		"elec":    haystack.NewMarker(),
+		"meter":   haystack.NewMarker(),
+		"equip":   haystack.NewMarker(),
+		"siteRef": haystack.NewRef("site", ""),
+	}),
+	"temp": haystack.NewDict(map[string]haystack.Val{
+		"id":       haystack.NewRef("temp", ""),
+		"point":    haystack.NewMarker(),
+		"curVal":   haystack.NewNumber(72.5, "°F"),
+		"equipRef": haystack.NewRef("doas", ""),
+		"enabled":  haystack.NewBool(true),
+	}),
+}
+
+func testContext(t *testing.T) Context {
+	ns, err := defs.StandardNamespace()
+	assert.Nil(t, err)
+	return Context{
+		Resolver: haystack.RefResolverFunc(func(ref haystack.Ref) (haystack.Dict, error) {
+			if dict, ok := testEntities[ref.Id()]; ok {
+				return dict, nil
+			}
+			return haystack.EmptyDict(), errors.New("Unknown ref: " + ref.ToZinc())
+		}),
+		Namespace: ns,
+	}
+}
+
+func testMatches(t *testing.T, filterStr string, ctx Context, expected ...string) {
+	filter, err := Parse(filterStr)
+	assert.Nil(t, err, filterStr)
+	if err != nil {
+		return
+	}
+	actual := []string{}
+	for _, id := range []string{"site", "doas", "meter", "temp"} {
+		if filter.Include(testEntities[id], ctx) {
+			actual = append(actual, id)
+		}
+	}
+	if expected == nil {
+		expected = []string{}
+	}
+	assert.Equal(t, expected, actual, filterStr)
+}
+
+func TestFilter_Include(t *testing.T) {
+	ctx := testContext(t)
+	testMatches(t, "site", ctx, "site")
+	testMatches(t, "not equip", ctx, "site", "temp")
+	testMatches(t, "equip and siteRef", ctx, "doas", "meter")
+	testMatches(t, "site or point", ctx, "site", "temp")
+	testMatches(t, "dis == \"HQ\"", ctx, "site")
+	testMatches(t, "dis != \"HQ\"", ctx)
+	testMatches(t, "area > 1000ft²", ctx, "site")
+	testMatches(t, "area > 1000", ctx)
+	testMatches(t, "curVal <= 72.5°F", ctx, "temp")
+	testMatches(t, "enabled == true", ctx, "temp")
+	testMatches(t, "siteRef == @site", ctx, "doas", "meter")
+	testMatches(t, "equipRef->siteRef->dis == \"HQ\"", ctx, "temp")
+	testMatches(t, "equipRef->doas", ctx, "temp")
+	testMatches(t, "point and (equipRef->meter or enabled == false)", ctx)
+}
+
+func TestFilter_Include_symbols(t *testing.T) {
+	ctx := testContext(t)
+	testMatches(t, "^ahu", ctx, "doas")
+	testMatches(t, "^equip", ctx, "doas", "meter")
+	testMatches(t, "^elec-meter", ctx, "meter")
+	testMatches(t, "^meter", ctx, "meter")
+	testMatches(t, "^entity", ctx, "site", "doas", "meter", "temp")
+	testMatches(t, "^equip and not siteRef", ctx)
+
+	// Without a namespace, symbols match by their marker tags
+	testMatches(t, "^ahu", Context{})
+	testMatches(t, "^elec-meter", Context{}, "meter")
+}
+
+func TestFilter_Include_noResolver(t *testing.T) {
+	testMatches(t, "equipRef->doas", Context{})
+	testMatches(t, "not equipRef->doas", Context{}, "site", "doas", "meter", "temp")
+}
+
+func TestFilter_String(t *testing.T) {
+	for _, filterStr := range []string{
+		"site",
+		"not equip",
+		"equipRef->siteRef->dis == \"HQ\"",
+		"(site or equip) and ^ahu",
+		"site or (equip and curVal >= 1kW)",
+	} {
+		filter, err := Parse(filterStr)
+		assert.Nil(t, err)
+		assert.Equal(t, filterStr, filter.String())
+	}
+}
+
+func TestFilter_String_vals(t *testing.T) {
+	for _, filterStr := range []string{
+		"curVal == true",
+		"curVal != false",
+		"curVal > -1.5kW",
+		"dis == \"a \\\"b\\\"\"",
+		"siteRef == @p:demo:r:site",
+		"uri == `http://host/`",
+		"def == ^elec-meter",
+		"date >= 2023-01-31",
+		"time < 12:30:00",
+		"mod <= 2023-01-31T12:30:00-05:00 New_York",
+	} {
+		filter, err := Parse(filterStr)
+		assert.Nil(t, err)
+		assert.Equal(t, filterStr, filter.String())
+		reparsed, err := Parse(filter.String())
+		assert.Nil(t, err)
+		assert.Equal(t, filter, reparsed)
+	}
+
+	// Values created in code render in the parsed form
+	for _, filter := range []Filter{
+		Cmp(Path{"curVal"}, OpEq, haystack.NewBool(true)),
+		Cmp(Path{"siteRef"}, OpEq, haystack.NewRef("site", "Site")),
+	} {
+		reparsed, err := Parse(filter.String())
+		assert.Nil(t, err)
+		assert.Equal(t, filter.String(), reparsed.String())
+	}
+	assert.Equal(t, "siteRef == @site", Cmp(Path{"siteRef"}, OpEq, haystack.NewRef("site", "Site")).String())
+}
+
+func TestParse_errors(t *testing.T) {
+	for _, filterStr := range []string{
+		"",
+		"site and",
+		"(site",
+		"dis ==",
+		"dis == foo",
+		"site equip",
+		"not ^ahu",
+	} {
+		_, err := Parse(filterStr)
+		assert.NotNil(t, err, filterStr)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	filter, err := Parse("^ahu")
+	assert.Nil(t, err)
+	dicts := []haystack.Dict{testEntities["site"], testEntities["doas"], testEntities["meter"]}
+	assert.Equal(t, []haystack.Dict{testEntities["doas"]}, Select(filter, dicts, testContext(t)))
+}
+
+func TestRequired(t *testing.T) {
+	filter, err := Parse(`equip and siteRef == @site and area > 100 and (ahu or meter) and equipRef->siteRef == @x`)
+	assert.Nil(t, err)
+	tags, refs := Required(filter)
+	assert.Equal(t, []string{"equip", "siteRef", "area"}, tags)
+	assert.Equal(t, map[string]haystack.Ref{"siteRef": haystack.NewRef("site", "")}, refs)
+
+	filter, err = Parse(`not equip or site`)
+	assert.Nil(t, err)
+	tags, refs = Required(filter)
+	assert.Empty(t, tags)
+	assert.Empty(t, refs)
+}
+
+func TestMapRefs(t *testing.T) {
+	filter, err := Parse(`equip and (siteRef == @a or equipRef->siteRef == @b) and id != @c and area > 100`)
+	assert.Nil(t, err)
+	mapped := MapRefs(filter, func(ref haystack.Ref) haystack.Ref {
+		return ref.Absolutize("demo")
+	})
+	expected, err := Parse(`equip and (siteRef == @p:demo:r:a or equipRef->siteRef == @p:demo:r:b) and id != @p:demo:r:c and area > 100`)
+	assert.Nil(t, err)
+	assert.Equal(t, expected.String(), mapped.String())
+}
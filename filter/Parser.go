@@ -0,0 +1,208 @@
+package filter
+
+import (
+	"errors"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+// Parse parses a Haystack filter string, like `equip and siteRef->area > 1000`. In addition to tag paths,
+// comparisons, `not`, `and`, `or` and parentheses, Haystack 4 symbol terms like `^ahu` are supported.
+func Parse(str string) (Filter, error) {
+	parser := parser{}
+	parser.tokenizer.InitString(str)
+	err := parser.consume()
+	if err != nil {
+		return nil, err
+	}
+	filter, err := parser.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if parser.cur != io.EOF {
+		return nil, errors.New("Unexpected " + parser.cur.String() + " in filter: " + str)
+	}
+	return filter, nil
+}
+
+type parser struct {
+	tokenizer io.Tokenizer
+	cur       io.Token
+	curVal    haystack.Val
+}
+
+func (parser *parser) parseOr() (Filter, error) {
+	filter, err := parser.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isKeyword("or") {
+		err = parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		right, err := parser.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		filter = Or(filter, right)
+	}
+	return filter, nil
+}
+
+func (parser *parser) parseAnd() (Filter, error) {
+	filter, err := parser.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+	for parser.isKeyword("and") {
+		err = parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		right, err := parser.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		filter = And(filter, right)
+	}
+	return filter, nil
+}
+
+func (parser *parser) parseTerm() (Filter, error) {
+	switch {
+	case parser.cur == io.LPAREN:
+		err := parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		filter, err := parser.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		err = parser.consumeToken(io.RPAREN)
+		if err != nil {
+			return nil, err
+		}
+		return filter, nil
+	case parser.cur == io.SYMBOL:
+		name := parser.curVal.(haystack.Symbol).String()
+		err := parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		return Symbol(name), nil
+	case parser.isKeyword("not"):
+		err := parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		path, err := parser.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		return Missing(path), nil
+	}
+
+	path, err := parser.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	op := ""
+	switch parser.cur {
+	case io.EQ:
+		op = OpEq
+	case io.NOTEQ:
+		op = OpNotEq
+	case io.LT:
+		op = OpLt
+	case io.LTEQ:
+		op = OpLtEq
+	case io.GT:
+		op = OpGt
+	case io.GTEQ:
+		op = OpGtEq
+	default:
+		return Has(path), nil
+	}
+	err = parser.consume()
+	if err != nil {
+		return nil, err
+	}
+	val, err := parser.parseVal()
+	if err != nil {
+		return nil, err
+	}
+	return Cmp(path, op, val), nil
+}
+
+func (parser *parser) parsePath() (Path, error) {
+	path := Path{}
+	for {
+		if parser.cur != io.ID || parser.isKeyword("and") || parser.isKeyword("or") || parser.isKeyword("not") {
+			return nil, errors.New("Expected tag name not " + parser.cur.String())
+		}
+		path = append(path, parser.curVal.(haystack.Id).String())
+		err := parser.consume()
+		if err != nil {
+			return nil, err
+		}
+		if parser.cur != io.ARROW {
+			return path, nil
+		}
+		err = parser.consume()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (parser *parser) parseVal() (haystack.Val, error) {
+	var val haystack.Val
+	switch parser.cur {
+	case io.ID:
+		switch parser.curVal.(haystack.Id).String() {
+		case "true":
+			val = haystack.NewBool(true)
+		case "false":
+			val = haystack.NewBool(false)
+		default:
+			return nil, errors.New("Unexpected value: " + parser.curVal.ToZinc())
+		}
+	case io.NUMBER, io.STR, io.REF, io.URI, io.SYMBOL, io.DATE, io.TIME, io.DATETIME:
+		val = parser.curVal
+	default:
+		return nil, errors.New("Expected value not " + parser.cur.String())
+	}
+	err := parser.consume()
+	if err != nil {
+		return nil, err
+	}
+	return val, nil
+}
+
+func (parser *parser) isKeyword(keyword string) bool {
+	if parser.cur != io.ID {
+		return false
+	}
+	id, ok := parser.curVal.(haystack.Id)
+	return ok && id.String() == keyword
+}
+
+func (parser *parser) consumeToken(expected io.Token) error {
+	if parser.cur != expected {
+		return errors.New("Expected " + expected.String() + " not " + parser.cur.String())
+	}
+	return parser.consume()
+}
+
+func (parser *parser) consume() error {
+	token, err := parser.tokenizer.Next()
+	if err != nil {
+		return err
+	}
+	parser.cur = token
+	parser.curVal = parser.tokenizer.Val()
+	return nil
+}
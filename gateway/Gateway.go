@@ -0,0 +1,853 @@
+package gateway
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/client"
+	"github.com/NeedleInAJayStack/haystack/filter"
+	"github.com/NeedleInAJayStack/haystack/server"
+	"github.com/NeedleInAJayStack/haystack/watch"
+)
+
+// Upstream is a Haystack server behind a Gateway. It is implemented by client.Client. Server side errors should be
+// returned as a client.CallError, so that the upstream's err grid is passed on.
+type Upstream interface {
+	Call(op string, req haystack.Grid) (haystack.Grid, error)
+}
+
+type upstream struct {
+	name    string
+	project string
+	conn    Upstream
+}
+
+// Gateway is a server.Handler that presents many upstream servers as one. Each upstream has a name, and the Refs of
+// its records are made absolute in a project of that name, so @abc on the "east" upstream is @p:east:r:abc. Requests
+// are routed to the upstreams by the projects of their Refs:
+//
+//   - 'read' by filter is sent to every upstream with the same `limit`, and the results are merged in order of id and
+//     then truncated to the limit. Refs in the filter are mapped to their upstream. 'read' by ids is split between
+//     the owning upstreams.
+//   - 'nav' has a root for each upstream, whose navId is the upstream name. Str navIds of upstreams are prefixed
+//     by the upstream name and a colon.
+//   - 'hisRead', 'hisWrite', 'pointWrite' and 'invokeAction' are sent to the owning upstream.
+//   - 'watchSub', 'watchUnsub' and 'watchPoll' manage a watch on each upstream behind a single gateway watch. A
+//     watch isn't created if every upstream fails to subscribe. The gateway watch has the shortest lease of its
+//     upstream watches, and is renewed by subscribing and polling. Expired watches are closed by Sweep, and a watch
+//     that every upstream reports unknown is dropped.
+//
+// When an upstream is unavailable, requests sent to many upstreams return the results of the others, with the
+// `partial` marker and the names of the failed upstreams in an `unavailable` List in the grid meta.
+//
+// A Gateway is safe for concurrent use.
+type Gateway struct {
+	lock      sync.Mutex
+	upstreams map[string]*upstream
+	watches   map[string]*gatewayWatch
+	clock     watch.Clock
+	stop      chan struct{}
+}
+
+// gatewayWatch is a gateway watch, made of a watch on each upstream with subscribed records
+type gatewayWatch struct {
+	dis     string
+	ids     map[string]string // upstream name -> upstream watch id
+	lease   time.Duration
+	expires time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewGateway creates a Gateway without upstreams.
+func NewGateway() *Gateway {
+	return &Gateway{upstreams: map[string]*upstream{}, watches: map[string]*gatewayWatch{}, clock: realClock{}}
+}
+
+// SetClock sets the clock used for watch leases. The default is the system clock.
+func (gateway *Gateway) SetClock(clock watch.Clock) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	gateway.clock = clock
+}
+
+// Add adds an upstream, replacing any upstream with the name. The name must be valid in a Ref id. If the upstream
+// uses absolute Refs, like SkySpark, project is its project name, so that its Refs are relativized before they are
+// made absolute in the gateway. Otherwise, project should be empty.
+func (gateway *Gateway) Add(name string, conn Upstream, project string) error {
+	if err := haystack.NewRef(name, "").Validate(); err != nil || strings.Contains(name, ":") {
+		return errors.New("invalid upstream name: " + name)
+	}
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	gateway.upstreams[name] = &upstream{name: name, project: project, conn: conn}
+	return nil
+}
+
+// Remove removes an upstream
+func (gateway *Gateway) Remove(name string) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	delete(gateway.upstreams, name)
+}
+
+// Names returns the names of the upstreams, sorted
+func (gateway *Gateway) Names() []string {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	names := make([]string, 0, len(gateway.upstreams))
+	for name := range gateway.upstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Handle routes the request to the upstreams. The user is not passed on; each upstream is called with the
+// credentials of its connection.
+func (gateway *Gateway) Handle(user string, op string, req haystack.Grid) haystack.Grid {
+	var resp haystack.Grid
+	var err error
+	switch op {
+	case "read":
+		if req.Col("id") != nil {
+			resp, err = gateway.readByIds(req)
+		} else {
+			resp, err = gateway.read(req)
+		}
+	case "nav":
+		resp, err = gateway.nav(req)
+	case "hisRead", "pointWrite":
+		resp, err = gateway.routeRows(op, req)
+	case "hisWrite", "invokeAction":
+		resp, err = gateway.routeMeta(op, req)
+	case "watchSub":
+		resp, err = gateway.watchSub(req)
+	case "watchUnsub":
+		resp, err = gateway.watchUnsub(req)
+	case "watchPoll":
+		resp, err = gateway.watchPoll(req)
+	default:
+		err = server.NewUnknownOpError(op)
+	}
+	if err != nil {
+		return errGrid(err)
+	}
+	return resp
+}
+
+// read sends a filter read to every upstream and merges the results
+func (gateway *Gateway) read(req haystack.Grid) (haystack.Grid, error) {
+	if req.RowCount() == 0 {
+		return haystack.EmptyGrid(), errors.New("read request has no filter")
+	}
+	filterStr, ok := req.RowAt(0).Get("filter").(haystack.Str)
+	if !ok {
+		return haystack.EmptyGrid(), errors.New("read request has no filter")
+	}
+	parsed, err := filter.Parse(filterStr.String())
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	limit := 0
+	if number, ok := req.RowAt(0).Get("limit").(haystack.Number); ok {
+		limit = int(number.Float())
+	}
+
+	// No upstream needs to return more than the limit. The limit is applied again to the merged rows, so that the
+	// result doesn't depend on which upstreams answer first.
+	results := gateway.fanOut(gateway.all(), func(up *upstream) (haystack.Grid, error) {
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("filter")
+		row := []haystack.Val{haystack.NewStr(filter.MapRefs(parsed, up.toUpstreamRef).String())}
+		if limit > 0 {
+			gb.AddColNoMeta("limit")
+			row = append(row, haystack.NewNumber(float64(limit), ""))
+		}
+		gb.AddRow(row)
+		return up.call("read", gb.ToGrid())
+	})
+	grids, unavailable, err := split(results)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	rows := []haystack.Dict{}
+	for _, grid := range grids {
+		for _, row := range grid.Rows() {
+			rows = append(rows, row.ToDict())
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, _ := rows[i].GetRef("id")
+		b, _ := rows[j].GetRef("id")
+		return a.Id() < b.Id()
+	})
+	if limit > 0 && len(rows) > limit {
+		rows = rows[:limit]
+	}
+	return buildGrid(haystack.EmptyDict(), grids, rows, unavailable), nil
+}
+
+// readByIds splits the ids between their upstreams, and returns a row for each id in the order of the request
+func (gateway *Gateway) readByIds(req haystack.Grid) (haystack.Grid, error) {
+	rows := make([]haystack.Dict, req.RowCount())
+	groups := map[*upstream][]int{} // upstream -> indexes of its ids
+	for i, row := range req.Rows() {
+		rows[i] = haystack.EmptyDict()
+		id, ok := row.Get("id").(haystack.Ref)
+		if !ok {
+			continue
+		}
+		if up, err := gateway.owner(id); err == nil {
+			groups[up] = append(groups[up], i)
+		}
+	}
+
+	ups := make([]*upstream, 0, len(groups))
+	for up := range groups {
+		ups = append(ups, up)
+	}
+	sortUpstreams(ups)
+	results := gateway.fanOut(ups, func(up *upstream) (haystack.Grid, error) {
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("id")
+		for _, index := range groups[up] {
+			gb.AddRow([]haystack.Val{req.RowAt(index).Get("id")})
+		}
+		return up.call("read", gb.ToGrid())
+	})
+	grids := []haystack.Grid{}
+	unavailable := []string{}
+	for _, result := range results {
+		if result.err != nil {
+			unavailable = append(unavailable, result.up.name)
+			continue
+		}
+		grids = append(grids, result.grid)
+		for i, index := range groups[result.up] {
+			if i < result.grid.RowCount() {
+				rows[index] = result.grid.RowAt(i).ToDict()
+			}
+		}
+	}
+	return buildGrid(haystack.EmptyDict(), grids, rows, unavailable), nil
+}
+
+// nav returns a root for each upstream, or routes the navId to its upstream
+func (gateway *Gateway) nav(req haystack.Grid) (haystack.Grid, error) {
+	var navId haystack.Val = haystack.NewNull()
+	if req.RowCount() > 0 {
+		navId = req.RowAt(0).Get("navId")
+	}
+
+	var up *upstream
+	var upNavId haystack.Val = haystack.NewNull()
+	switch navId := navId.(type) {
+	case haystack.Null:
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("navId")
+		gb.AddColNoMeta("dis")
+		for _, up := range gateway.all() {
+			gb.AddRow([]haystack.Val{haystack.NewStr(up.name), haystack.NewStr(up.name)})
+		}
+		return gb.ToGrid(), nil
+	case haystack.Str:
+		name := navId.String()
+		rest := ""
+		if index := strings.Index(name, ":"); index >= 0 {
+			name, rest = name[:index], name[index+1:]
+		}
+		var err error
+		up, err = gateway.upstream(name)
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		if rest != "" {
+			upNavId = haystack.NewStr(rest)
+		}
+	case haystack.Ref:
+		var err error
+		up, err = gateway.owner(navId)
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		upNavId = navId
+	default:
+		return haystack.EmptyGrid(), errors.New("invalid navId: " + navId.ToZinc())
+	}
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("navId")
+	gb.AddRow([]haystack.Val{upNavId})
+	resp, err := up.call("nav", gb.ToGrid())
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	if resp.Col("navId") == nil {
+		return resp, nil
+	}
+	rows := make([]haystack.Dict, 0, resp.RowCount())
+	for _, row := range resp.Rows() {
+		dict := row.ToDict()
+		if str, ok := dict.Get("navId").(haystack.Str); ok {
+			dict = dict.Set("navId", haystack.NewStr(up.name+":"+str.String()))
+		}
+		rows = append(rows, dict)
+	}
+	return buildGrid(resp.Meta(), []haystack.Grid{resp}, rows, nil), nil
+}
+
+// routeRows sends the request to the upstream that owns the `id` of every row
+func (gateway *Gateway) routeRows(op string, req haystack.Grid) (haystack.Grid, error) {
+	var up *upstream
+	for _, row := range req.Rows() {
+		rowUp, err := gateway.ownerOf(row.Get("id"))
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		if up != nil && rowUp != up {
+			return haystack.EmptyGrid(), errors.New("request has ids of more than one upstream")
+		}
+		up = rowUp
+	}
+	if up == nil {
+		return haystack.EmptyGrid(), errors.New("request has no id")
+	}
+	return up.call(op, req)
+}
+
+// routeMeta sends the request to the upstream that owns the `id` in the grid meta
+func (gateway *Gateway) routeMeta(op string, req haystack.Grid) (haystack.Grid, error) {
+	up, err := gateway.ownerOf(req.Meta().Get("id"))
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return up.call(op, req)
+}
+
+// watchSub subscribes to the ids on the watches of their upstreams, creating the gateway watch if needed
+func (gateway *Gateway) watchSub(req haystack.Grid) (haystack.Grid, error) {
+	meta := req.Meta()
+	var lease time.Duration
+	if meta.Has("lease") {
+		var err error
+		lease, err = meta.GetDuration("lease")
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+	}
+	watchId := ""
+	var w *gatewayWatch
+	if str, ok := meta.Get("watchId").(haystack.Str); ok {
+		watchId = str.String()
+		var err error
+		w, err = gateway.watch(watchId)
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+	} else {
+		dis, _ := meta.Get("watchDis").(haystack.Str)
+		watchId = haystack.GenRef().Id()
+		w = &gatewayWatch{dis: dis.String(), ids: map[string]string{}}
+	}
+
+	ids, err := reqIds(req)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	groups := map[*upstream][]haystack.Ref{}
+	for _, id := range ids {
+		if up, err := gateway.owner(id); err == nil {
+			groups[up] = append(groups[up], id)
+		}
+	}
+	ups := make([]*upstream, 0, len(groups))
+	for up := range groups {
+		ups = append(ups, up)
+	}
+	sortUpstreams(ups)
+	results := gateway.fanOut(ups, func(up *upstream) (haystack.Grid, error) {
+		gb := haystack.NewGridBuilder()
+		upWatchId, subscribed := gateway.upstreamWatchId(w, up.name)
+		if subscribed {
+			gb.AddMetaVal("watchId", haystack.NewStr(upWatchId))
+		} else {
+			gb.AddMetaVal("watchDis", haystack.NewStr(w.dis))
+		}
+		if meta.Has("lease") {
+			gb.AddMetaVal("lease", meta.Get("lease"))
+		}
+		gb.AddColNoMeta("id")
+		for _, id := range groups[up] {
+			gb.AddRow([]haystack.Val{id})
+		}
+		return up.call("watchSub", gb.ToGrid())
+	})
+	if gateway.dropUnknown(watchId, w, results) {
+		return haystack.EmptyGrid(), watch.NewUnknownWatchError(watchId)
+	}
+	if _, _, err := split(results); err != nil {
+		// No upstream watch was created or renewed, so the gateway watch isn't either
+		return haystack.EmptyGrid(), err
+	}
+
+	grids := []haystack.Grid{}
+	rows := []haystack.Dict{}
+	unavailable := []string{}
+	gateway.lock.Lock()
+	// The gateway watch must be renewed before any of its upstream watches expire
+	if lease > 0 {
+		w.lease = lease
+	}
+	upLease := time.Duration(0)
+	for _, result := range results {
+		if result.err != nil {
+			unavailable = append(unavailable, result.up.name)
+			continue
+		}
+		if upWatchId, ok := result.grid.Meta().Get("watchId").(haystack.Str); ok {
+			w.ids[result.up.name] = upWatchId.String()
+		}
+		if duration, err := result.grid.Meta().GetDuration("lease"); err == nil && duration > 0 {
+			if upLease == 0 || duration < upLease {
+				upLease = duration
+			}
+		}
+		grids = append(grids, result.grid)
+		for _, row := range result.grid.Rows() {
+			rows = append(rows, row.ToDict())
+		}
+	}
+	if upLease > 0 {
+		w.lease = upLease
+	}
+	if w.lease <= 0 {
+		w.lease = watch.DefaultLease
+	}
+	w.expires = gateway.clock.Now().Add(w.lease)
+	gateway.watches[watchId] = w
+	respMeta := haystack.NewDict(map[string]haystack.Val{
+		"watchId": haystack.NewStr(watchId),
+		"lease":   haystack.NewNumberFromDuration(w.lease),
+	})
+	gateway.lock.Unlock()
+
+	return buildGrid(respMeta, grids, rows, unavailable), nil
+}
+
+// watchUnsub removes the ids from the watches of their upstreams, or closes every upstream watch
+func (gateway *Gateway) watchUnsub(req haystack.Grid) (haystack.Grid, error) {
+	watchId, _ := req.Meta().Get("watchId").(haystack.Str)
+	w, err := gateway.watch(watchId.String())
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	ids, err := reqIds(req)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	closeWatch := req.Meta().Has("close") || len(ids) == 0
+
+	groups := map[*upstream][]haystack.Ref{}
+	for _, up := range gateway.watchUpstreams(w) {
+		groups[up] = []haystack.Ref{}
+	}
+	for _, id := range ids {
+		if up, err := gateway.owner(id); err == nil {
+			if _, ok := groups[up]; ok {
+				groups[up] = append(groups[up], id)
+			}
+		}
+	}
+	ups := make([]*upstream, 0, len(groups))
+	for up, upIds := range groups {
+		if closeWatch || len(upIds) > 0 {
+			ups = append(ups, up)
+		}
+	}
+	sortUpstreams(ups)
+	results := gateway.fanOut(ups, func(up *upstream) (haystack.Grid, error) {
+		upWatchId, _ := gateway.upstreamWatchId(w, up.name)
+		gb := haystack.NewGridBuilder()
+		gb.AddMetaVal("watchId", haystack.NewStr(upWatchId))
+		if closeWatch {
+			gb.AddMetaVal("close", haystack.NewMarker())
+		}
+		gb.AddColNoMeta("id")
+		for _, id := range groups[up] {
+			gb.AddRow([]haystack.Val{id})
+		}
+		return up.call("watchUnsub", gb.ToGrid())
+	})
+	if closeWatch {
+		gateway.lock.Lock()
+		delete(gateway.watches, watchId.String())
+		gateway.lock.Unlock()
+	}
+	unavailable := []string{}
+	for _, result := range results {
+		if result.err != nil {
+			unavailable = append(unavailable, result.up.name)
+		}
+	}
+	return buildGrid(haystack.EmptyDict(), nil, nil, unavailable), nil
+}
+
+// watchPoll polls the watch of every upstream and merges the changes
+func (gateway *Gateway) watchPoll(req haystack.Grid) (haystack.Grid, error) {
+	watchId, _ := req.Meta().Get("watchId").(haystack.Str)
+	w, err := gateway.watch(watchId.String())
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	refresh := req.Meta().Has("refresh")
+	results := gateway.fanOut(gateway.watchUpstreams(w), func(up *upstream) (haystack.Grid, error) {
+		upWatchId, _ := gateway.upstreamWatchId(w, up.name)
+		gb := haystack.NewGridBuilder()
+		gb.AddMetaVal("watchId", haystack.NewStr(upWatchId))
+		if refresh {
+			gb.AddMetaVal("refresh", haystack.NewMarker())
+		}
+		gb.AddColNoMeta("empty")
+		return up.call("watchPoll", gb.ToGrid())
+	})
+	if gateway.dropUnknown(watchId.String(), w, results) {
+		return haystack.EmptyGrid(), watch.NewUnknownWatchError(watchId.String())
+	}
+	grids, unavailable, err := split(results)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	gateway.lock.Lock()
+	w.expires = gateway.clock.Now().Add(w.lease)
+	gateway.lock.Unlock()
+	rows := []haystack.Dict{}
+	for _, grid := range grids {
+		for _, row := range grid.Rows() {
+			rows = append(rows, row.ToDict())
+		}
+	}
+	meta := haystack.NewDict(map[string]haystack.Val{"watchId": watchId})
+	return buildGrid(meta, grids, rows, unavailable), nil
+}
+
+// all returns the upstreams, sorted by name
+func (gateway *Gateway) all() []*upstream {
+	names := gateway.Names()
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	ups := make([]*upstream, 0, len(names))
+	for _, name := range names {
+		if up, ok := gateway.upstreams[name]; ok {
+			ups = append(ups, up)
+		}
+	}
+	return ups
+}
+
+func (gateway *Gateway) upstream(name string) (*upstream, error) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	up, ok := gateway.upstreams[name]
+	if !ok {
+		return nil, NewUnknownUpstreamError(name)
+	}
+	return up, nil
+}
+
+// owner returns the upstream whose project the Ref is in
+func (gateway *Gateway) owner(ref haystack.Ref) (*upstream, error) {
+	if !ref.IsAbsolute() {
+		return nil, errors.New("ref is not in an upstream project: @" + ref.Id())
+	}
+	return gateway.upstream(ref.Project())
+}
+
+func (gateway *Gateway) ownerOf(val haystack.Val) (*upstream, error) {
+	ref, ok := val.(haystack.Ref)
+	if !ok {
+		return nil, errors.New("request has no id")
+	}
+	return gateway.owner(ref)
+}
+
+func (gateway *Gateway) watch(watchId string) (*gatewayWatch, error) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	w, ok := gateway.watches[watchId]
+	if !ok || gateway.clock.Now().After(w.expires) {
+		return nil, watch.NewUnknownWatchError(watchId)
+	}
+	return w, nil
+}
+
+// dropUnknown forgets the upstream watches that their upstreams report unknown, and drops the gateway watch if that
+// leaves it without any. It returns whether the gateway watch was dropped.
+func (gateway *Gateway) dropUnknown(watchId string, w *gatewayWatch, results []result) bool {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	forgot := false
+	for _, result := range results {
+		if isUnknownWatch(result.err) {
+			delete(w.ids, result.up.name)
+			forgot = true
+		}
+	}
+	if !forgot || len(w.ids) > 0 {
+		return false
+	}
+	delete(gateway.watches, watchId)
+	return true
+}
+
+// Sweep closes the gateway watches whose lease has passed, along with their upstream watches, and returns how many
+// were closed.
+func (gateway *Gateway) Sweep() int {
+	gateway.lock.Lock()
+	now := gateway.clock.Now()
+	expired := []*gatewayWatch{}
+	for watchId, w := range gateway.watches {
+		if now.After(w.expires) {
+			delete(gateway.watches, watchId)
+			expired = append(expired, w)
+		}
+	}
+	gateway.lock.Unlock()
+
+	// The upstreams expire their watches too, so failures are ignored
+	for _, w := range expired {
+		gateway.fanOut(gateway.watchUpstreams(w), func(up *upstream) (haystack.Grid, error) {
+			upWatchId, _ := gateway.upstreamWatchId(w, up.name)
+			gb := haystack.NewGridBuilder()
+			gb.AddMetaVal("watchId", haystack.NewStr(upWatchId))
+			gb.AddMetaVal("close", haystack.NewMarker())
+			gb.AddColNoMeta("id")
+			return up.call("watchUnsub", gb.ToGrid())
+		})
+	}
+	return len(expired)
+}
+
+// Start sweeps expired watches on a background goroutine at the interval, until Stop is called.
+func (gateway *Gateway) Start(interval time.Duration) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	if gateway.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	gateway.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				gateway.Sweep()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background sweeping started by Start.
+func (gateway *Gateway) Stop() {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	if gateway.stop != nil {
+		close(gateway.stop)
+		gateway.stop = nil
+	}
+}
+
+func (gateway *Gateway) upstreamWatchId(w *gatewayWatch, name string) (string, bool) {
+	gateway.lock.Lock()
+	defer gateway.lock.Unlock()
+	upWatchId, ok := w.ids[name]
+	return upWatchId, ok
+}
+
+// watchUpstreams returns the upstreams that the watch has a watch on, sorted by name
+func (gateway *Gateway) watchUpstreams(w *gatewayWatch) []*upstream {
+	ups := []*upstream{}
+	for _, up := range gateway.all() {
+		if _, ok := gateway.upstreamWatchId(w, up.name); ok {
+			ups = append(ups, up)
+		}
+	}
+	return ups
+}
+
+func sortUpstreams(ups []*upstream) {
+	sort.Slice(ups, func(i, j int) bool {
+		return ups[i].name < ups[j].name
+	})
+}
+
+type result struct {
+	up   *upstream
+	grid haystack.Grid
+	err  error
+}
+
+// fanOut calls the upstreams concurrently, returning the results in the order of the upstreams
+func (gateway *Gateway) fanOut(ups []*upstream, call func(up *upstream) (haystack.Grid, error)) []result {
+	results := make([]result, len(ups))
+	var wait sync.WaitGroup
+	for i, up := range ups {
+		wait.Add(1)
+		go func(i int, up *upstream) {
+			defer wait.Done()
+			grid, err := call(up)
+			results[i] = result{up: up, grid: grid, err: err}
+		}(i, up)
+	}
+	wait.Wait()
+	return results
+}
+
+// split separates the grids of the successful results from the names of the failed upstreams. If every upstream
+// failed, the first error is returned.
+func split(results []result) ([]haystack.Grid, []string, error) {
+	grids := []haystack.Grid{}
+	unavailable := []string{}
+	var firstErr error
+	for _, result := range results {
+		if result.err != nil {
+			unavailable = append(unavailable, result.up.name)
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			continue
+		}
+		grids = append(grids, result.grid)
+	}
+	if len(grids) == 0 && firstErr != nil {
+		return nil, nil, firstErr
+	}
+	return grids, unavailable, nil
+}
+
+// buildGrid creates a grid of the rows with the columns of the upstream grids, flagging unavailable upstreams
+func buildGrid(meta haystack.Dict, grids []haystack.Grid, rows []haystack.Dict, unavailable []string) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaDict(meta)
+	if len(unavailable) > 0 {
+		names := make([]haystack.Val, 0, len(unavailable))
+		for _, name := range unavailable {
+			names = append(names, haystack.NewStr(name))
+		}
+		gb.AddMetaVal("partial", haystack.NewMarker())
+		gb.AddMetaVal("unavailable", haystack.NewList(names))
+	}
+
+	colNames := []string{}
+	added := map[string]bool{}
+	for _, grid := range grids {
+		for _, col := range grid.Cols() {
+			if !added[col.Name()] && col.Name() != "empty" {
+				added[col.Name()] = true
+				colNames = append(colNames, col.Name())
+				gb.AddColDict(col.Name(), col.Meta())
+			}
+		}
+	}
+	if len(colNames) == 0 {
+		gb.AddColNoMeta("empty")
+		return gb.ToGrid()
+	}
+	for _, row := range rows {
+		vals := make([]haystack.Val, 0, len(colNames))
+		for _, name := range colNames {
+			vals = append(vals, row.Get(name))
+		}
+		gb.AddRow(vals)
+	}
+	return gb.ToGrid()
+}
+
+// isUnknownWatch returns whether the error is an upstream's err grid for a watch that it doesn't have. Servers that
+// don't set the `errType` are recognized by their message.
+func isUnknownWatch(err error) bool {
+	upErr, ok := err.(UpstreamError)
+	if !ok {
+		return false
+	}
+	callErr, ok := upErr.Err.(client.CallError)
+	if !ok {
+		return false
+	}
+	if errType, ok := callErr.Grid.Meta().Get("errType").(haystack.Str); ok {
+		return errType.String() == watch.UnknownWatchErrType
+	}
+	dis, _ := callErr.Grid.Meta().Get("dis").(haystack.Str)
+	return strings.Contains(strings.ToLower(dis.String()), "unknown watch")
+}
+
+// errGrid returns the err grid of an upstream, or an err grid describing the error
+func errGrid(err error) haystack.Grid {
+	if upErr, ok := err.(UpstreamError); ok {
+		if callErr, ok := upErr.Err.(client.CallError); ok {
+			return callErr.Grid
+		}
+	}
+	return server.ErrGrid(err)
+}
+
+// call calls the upstream, mapping the Refs of the request and response
+func (up *upstream) call(op string, req haystack.Grid) (haystack.Grid, error) {
+	resp, err := up.conn.Call(op, haystack.MapRefs(req, up.toUpstreamRef).(haystack.Grid))
+	if err != nil {
+		if callErr, ok := err.(client.CallError); ok {
+			err = client.NewCallError(haystack.MapRefs(callErr.Grid, up.fromUpstreamRef).(haystack.Grid))
+		}
+		return haystack.EmptyGrid(), NewUpstreamError(up.name, err)
+	}
+	return haystack.MapRefs(resp, up.fromUpstreamRef).(haystack.Grid), nil
+}
+
+// toUpstreamRef maps a gateway Ref to the upstream. Refs of other upstreams are unchanged.
+func (up *upstream) toUpstreamRef(ref haystack.Ref) haystack.Ref {
+	if ref.Project() != up.name {
+		return ref
+	}
+	ref = ref.Relativize(up.name)
+	if up.project != "" {
+		ref = ref.Absolutize(up.project)
+	}
+	return ref
+}
+
+// fromUpstreamRef maps an upstream Ref to the gateway. Refs of other upstream projects are unchanged.
+func (up *upstream) fromUpstreamRef(ref haystack.Ref) haystack.Ref {
+	if up.project != "" {
+		ref = ref.Relativize(up.project)
+	}
+	return ref.Absolutize(up.name)
+}
+
+// reqIds returns the Refs in the `id` column of a watch request, or the `ids` column used by client.Client
+func reqIds(req haystack.Grid) ([]haystack.Ref, error) {
+	col := "id"
+	if req.Col("id") == nil && req.Col("ids") != nil {
+		col = "ids"
+	}
+	ids := []haystack.Ref{}
+	for _, row := range req.Rows() {
+		ref, ok := row.Get(col).(haystack.Ref)
+		if !ok {
+			return nil, errors.New("watch request row has no ref in '" + col + "'")
+		}
+		ids = append(ids, ref)
+	}
+	return ids, nil
+}
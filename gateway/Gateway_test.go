@@ -0,0 +1,371 @@
+package gateway
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/client"
+	"github.com/NeedleInAJayStack/haystack/nav"
+	"github.com/NeedleInAJayStack/haystack/server"
+	"github.com/NeedleInAJayStack/haystack/store"
+	"github.com/NeedleInAJayStack/haystack/watch"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeUpstream serves a store like a Haystack server, returning errors like client.Client
+type fakeUpstream struct {
+	recs     *store.MemStore
+	watches  *watch.Manager
+	ops      server.Ops
+	down     bool
+	requests []haystack.Grid
+}
+
+func newFakeUpstream(t *testing.T, zinc string) *fakeUpstream {
+	recs := store.NewMemStore()
+	assert.Nil(t, recs.LoadZinc(zinc))
+	up := &fakeUpstream{recs: recs, watches: watch.NewManager(recs)}
+	tree := nav.NewTree(nav.SiteEquipPoint(), recs)
+	up.ops = server.Ops{
+		"read": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			if req.Col("id") != nil {
+				ids := []haystack.Ref{}
+				for _, row := range req.Rows() {
+					ids = append(ids, row.Get("id").(haystack.Ref))
+				}
+				return recs.ReadByIds(ids), nil
+			}
+			limit := 0
+			if number, ok := req.RowAt(0).Get("limit").(haystack.Number); ok {
+				limit = int(number.Float())
+			}
+			return recs.ReadLimit(req.RowAt(0).Get("filter").(haystack.Str).String(), limit)
+		},
+		"nav": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			return tree.HandleNav(req)
+		},
+		"hisRead": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			gb := haystack.NewGridBuilder()
+			gb.AddMetaVal("id", req.RowAt(0).Get("id"))
+			gb.AddColNoMeta("ts")
+			gb.AddColNoMeta("val")
+			return gb.ToGrid(), nil
+		},
+		"watchSub":   up.watches.HandleSub,
+		"watchUnsub": up.watches.HandleUnsub,
+		"watchPoll":  up.watches.HandlePoll,
+	}
+	return up
+}
+
+func (up *fakeUpstream) Call(op string, req haystack.Grid) (haystack.Grid, error) {
+	if up.down {
+		return haystack.EmptyGrid(), client.NewNetworkError("connection refused")
+	}
+	up.requests = append(up.requests, req)
+	resp := up.ops.Handle("", op, req)
+	if server.IsErrGrid(resp) {
+		return haystack.EmptyGrid(), client.NewCallError(resp)
+	}
+	return resp, nil
+}
+
+func testGateway(t *testing.T) (*Gateway, *fakeUpstream, *fakeUpstream) {
+	east := newFakeUpstream(t, `ver:"3.0"
+id,dis,site,equip,point,siteRef,equipRef
+@p:demo:r:s1,"East HQ",M,,,,
+@p:demo:r:ahu,"AHU",,M,,@p:demo:r:s1,
+@p:demo:r:dat,"DAT",,,M,@p:demo:r:s1,@p:demo:r:ahu
+`)
+	west := newFakeUpstream(t, `ver:"3.0"
+id,dis,site,equip,siteRef
+@s1,"West HQ",M,,
+@rtu,"RTU",,M,@s1
+`)
+	gateway := NewGateway()
+	assert.Nil(t, gateway.Add("east", east, "demo"))
+	assert.Nil(t, gateway.Add("west", west, ""))
+	return gateway, east, west
+}
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+}
+
+func readReq(filterStr string) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("filter")
+	gb.AddRow([]haystack.Val{haystack.NewStr(filterStr)})
+	return gb.ToGrid()
+}
+
+func idsReq(col string, ids ...string) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta(col)
+	for _, id := range ids {
+		gb.AddRow([]haystack.Val{haystack.NewRef(id, "")})
+	}
+	return gb.ToGrid()
+}
+
+func rowIds(grid haystack.Grid) []string {
+	ids := []string{}
+	for _, row := range grid.Rows() {
+		id, _ := row.Get("id").(haystack.Ref)
+		ids = append(ids, id.Id())
+	}
+	return ids
+}
+
+func TestGateway_Add(t *testing.T) {
+	gateway, _, _ := testGateway(t)
+	assert.NotNil(t, gateway.Add("bad:name", nil, ""))
+	assert.NotNil(t, gateway.Add("", nil, ""))
+	assert.Equal(t, []string{"east", "west"}, gateway.Names())
+	gateway.Remove("east")
+	assert.Equal(t, []string{"west"}, gateway.Names())
+}
+
+func TestGateway_Read(t *testing.T) {
+	gateway, east, west := testGateway(t)
+
+	grid := gateway.Handle("alice", "read", readReq("site"))
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, []string{"p:east:r:s1", "p:west:r:s1"}, rowIds(grid))
+
+	grid = gateway.Handle("alice", "read", readReq("equip and siteRef==@p:west:r:s1"))
+	assert.Equal(t, []string{"p:west:r:rtu"}, rowIds(grid))
+	assert.Equal(t, haystack.NewRef("p:west:r:s1", ""), grid.RowAt(0).Get("siteRef"))
+	assert.Equal(t, "equip and siteRef == @p:west:r:s1", east.requests[len(east.requests)-1].RowAt(0).Get("filter").(haystack.Str).String())
+	assert.Equal(t, "equip and siteRef == @s1", west.requests[len(west.requests)-1].RowAt(0).Get("filter").(haystack.Str).String())
+
+	grid = gateway.Handle("alice", "read", idsReq("id", "p:west:r:rtu", "p:north:r:x", "p:east:r:dat", "p:east:r:missing"))
+	assert.Equal(t, []string{"p:west:r:rtu", "", "p:east:r:dat", ""}, rowIds(grid))
+	assert.Equal(t, haystack.NewRef("p:east:r:ahu", ""), grid.RowAt(2).Get("equipRef"))
+	assert.Equal(t, haystack.NewRef("p:demo:r:dat", ""), east.requests[len(east.requests)-1].RowAt(0).Get("id"))
+
+	// Filters reach the upstreams in a form they parse
+	grid = gateway.Handle("alice", "read", readReq("site and active == true"))
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, "site and active == true", west.requests[len(west.requests)-1].RowAt(0).Get("filter").(haystack.Str).String())
+
+	// The limit is sent to each upstream, and applied again to the merged rows, in order of id
+	limitReq := func(limit int) haystack.Grid {
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("filter")
+		gb.AddColNoMeta("limit")
+		gb.AddRow([]haystack.Val{haystack.NewStr("equip or site"), haystack.NewNumber(float64(limit), "")})
+		return gb.ToGrid()
+	}
+	grid = gateway.Handle("alice", "read", limitReq(3))
+	assert.Equal(t, []string{"p:east:r:ahu", "p:east:r:s1", "p:west:r:rtu"}, rowIds(grid))
+	assert.Equal(t, haystack.NewNumber(3, ""), east.requests[len(east.requests)-1].RowAt(0).Get("limit"))
+	assert.Equal(t, haystack.NewNumber(3, ""), west.requests[len(west.requests)-1].RowAt(0).Get("limit"))
+
+	grid = gateway.Handle("alice", "read", limitReq(1))
+	assert.Equal(t, []string{"p:east:r:ahu"}, rowIds(grid))
+	assert.Equal(t, haystack.NewNumber(1, ""), west.requests[len(west.requests)-1].RowAt(0).Get("limit"))
+
+	// An unavailable upstream gives partial results
+	west.down = true
+	grid = gateway.Handle("alice", "read", readReq("site"))
+	assert.Equal(t, []string{"p:east:r:s1"}, rowIds(grid))
+	assert.True(t, grid.Meta().Has("partial"))
+	assert.Equal(t, haystack.NewList([]haystack.Val{haystack.NewStr("west")}), grid.Meta().Get("unavailable"))
+
+	east.down = true
+	grid = gateway.Handle("alice", "read", readReq("site"))
+	assert.True(t, server.IsErrGrid(grid))
+}
+
+func TestGateway_Nav(t *testing.T) {
+	gateway, _, _ := testGateway(t)
+
+	grid := gateway.Handle("alice", "nav", haystack.EmptyGrid())
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("east"), grid.RowAt(0).Get("navId"))
+
+	navReq := func(navId haystack.Val) haystack.Grid {
+		gb := haystack.NewGridBuilder()
+		gb.AddColNoMeta("navId")
+		gb.AddRow([]haystack.Val{navId})
+		return gb.ToGrid()
+	}
+	grid = gateway.Handle("alice", "nav", navReq(haystack.NewStr("east")))
+	assert.Equal(t, []string{"p:east:r:s1"}, rowIds(grid))
+	assert.Equal(t, haystack.NewRef("p:east:r:s1", ""), grid.RowAt(0).Get("navId"))
+
+	grid = gateway.Handle("alice", "nav", navReq(grid.RowAt(0).Get("navId")))
+	assert.Equal(t, []string{"p:east:r:ahu"}, rowIds(grid))
+
+	grid = gateway.Handle("alice", "nav", navReq(haystack.NewStr("north")))
+	assert.Equal(t, haystack.NewStr("Unknown upstream: north"), grid.Meta().Get("dis"))
+}
+
+func TestGateway_Route(t *testing.T) {
+	gateway, east, west := testGateway(t)
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("range")
+	gb.AddRow([]haystack.Val{haystack.NewRef("p:east:r:dat", ""), haystack.NewStr("today")})
+	grid := gateway.Handle("alice", "hisRead", gb.ToGrid())
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, haystack.NewRef("p:east:r:dat", ""), grid.Meta().Get("id"))
+	assert.Equal(t, 1, len(east.requests))
+	assert.Equal(t, 0, len(west.requests))
+
+	gb.AddRow([]haystack.Val{haystack.NewRef("p:west:r:rtu", ""), haystack.NewStr("today")})
+	grid = gateway.Handle("alice", "hisRead", gb.ToGrid())
+	assert.True(t, server.IsErrGrid(grid))
+
+	// Upstream err grids are passed on
+	gb = haystack.NewGridBuilder()
+	gb.AddMetaVal("id", haystack.NewRef("p:west:r:rtu", ""))
+	gb.AddMetaVal("action", haystack.NewStr("reset"))
+	gb.AddColNoMeta("empty")
+	grid = gateway.Handle("alice", "invokeAction", gb.ToGrid())
+	assert.Equal(t, haystack.NewStr("Unknown op: invokeAction"), grid.Meta().Get("dis"))
+
+	west.down = true
+	grid = gateway.Handle("alice", "invokeAction", gb.ToGrid())
+	assert.Equal(t, haystack.NewStr("Upstream west: Network error: connection refused"), grid.Meta().Get("dis"))
+
+	grid = gateway.Handle("alice", "eval", haystack.EmptyGrid())
+	assert.True(t, server.IsErrGrid(grid))
+}
+
+func TestGateway_Watch(t *testing.T) {
+	gateway, east, west := testGateway(t)
+
+	req := idsReq("ids", "p:east:r:dat", "p:west:r:rtu")
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchDis", haystack.NewStr("dashboard"))
+	gb.AddColNoMeta("ids")
+	for _, row := range req.Rows() {
+		gb.AddRow([]haystack.Val{row.Get("ids")})
+	}
+	grid := gateway.Handle("alice", "watchSub", gb.ToGrid())
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, []string{"p:east:r:dat", "p:west:r:rtu"}, rowIds(grid))
+	watchId := grid.Meta().Get("watchId")
+	assert.Equal(t, 1, len(east.watches.WatchIds("")))
+	assert.Equal(t, 1, len(west.watches.WatchIds("")))
+
+	pollReq := haystack.NewGridBuilder()
+	pollReq.AddMetaVal("watchId", watchId)
+	pollReq.AddColNoMeta("empty")
+	grid = gateway.Handle("alice", "watchPoll", pollReq.ToGrid())
+	assert.Equal(t, 0, grid.RowCount())
+
+	_, err := west.recs.Update(haystack.NewRef("rtu", ""), haystack.NewDict(map[string]haystack.Val{"dis": haystack.NewStr("RTU-1")}))
+	assert.Nil(t, err)
+	west.watches.Changed(haystack.NewRef("rtu", ""))
+	grid = gateway.Handle("alice", "watchPoll", pollReq.ToGrid())
+	assert.Equal(t, []string{"p:west:r:rtu"}, rowIds(grid))
+	assert.Equal(t, watchId, grid.Meta().Get("watchId"))
+
+	east.down = true
+	grid = gateway.Handle("alice", "watchPoll", pollReq.ToGrid())
+	assert.True(t, grid.Meta().Has("partial"))
+	east.down = false
+
+	unsubReq := haystack.NewGridBuilder()
+	unsubReq.AddMetaVal("watchId", watchId)
+	unsubReq.AddMetaVal("close", haystack.NewMarker())
+	unsubReq.AddColNoMeta("id")
+	grid = gateway.Handle("alice", "watchUnsub", unsubReq.ToGrid())
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Empty(t, east.watches.WatchIds(""))
+	assert.Empty(t, west.watches.WatchIds(""))
+
+	grid = gateway.Handle("alice", "watchPoll", pollReq.ToGrid())
+	assert.True(t, server.IsErrGrid(grid))
+
+	// A watch isn't created if no upstream subscribes
+	east.down = true
+	west.down = true
+	grid = gateway.Handle("alice", "watchSub", gb.ToGrid())
+	assert.True(t, server.IsErrGrid(grid))
+	assert.Empty(t, gateway.watches)
+}
+
+func watchReq(meta map[string]haystack.Val, ids ...string) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaDict(haystack.NewDict(meta))
+	gb.AddColNoMeta("id")
+	for _, id := range ids {
+		gb.AddRow([]haystack.Val{haystack.NewRef(id, "")})
+	}
+	return gb.ToGrid()
+}
+
+func TestGateway_Watch_lease(t *testing.T) {
+	gateway, east, west := testGateway(t)
+	clock := &fakeClock{now: time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)}
+	gateway.SetClock(clock)
+	east.watches.SetClock(clock)
+	west.watches.SetClock(clock)
+	east.watches.SetDefaultLimits(watch.Limits{MaxLease: 10 * time.Second})
+
+	// The gateway watch has the shortest lease of its upstream watches
+	sub := map[string]haystack.Val{"watchDis": haystack.NewStr("dashboard"), "lease": haystack.NewNumber(30, "s")}
+	grid := gateway.Handle("alice", "watchSub", watchReq(sub, "p:east:r:dat", "p:west:r:rtu"))
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, haystack.NewNumber(10, "s"), grid.Meta().Get("lease"))
+	watchId := grid.Meta().Get("watchId")
+	poll := map[string]haystack.Val{"watchId": watchId}
+
+	// Polling renews the lease
+	clock.Advance(8 * time.Second)
+	assert.Equal(t, 0, gateway.Sweep())
+	grid = gateway.Handle("alice", "watchPoll", watchReq(poll))
+	assert.False(t, server.IsErrGrid(grid))
+	clock.Advance(8 * time.Second)
+	assert.Equal(t, 0, gateway.Sweep())
+
+	// An expired watch is closed with its upstream watches
+	clock.Advance(3 * time.Second)
+	assert.Equal(t, 1, gateway.Sweep())
+	assert.Empty(t, gateway.watches)
+	assert.Empty(t, west.watches.WatchIds(""))
+	grid = gateway.Handle("alice", "watchPoll", watchReq(poll))
+	assert.True(t, server.IsErrGrid(grid))
+	assert.Equal(t, haystack.NewStr(watch.UnknownWatchErrType), grid.Meta().Get("errType"))
+}
+
+func TestGateway_Watch_unknown(t *testing.T) {
+	gateway, east, west := testGateway(t)
+
+	sub := map[string]haystack.Val{"watchDis": haystack.NewStr("dashboard")}
+	grid := gateway.Handle("alice", "watchSub", watchReq(sub, "p:east:r:dat", "p:west:r:rtu"))
+	assert.False(t, server.IsErrGrid(grid))
+	poll := map[string]haystack.Val{"watchId": grid.Meta().Get("watchId")}
+	closeUpstream := func(up *fakeUpstream) {
+		for _, upWatchId := range up.watches.WatchIds("") {
+			assert.Nil(t, up.watches.Unsub("", upWatchId, nil, true))
+		}
+	}
+
+	// A watch that one upstream lost is kept for the others
+	closeUpstream(east)
+	grid = gateway.Handle("alice", "watchPoll", watchReq(poll))
+	assert.False(t, server.IsErrGrid(grid))
+	assert.Equal(t, 1, len(gateway.watches))
+
+	// A watch that every upstream lost is dropped
+	closeUpstream(west)
+	grid = gateway.Handle("alice", "watchPoll", watchReq(poll))
+	assert.True(t, server.IsErrGrid(grid))
+	assert.Equal(t, haystack.NewStr(watch.UnknownWatchErrType), grid.Meta().Get("errType"))
+	assert.Empty(t, gateway.watches)
+}
@@ -0,0 +1,31 @@
+package gateway
+
+// UnknownUpstreamError occurs when a request refers to an upstream that isn't in the Gateway, including through the
+// project of a Ref.
+type UnknownUpstreamError struct {
+	Name string
+}
+
+// NewUnknownUpstreamError creates a new UnknownUpstreamError object.
+func NewUnknownUpstreamError(name string) UnknownUpstreamError {
+	return UnknownUpstreamError{Name: name}
+}
+
+func (err UnknownUpstreamError) Error() string {
+	return "Unknown upstream: " + err.Name
+}
+
+// UpstreamError occurs when a call to an upstream server fails.
+type UpstreamError struct {
+	Name string
+	Err  error
+}
+
+// NewUpstreamError creates a new UpstreamError object.
+func NewUpstreamError(name string, err error) UpstreamError {
+	return UpstreamError{Name: name, Err: err}
+}
+
+func (err UpstreamError) Error() string {
+	return "Upstream " + err.Name + ": " + err.Err.Error()
+}
@@ -2,4 +2,4 @@ module github.com/NeedleInAJayStack/haystack
 
 go 1.14
 
-require github.com/stretchr/testify v1.8.3 // indirect
+require github.com/stretchr/testify v1.8.3
@@ -0,0 +1,163 @@
+package haystack
+
+// columnKind identifies the storage layout used by a gridColumn.
+type columnKind int
+
+const (
+	// columnEmpty holds only nulls so far, so no values are stored
+	columnEmpty columnKind = iota
+	// columnNumber stores Number values unboxed
+	columnNumber
+	// columnDateTime stores DateTime values unboxed
+	columnDateTime
+	// columnVal stores arbitrary boxed Vals
+	columnVal
+)
+
+// gridColumn stores all the values of a single Grid column. Columns that contain only Numbers or only DateTimes
+// (optionally mixed with nulls) are stored in typed slices to avoid boxing every cell, which matters for large
+// history grids. As soon as a value of a different kind is appended, the column falls back to a generic []Val.
+//
+// Columns are append-only, so a Grid may safely share the backing arrays of a GridBuilder that keeps growing.
+type gridColumn struct {
+	kind      columnKind
+	size      int
+	nulls     []bool // Only used by typed columns. nil if the column has no nulls.
+	numbers   []Number
+	dateTimes []DateTime
+	vals      []Val
+}
+
+// newEmptyColumn creates a column that contains `size` nulls.
+func newEmptyColumn(size int) gridColumn {
+	return gridColumn{kind: columnEmpty, size: size}
+}
+
+// get returns the Val at the given index. Null is returned for null cells.
+func (col *gridColumn) get(index int) Val {
+	switch col.kind {
+	case columnNumber:
+		if col.isNull(index) {
+			return NewNull()
+		}
+		return col.numbers[index]
+	case columnDateTime:
+		if col.isNull(index) {
+			return NewNull()
+		}
+		return col.dateTimes[index]
+	case columnVal:
+		val := col.vals[index]
+		if val == nil {
+			return NewNull()
+		}
+		return val
+	default:
+		return NewNull()
+	}
+}
+
+func (col *gridColumn) isNull(index int) bool {
+	return col.nulls != nil && col.nulls[index]
+}
+
+// append adds the val to the end of the column, converting the column layout if required.
+func (col *gridColumn) append(val Val) {
+	switch val := val.(type) {
+	case nil:
+		col.appendNull()
+	case Null:
+		col.appendNull()
+	case Number:
+		switch col.kind {
+		case columnEmpty:
+			col.toTyped(columnNumber)
+			col.numbers = append(col.numbers, val)
+			col.appendNotNull()
+		case columnNumber:
+			col.numbers = append(col.numbers, val)
+			col.appendNotNull()
+		default:
+			col.toGeneric()
+			col.vals = append(col.vals, val)
+		}
+	case DateTime:
+		switch col.kind {
+		case columnEmpty:
+			col.toTyped(columnDateTime)
+			col.dateTimes = append(col.dateTimes, val)
+			col.appendNotNull()
+		case columnDateTime:
+			col.dateTimes = append(col.dateTimes, val)
+			col.appendNotNull()
+		default:
+			col.toGeneric()
+			col.vals = append(col.vals, val)
+		}
+	default:
+		col.toGeneric()
+		col.vals = append(col.vals, val)
+	}
+	col.size++
+}
+
+func (col *gridColumn) appendNull() {
+	switch col.kind {
+	case columnNumber:
+		col.markNull()
+		col.numbers = append(col.numbers, Number{})
+	case columnDateTime:
+		col.markNull()
+		col.dateTimes = append(col.dateTimes, DateTime{})
+	case columnVal:
+		col.vals = append(col.vals, NewNull())
+	}
+}
+
+// markNull records that the cell about to be appended is null, allocating the null mask if needed.
+func (col *gridColumn) markNull() {
+	if col.nulls == nil {
+		col.nulls = make([]bool, col.size, col.size+1)
+	}
+	col.nulls = append(col.nulls, true)
+}
+
+// appendNotNull keeps the null mask aligned when a non-null value is appended.
+func (col *gridColumn) appendNotNull() {
+	if col.nulls != nil {
+		col.nulls = append(col.nulls, false)
+	}
+}
+
+// toTyped converts an empty column into a typed column, keeping any existing null cells.
+func (col *gridColumn) toTyped(kind columnKind) {
+	col.kind = kind
+	if col.size > 0 {
+		col.nulls = make([]bool, col.size)
+		for i := range col.nulls {
+			col.nulls[i] = true
+		}
+	}
+	switch kind {
+	case columnNumber:
+		col.numbers = make([]Number, col.size)
+	case columnDateTime:
+		col.dateTimes = make([]DateTime, col.size)
+	}
+}
+
+// toGeneric converts the column to the boxed []Val layout. It does nothing if the column is already generic.
+func (col *gridColumn) toGeneric() {
+	if col.kind == columnVal {
+		return
+	}
+	vals := make([]Val, col.size)
+	for i := 0; i < col.size; i++ {
+		vals[i] = col.get(i)
+	}
+	col.kind = columnVal
+	col.vals = vals
+	col.nulls = nil
+	col.numbers = nil
+	col.dateTimes = nil
+}
@@ -0,0 +1,430 @@
+package his
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Kinds of values that a point can store
+const (
+	KindNumber = "Number"
+	KindBool   = "Bool"
+	KindStr    = "Str"
+)
+
+// DefaultSegmentSize is the default number of samples in a compressed segment
+const DefaultSegmentSize = 1024
+
+// Historian is an embedded time-series store for point history, keyed by point Ref. Each point stores values of a
+// single kind, in its own timezone and unit. Samples are kept in time order and compressed in segments; writes may
+// be in any order, and a write at an existing timestamp replaces the value.
+//
+// A Historian is safe for concurrent use.
+type Historian struct {
+	lock             sync.RWMutex
+	points           map[string]*point
+	segmentSize      int
+	defaultRetention time.Duration
+}
+
+type point struct {
+	id        haystack.Ref
+	kind      string
+	tz        haystack.Tz
+	unit      string
+	retention time.Duration
+	segments  []segment // compressed, in order and not overlapping
+	tail      []sample  // uncompressed samples after the last segment, in order
+}
+
+// Stats summarizes the history of a point. Start and End are only set if Count is not zero, and Min, Max and Mean
+// are Null unless the point is a Number point with history.
+type Stats struct {
+	Count    int
+	Start    haystack.DateTime
+	End      haystack.DateTime
+	Min      haystack.Val
+	Max      haystack.Val
+	Mean     haystack.Val
+	Segments int // number of compressed segments
+	Bytes    int // size of the compressed segments
+}
+
+// NewHistorian creates an empty Historian with no retention limit.
+func NewHistorian() *Historian {
+	return &Historian{points: map[string]*point{}, segmentSize: DefaultSegmentSize}
+}
+
+// SetSegmentSize sets the number of samples compressed together. Larger segments compress better, but make writes
+// before the latest sample slower.
+func (historian *Historian) SetSegmentSize(size int) {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	if size > 0 {
+		historian.segmentSize = size
+	}
+}
+
+// SetDefaultRetention sets how long history is kept for points without their own retention. Zero keeps history
+// forever. See ApplyRetention.
+func (historian *Historian) SetDefaultRetention(retention time.Duration) {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	historian.defaultRetention = retention
+}
+
+// AddPoint adds a point from its record, which must have an `id` Ref, a `kind` of "Number", "Bool" or "Str", and a
+// `tz`. The `unit` of a Number point is optional. Adding a point that already exists updates its timezone and unit,
+// but its kind can't be changed.
+func (historian *Historian) AddPoint(rec haystack.Dict) error {
+	id, err := rec.GetRef("id")
+	if err != nil {
+		return err
+	}
+	kind, err := rec.GetStr("kind")
+	if err != nil {
+		return err
+	}
+	switch kind.String() {
+	case KindNumber, KindBool, KindStr:
+	default:
+		return errors.New("unsupported his kind: " + kind.String())
+	}
+	tzName, err := rec.GetStr("tz")
+	if err != nil {
+		return err
+	}
+	tz, err := haystack.NewTz(tzName.String())
+	if err != nil {
+		return err
+	}
+	unit := ""
+	if rec.Has("unit") {
+		unitStr, err := rec.GetStr("unit")
+		if err != nil {
+			return err
+		}
+		unit = unitStr.String()
+	}
+
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	existing, ok := historian.points[id.Id()]
+	if !ok {
+		historian.points[id.Id()] = &point{id: id, kind: kind.String(), tz: tz, unit: unit}
+		return nil
+	}
+	if existing.kind != kind.String() {
+		return errors.New("his kind of @" + id.Id() + " can't be changed from " + existing.kind)
+	}
+	existing.tz = tz
+	existing.unit = unit
+	return nil
+}
+
+// RemovePoint removes a point and its history.
+func (historian *Historian) RemovePoint(id haystack.Ref) error {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	if _, ok := historian.points[id.Id()]; !ok {
+		return NewUnknownPointError(id)
+	}
+	delete(historian.points, id.Id())
+	return nil
+}
+
+// SetRetention sets how long the history of the point is kept. Zero uses the default retention.
+func (historian *Historian) SetRetention(id haystack.Ref, retention time.Duration) error {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	point, ok := historian.points[id.Id()]
+	if !ok {
+		return NewUnknownPointError(id)
+	}
+	point.retention = retention
+	return nil
+}
+
+// Write stores history items, which are Dicts with a `ts` DateTime and a `val`, like the rows of a 'hisWrite' grid.
+// Values must match the kind of the point. Numbers must have the point's unit or no unit. An error is returned, and
+// nothing is written, if any item is invalid.
+func (historian *Historian) Write(id haystack.Ref, items []haystack.Dict) error {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	point, ok := historian.points[id.Id()]
+	if !ok {
+		return NewUnknownPointError(id)
+	}
+
+	samples := make([]sample, 0, len(items))
+	for _, item := range items {
+		ts, err := item.GetDateTime("ts")
+		if err != nil {
+			return err
+		}
+		val, err := point.normalize(item.Get("val"))
+		if err != nil {
+			return err
+		}
+		samples = append(samples, sample{ts: toMillis(ts), val: val})
+	}
+	if len(samples) == 0 {
+		return nil
+	}
+	return point.write(sortSamples(samples), historian.segmentSize)
+}
+
+// Read returns the history of the point in the span as a 'hisRead' grid: the grid meta has the `id`, `hisStart` and
+// `hisEnd`, and the rows have a `ts` in the point's timezone and a `val`.
+func (historian *Historian) Read(id haystack.Ref, span haystack.Span) (haystack.Grid, error) {
+	historian.lock.RLock()
+	defer historian.lock.RUnlock()
+	point, ok := historian.points[id.Id()]
+	if !ok {
+		return haystack.EmptyGrid(), NewUnknownPointError(id)
+	}
+
+	start := toMillis(span.Start())
+	end := toMillis(span.End())
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("id", id)
+	gb.AddMetaVal("hisStart", span.Start().ToTimezone(point.tz))
+	gb.AddMetaVal("hisEnd", span.End().ToTimezone(point.tz))
+	gb.AddColNoMeta("ts")
+	gb.AddColNoMeta("val")
+	add := func(samples []sample) {
+		for _, sample := range samples {
+			if start <= sample.ts && sample.ts < end {
+				gb.AddRow([]haystack.Val{point.dateTime(sample.ts), sample.val})
+			}
+		}
+	}
+	for _, seg := range point.segments {
+		if seg.end < start || seg.start >= end {
+			continue
+		}
+		samples, err := seg.decode(point.kind, point.unit)
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		add(samples)
+	}
+	add(point.tail)
+	return gb.ToGrid(), nil
+}
+
+// ApplyRetention removes the samples of every point that are older than its retention, relative to now. It returns
+// the number of samples removed.
+func (historian *Historian) ApplyRetention(now haystack.DateTime) (int, error) {
+	historian.lock.Lock()
+	defer historian.lock.Unlock()
+	removed := 0
+	for _, point := range historian.points {
+		retention := point.retention
+		if retention == 0 {
+			retention = historian.defaultRetention
+		}
+		if retention == 0 {
+			continue
+		}
+		count, err := point.trim(toMillis(now.Minus(retention)), historian.segmentSize)
+		if err != nil {
+			return removed, err
+		}
+		removed += count
+	}
+	return removed, nil
+}
+
+// Stats returns a summary of the history of the point.
+func (historian *Historian) Stats(id haystack.Ref) (Stats, error) {
+	historian.lock.RLock()
+	defer historian.lock.RUnlock()
+	point, ok := historian.points[id.Id()]
+	if !ok {
+		return Stats{}, NewUnknownPointError(id)
+	}
+
+	stats := Stats{Min: haystack.NewNull(), Max: haystack.NewNull(), Mean: haystack.NewNull()}
+	stats.Segments = len(point.segments)
+	all := []sample{}
+	for _, seg := range point.segments {
+		stats.Bytes += len(seg.data)
+		samples, err := seg.decode(point.kind, point.unit)
+		if err != nil {
+			return stats, err
+		}
+		all = append(all, samples...)
+	}
+	all = append(all, point.tail...)
+
+	stats.Count = len(all)
+	if stats.Count == 0 {
+		return stats, nil
+	}
+	stats.Start = point.dateTime(all[0].ts)
+	stats.End = point.dateTime(all[len(all)-1].ts)
+	if point.kind == KindNumber {
+		min, max, sum := math.Inf(1), math.Inf(-1), 0.0
+		for _, sample := range all {
+			val := sample.val.(haystack.Number).Float()
+			min = math.Min(min, val)
+			max = math.Max(max, val)
+			sum += val
+		}
+		stats.Min = haystack.NewNumber(min, point.unit)
+		stats.Max = haystack.NewNumber(max, point.unit)
+		stats.Mean = haystack.NewNumber(sum/float64(len(all)), point.unit)
+	}
+	return stats, nil
+}
+
+// normalize checks that the value matches the point, and gives Numbers the point's unit
+func (point *point) normalize(val haystack.Val) (haystack.Val, error) {
+	switch val := val.(type) {
+	case haystack.Number:
+		if point.kind == KindNumber {
+			if val.Unit() != "" && val.Unit() != point.unit {
+				return nil, errors.New("his unit of @" + point.id.Id() + " is '" + point.unit + "', not '" + val.Unit() + "'")
+			}
+			return haystack.NewNumber(val.Float(), point.unit), nil
+		}
+	case haystack.Bool:
+		if point.kind == KindBool {
+			return val, nil
+		}
+	case haystack.Str:
+		if point.kind == KindStr {
+			return val, nil
+		}
+	}
+	return nil, haystack.NewTagTypeError("val", point.kind, val)
+}
+
+func (point *point) dateTime(ts int64) haystack.DateTime {
+	return haystack.NewDateTimeFromGo(time.Unix(0, ts*int64(time.Millisecond))).ToTimezone(point.tz)
+}
+
+// write merges sorted samples into the history. Samples after the last segment are added to the tail, and earlier
+// samples are merged into the segment that covers them.
+func (point *point) write(samples []sample, segmentSize int) error {
+	lastEnd := int64(math.MinInt64)
+	if len(point.segments) > 0 {
+		lastEnd = point.segments[len(point.segments)-1].end
+	}
+	split := sort.Search(len(samples), func(i int) bool { return samples[i].ts > lastEnd })
+	old, recent := samples[:split], samples[split:]
+
+	// Merge into segments from the last to the first, so that splitting a segment doesn't move the others
+	for len(old) > 0 {
+		last := old[len(old)-1]
+		index := sort.Search(len(point.segments), func(i int) bool { return point.segments[i].start > last.ts }) - 1
+		if index < 0 {
+			index = 0
+		}
+		first := sort.Search(len(old), func(i int) bool { return old[i].ts >= point.segments[index].start })
+		if index == 0 {
+			first = 0
+		}
+		existing, err := point.segments[index].decode(point.kind, point.unit)
+		if err != nil {
+			return err
+		}
+		merged := point.encode(mergeSamples(existing, old[first:]), segmentSize)
+		point.segments = append(point.segments[:index], append(merged, point.segments[index+1:]...)...)
+		old = old[:first]
+	}
+
+	point.tail = mergeSamples(point.tail, recent)
+	for len(point.tail) >= segmentSize {
+		point.segments = append(point.segments, encodeSegment(point.kind, point.tail[:segmentSize]))
+		point.tail = append([]sample{}, point.tail[segmentSize:]...)
+	}
+	return nil
+}
+
+// trim removes the samples before the cutoff and returns how many were removed
+func (point *point) trim(cutoff int64, segmentSize int) (int, error) {
+	removed := 0
+	keep := []segment{}
+	for _, seg := range point.segments {
+		if seg.end < cutoff {
+			removed += seg.count
+			continue
+		}
+		if seg.start < cutoff {
+			samples, err := seg.decode(point.kind, point.unit)
+			if err != nil {
+				return removed, err
+			}
+			index := sort.Search(len(samples), func(i int) bool { return samples[i].ts >= cutoff })
+			removed += index
+			keep = append(keep, point.encode(samples[index:], segmentSize)...)
+			continue
+		}
+		keep = append(keep, seg)
+	}
+	point.segments = keep
+
+	index := sort.Search(len(point.tail), func(i int) bool { return point.tail[i].ts >= cutoff })
+	removed += index
+	point.tail = append([]sample{}, point.tail[index:]...)
+	return removed, nil
+}
+
+// encode compresses the samples into segments of at most segmentSize samples
+func (point *point) encode(samples []sample, segmentSize int) []segment {
+	segments := []segment{}
+	for start := 0; start < len(samples); start += segmentSize {
+		end := start + segmentSize
+		if end > len(samples) {
+			end = len(samples)
+		}
+		segments = append(segments, encodeSegment(point.kind, samples[start:end]))
+	}
+	return segments
+}
+
+// sortSamples sorts the samples by time. Of samples with the same time, the last one is kept.
+func sortSamples(samples []sample) []sample {
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].ts < samples[j].ts })
+	result := make([]sample, 0, len(samples))
+	for _, sample := range samples {
+		if len(result) > 0 && result[len(result)-1].ts == sample.ts {
+			result[len(result)-1] = sample
+		} else {
+			result = append(result, sample)
+		}
+	}
+	return result
+}
+
+// mergeSamples merges two sorted sample slices. Samples in b replace those in a with the same time.
+func mergeSamples(a []sample, b []sample) []sample {
+	result := make([]sample, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case j == len(b) || (i < len(a) && a[i].ts < b[j].ts):
+			result = append(result, a[i])
+			i++
+		case i == len(a) || b[j].ts < a[i].ts:
+			result = append(result, b[j])
+			j++
+		default:
+			result = append(result, b[j])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+func toMillis(dateTime haystack.DateTime) int64 {
+	return dateTime.ToGo().UnixNano() / int64(time.Millisecond)
+}
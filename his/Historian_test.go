@@ -0,0 +1,156 @@
+package his
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPoint = haystack.NewRef("p", "")
+
+func newTestHistorian(t *testing.T, kind string) *Historian {
+	historian := NewHistorian()
+	historian.SetSegmentSize(4)
+	err := historian.AddPoint(haystack.NewDict(map[string]haystack.Val{
+		"id":   testPoint,
+		"kind": haystack.NewStr(kind),
+		"tz":   haystack.NewStr("New_York"),
+		"unit": haystack.NewStr("kW"),
+	}))
+	assert.Nil(t, err)
+	return historian
+}
+
+func testItem(hour int, val haystack.Val) haystack.Dict {
+	ts, _ := haystack.NewDateTimeRaw(2021, 1, 1, hour, 0, 0, 0, "UTC")
+	return haystack.NewDict(map[string]haystack.Val{"ts": ts, "val": val})
+}
+
+func readAll(t *testing.T, historian *Historian) haystack.Grid {
+	tz, _ := haystack.NewTz("UTC")
+	span, _ := haystack.NewSpanDates(haystack.NewDate(2020, 1, 1), haystack.NewDate(2022, 1, 1), tz)
+	grid, err := historian.Read(testPoint, span)
+	assert.Nil(t, err)
+	return grid
+}
+
+func vals(grid haystack.Grid) []float64 {
+	result := []float64{}
+	for _, row := range grid.Rows() {
+		result = append(result, row.Get("val").(haystack.Number).Float())
+	}
+	return result
+}
+
+func TestHistorian_Write(t *testing.T) {
+	historian := newTestHistorian(t, KindNumber)
+	items := []haystack.Dict{}
+	for hour := 0; hour < 20; hour += 2 {
+		items = append(items, testItem(hour, haystack.NewNumber(float64(hour), "kW")))
+	}
+	assert.Nil(t, historian.Write(testPoint, items))
+
+	// Out of order writes, into the middle of segments and before the first, with a replaced value
+	assert.Nil(t, historian.Write(testPoint, []haystack.Dict{
+		testItem(5, haystack.NewNumber(5, "")),
+		testItem(0, haystack.NewNumber(-1, "")),
+		testItem(15, haystack.NewNumber(15, "kW")),
+	}))
+	assert.Nil(t, historian.Write(testPoint, []haystack.Dict{testItem(1, haystack.NewNumber(1, ""))}))
+
+	grid := readAll(t, historian)
+	assert.Equal(t, []float64{-1, 1, 2, 4, 5, 6, 8, 10, 12, 14, 15, 16, 18}, vals(grid))
+	assert.Equal(t, testPoint, grid.Meta().Get("id"))
+	first := grid.RowAt(0)
+	assert.Equal(t, "2020-12-31T19:00:00-05:00 New_York", first.Get("ts").ToZinc())
+	assert.Equal(t, "kW", first.Get("val").(haystack.Number).Unit())
+
+	tz, _ := haystack.NewTz("New_York")
+	start, _ := haystack.NewDateTimeRaw(2021, 1, 1, 2, 0, 0, 0, "UTC")
+	span, _ := haystack.NewSpan(start, start.Plus(4*time.Hour).ToTimezone(tz))
+	grid, err := historian.Read(testPoint, span)
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{2, 4, 5}, vals(grid))
+}
+
+func TestHistorian_Write_invalid(t *testing.T) {
+	historian := newTestHistorian(t, KindNumber)
+	assert.NotNil(t, historian.Write(testPoint, []haystack.Dict{
+		testItem(0, haystack.NewNumber(1, "")),
+		testItem(1, haystack.NewNumber(1, "W")),
+	}))
+	assert.NotNil(t, historian.Write(testPoint, []haystack.Dict{testItem(1, haystack.NewStr("1"))}))
+	assert.NotNil(t, historian.Write(testPoint, []haystack.Dict{
+		haystack.NewDict(map[string]haystack.Val{"val": haystack.NewNumber(1, "")}),
+	}))
+	assert.Equal(t, 0, readAll(t, historian).RowCount())
+
+	assert.Equal(t, NewUnknownPointError(haystack.NewRef("x", "")), historian.Write(haystack.NewRef("x", ""), nil))
+	assert.NotNil(t, historian.AddPoint(haystack.NewDict(map[string]haystack.Val{
+		"id":   testPoint,
+		"kind": haystack.NewStr(KindBool),
+		"tz":   haystack.NewStr("UTC"),
+	})))
+}
+
+func TestHistorian_Str(t *testing.T) {
+	historian := newTestHistorian(t, KindStr)
+	items := []haystack.Dict{}
+	for hour := 0; hour < 10; hour++ {
+		items = append(items, testItem(hour, haystack.NewStr([]string{"off", "on"}[hour/3%2])))
+	}
+	assert.Nil(t, historian.Write(testPoint, items))
+	grid := readAll(t, historian)
+	assert.Equal(t, 10, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("on"), grid.RowAt(3).Get("val"))
+	assert.Equal(t, haystack.NewStr("off"), grid.RowAt(6).Get("val"))
+}
+
+func TestHistorian_Retention(t *testing.T) {
+	historian := newTestHistorian(t, KindNumber)
+	items := []haystack.Dict{}
+	for hour := 0; hour < 10; hour++ {
+		items = append(items, testItem(hour, haystack.NewNumber(float64(hour), "")))
+	}
+	assert.Nil(t, historian.Write(testPoint, items))
+
+	now, _ := haystack.NewDateTimeRaw(2021, 1, 1, 10, 0, 0, 0, "UTC")
+	removed, err := historian.ApplyRetention(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, removed)
+
+	historian.SetDefaultRetention(24 * time.Hour)
+	assert.Nil(t, historian.SetRetention(testPoint, 5*time.Hour))
+	removed, err = historian.ApplyRetention(now)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, removed)
+	assert.Equal(t, []float64{5, 6, 7, 8, 9}, vals(readAll(t, historian)))
+}
+
+func TestHistorian_Stats(t *testing.T) {
+	historian := newTestHistorian(t, KindNumber)
+	stats, err := historian.Stats(testPoint)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, stats.Count)
+	assert.Equal(t, haystack.NewNull(), stats.Mean)
+
+	items := []haystack.Dict{}
+	for hour := 0; hour < 10; hour++ {
+		items = append(items, testItem(hour, haystack.NewNumber(float64(hour), "")))
+	}
+	assert.Nil(t, historian.Write(testPoint, items))
+	stats, err = historian.Stats(testPoint)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, stats.Count)
+	assert.Equal(t, 2, stats.Segments)
+	assert.Equal(t, "2020-12-31T19:00:00-05:00 New_York", stats.Start.ToZinc())
+	assert.Equal(t, "2021-01-01T04:00:00-05:00 New_York", stats.End.ToZinc())
+	assert.Equal(t, haystack.NewNumber(0, "kW"), stats.Min)
+	assert.Equal(t, haystack.NewNumber(9, "kW"), stats.Max)
+	assert.Equal(t, haystack.NewNumber(4.5, "kW"), stats.Mean)
+
+	_, err = historian.Stats(haystack.NewRef("x", ""))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,67 @@
+package his
+
+import "errors"
+
+var errEndOfBits = errors.New("unexpected end of segment data")
+
+// bitWriter appends bits to a byte slice, most significant bit first
+type bitWriter struct {
+	bytes []byte
+	free  uint // unused bits in the last byte
+}
+
+func (writer *bitWriter) writeBit(bit bool) {
+	if writer.free == 0 {
+		writer.bytes = append(writer.bytes, 0)
+		writer.free = 8
+	}
+	writer.free--
+	if bit {
+		writer.bytes[len(writer.bytes)-1] |= 1 << writer.free
+	}
+}
+
+// writeBits writes the lowest count bits of the value
+func (writer *bitWriter) writeBits(value uint64, count uint) {
+	for count > 0 {
+		count--
+		writer.writeBit((value>>count)&1 == 1)
+	}
+}
+
+// bitReader reads bits written by a bitWriter
+type bitReader struct {
+	bytes []byte
+	pos   uint // index of the next bit
+}
+
+func (reader *bitReader) readBit() (bool, error) {
+	index := reader.pos / 8
+	if index >= uint(len(reader.bytes)) {
+		return false, errEndOfBits
+	}
+	bit := reader.bytes[index]&(1<<(7-reader.pos%8)) != 0
+	reader.pos++
+	return bit, nil
+}
+
+func (reader *bitReader) readBits(count uint) (uint64, error) {
+	var value uint64
+	for i := uint(0); i < count; i++ {
+		bit, err := reader.readBit()
+		if err != nil {
+			return 0, err
+		}
+		value <<= 1
+		if bit {
+			value |= 1
+		}
+	}
+	return value, nil
+}
+
+// signExtend interprets the lowest count bits of the value as a two's complement number
+func signExtend(value uint64, count uint) int64 {
+	shift := 64 - count
+	return int64(value<<shift) >> shift
+}
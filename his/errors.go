@@ -0,0 +1,17 @@
+package his
+
+import "github.com/NeedleInAJayStack/haystack"
+
+// UnknownPointError occurs when a point has not been added to the Historian.
+type UnknownPointError struct {
+	Id haystack.Ref
+}
+
+// NewUnknownPointError creates a new UnknownPointError object.
+func NewUnknownPointError(id haystack.Ref) UnknownPointError {
+	return UnknownPointError{Id: id}
+}
+
+func (err UnknownPointError) Error() string {
+	return "Unknown his point: @" + err.Id.Id()
+}
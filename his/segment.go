@@ -0,0 +1,284 @@
+package his
+
+import (
+	"errors"
+	"math"
+	"math/bits"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// sample is a history value at a timestamp, in Unix milliseconds
+type sample struct {
+	ts  int64
+	val haystack.Val
+}
+
+// segment is a compressed run of time-ordered samples. Timestamps are encoded as delta-of-deltas, Number values by
+// XOR with the previous value, and Bool and Str values as runs of equal values.
+type segment struct {
+	start int64 // first timestamp
+	end   int64 // last timestamp
+	count int
+	data  []byte
+}
+
+func encodeSegment(kind string, samples []sample) segment {
+	writer := &bitWriter{}
+	encodeTimestamps(writer, samples)
+	switch kind {
+	case KindNumber:
+		encodeNumbers(writer, samples)
+	case KindBool, KindStr:
+		encodeRuns(writer, samples)
+	}
+	return segment{
+		start: samples[0].ts,
+		end:   samples[len(samples)-1].ts,
+		count: len(samples),
+		data:  writer.bytes,
+	}
+}
+
+func (seg segment) decode(kind string, unit string) ([]sample, error) {
+	reader := &bitReader{bytes: seg.data}
+	samples, err := decodeTimestamps(reader, seg.count)
+	if err != nil {
+		return nil, err
+	}
+	switch kind {
+	case KindNumber:
+		err = decodeNumbers(reader, samples, unit)
+	case KindBool, KindStr:
+		err = decodeRuns(reader, kind, samples)
+	default:
+		err = errors.New("unsupported his kind: " + kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return samples, nil
+}
+
+// Delta-of-delta buckets: a prefix of 1s ended by a 0, and the number of value bits that follow
+var dodBuckets = []uint{7, 9, 12}
+
+func encodeTimestamps(writer *bitWriter, samples []sample) {
+	writer.writeBits(uint64(samples[0].ts), 64)
+	prev := samples[0].ts
+	var prevDelta int64
+	for _, sample := range samples[1:] {
+		delta := sample.ts - prev
+		dod := delta - prevDelta
+		prev = sample.ts
+		prevDelta = delta
+
+		if dod == 0 {
+			writer.writeBit(false)
+			continue
+		}
+		written := false
+		for _, size := range dodBuckets {
+			writer.writeBit(true)
+			if fits(dod, size) {
+				writer.writeBit(false)
+				writer.writeBits(uint64(dod), size)
+				written = true
+				break
+			}
+		}
+		if !written {
+			writer.writeBit(true)
+			writer.writeBits(uint64(dod), 64)
+		}
+	}
+}
+
+func decodeTimestamps(reader *bitReader, count int) ([]sample, error) {
+	samples := make([]sample, 0, count)
+	first, err := reader.readBits(64)
+	if err != nil {
+		return nil, err
+	}
+	prev := int64(first)
+	samples = append(samples, sample{ts: prev})
+	var prevDelta int64
+	for len(samples) < count {
+		dod, err := readDod(reader)
+		if err != nil {
+			return nil, err
+		}
+		prevDelta += dod
+		prev += prevDelta
+		samples = append(samples, sample{ts: prev})
+	}
+	return samples, nil
+}
+
+func readDod(reader *bitReader) (int64, error) {
+	bit, err := reader.readBit()
+	if err != nil || !bit {
+		return 0, err
+	}
+	size := uint(64)
+	for _, bucket := range dodBuckets {
+		bit, err = reader.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			size = bucket
+			break
+		}
+	}
+	value, err := reader.readBits(size)
+	if err != nil {
+		return 0, err
+	}
+	return signExtend(value, size), nil
+}
+
+// fits returns true if the value can be represented in size bits of two's complement
+func fits(value int64, size uint) bool {
+	limit := int64(1) << (size - 1)
+	return -limit <= value && value < limit
+}
+
+func encodeNumbers(writer *bitWriter, samples []sample) {
+	prev := math.Float64bits(samples[0].val.(haystack.Number).Float())
+	writer.writeBits(prev, 64)
+	prevLeading, prevTrailing := uint(64), uint(0)
+	for _, sample := range samples[1:] {
+		cur := math.Float64bits(sample.val.(haystack.Number).Float())
+		xor := cur ^ prev
+		prev = cur
+		if xor == 0 {
+			writer.writeBit(false)
+			continue
+		}
+		writer.writeBit(true)
+
+		leading := uint(bits.LeadingZeros64(xor))
+		trailing := uint(bits.TrailingZeros64(xor))
+		if leading > 31 {
+			leading = 31
+		}
+		if prevLeading != 64 && leading >= prevLeading && trailing >= prevTrailing {
+			// The meaningful bits fit in the previous window
+			writer.writeBit(false)
+			writer.writeBits(xor>>prevTrailing, 64-prevLeading-prevTrailing)
+			continue
+		}
+		writer.writeBit(true)
+		meaningful := 64 - leading - trailing
+		writer.writeBits(uint64(leading), 5)
+		writer.writeBits(uint64(meaningful), 6) // 64 is written as 0
+		writer.writeBits(xor>>trailing, meaningful)
+		prevLeading, prevTrailing = leading, trailing
+	}
+}
+
+func decodeNumbers(reader *bitReader, samples []sample, unit string) error {
+	prev, err := reader.readBits(64)
+	if err != nil {
+		return err
+	}
+	samples[0].val = haystack.NewNumber(math.Float64frombits(prev), unit)
+	var leading, trailing uint
+	for i := 1; i < len(samples); i++ {
+		changed, err := reader.readBit()
+		if err != nil {
+			return err
+		}
+		if changed {
+			newWindow, err := reader.readBit()
+			if err != nil {
+				return err
+			}
+			if newWindow {
+				value, err := reader.readBits(5)
+				if err != nil {
+					return err
+				}
+				leading = uint(value)
+				value, err = reader.readBits(6)
+				if err != nil {
+					return err
+				}
+				meaningful := uint(value)
+				if meaningful == 0 {
+					meaningful = 64
+				}
+				trailing = 64 - leading - meaningful
+			}
+			xor, err := reader.readBits(64 - leading - trailing)
+			if err != nil {
+				return err
+			}
+			prev ^= xor << trailing
+		}
+		samples[i].val = haystack.NewNumber(math.Float64frombits(prev), unit)
+	}
+	return nil
+}
+
+// encodeRuns writes each run of equal values as its length and the value
+func encodeRuns(writer *bitWriter, samples []sample) {
+	for i := 0; i < len(samples); {
+		run := 1
+		for i+run < len(samples) && haystack.ValEquals(samples[i].val, samples[i+run].val) {
+			run++
+		}
+		writer.writeBits(uint64(run), 32)
+		switch val := samples[i].val.(type) {
+		case haystack.Bool:
+			writer.writeBit(val.ToBool())
+		case haystack.Str:
+			str := val.String()
+			writer.writeBits(uint64(len(str)), 32)
+			for j := 0; j < len(str); j++ {
+				writer.writeBits(uint64(str[j]), 8)
+			}
+		}
+		i += run
+	}
+}
+
+func decodeRuns(reader *bitReader, kind string, samples []sample) error {
+	for i := 0; i < len(samples); {
+		run, err := reader.readBits(32)
+		if err != nil {
+			return err
+		}
+		var val haystack.Val
+		if kind == KindBool {
+			bit, err := reader.readBit()
+			if err != nil {
+				return err
+			}
+			val = haystack.NewBool(bit)
+		} else {
+			length, err := reader.readBits(32)
+			if err != nil {
+				return err
+			}
+			str := make([]byte, length)
+			for j := range str {
+				char, err := reader.readBits(8)
+				if err != nil {
+					return err
+				}
+				str[j] = byte(char)
+			}
+			val = haystack.NewStr(string(str))
+		}
+		if run == 0 || i+int(run) > len(samples) {
+			return errors.New("invalid run length in segment data")
+		}
+		for j := 0; j < int(run); j++ {
+			samples[i+j].val = val
+		}
+		i += int(run)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package his
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func testSegmentRoundTrip(t *testing.T, kind string, unit string, samples []sample) segment {
+	seg := encodeSegment(kind, samples)
+	decoded, err := seg.decode(kind, unit)
+	assert.Nil(t, err)
+	assert.Equal(t, len(samples), len(decoded))
+	for i := range samples {
+		assert.Equal(t, samples[i].ts, decoded[i].ts)
+		assert.True(t, haystack.ValEquals(samples[i].val, decoded[i].val), "%v != %v", samples[i].val, decoded[i].val)
+	}
+	return seg
+}
+
+func TestSegment_numbers(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	samples := []sample{}
+	ts := int64(1600000000000)
+	val := 70.0
+	for i := 0; i < 1000; i++ {
+		ts += 60000
+		if i%10 == 0 {
+			ts += random.Int63n(5000) - 2500
+		}
+		if i%3 == 0 {
+			val += random.Float64() - 0.5
+		}
+		samples = append(samples, sample{ts: ts, val: haystack.NewNumber(val, "°F")})
+	}
+	samples = append(samples,
+		sample{ts: ts + 1, val: haystack.Inf()},
+		sample{ts: ts + 1000000000, val: haystack.NegInf()},
+		sample{ts: ts + 1000000001, val: haystack.NewNumber(math.MaxFloat64, "°F")},
+		sample{ts: ts + 1000000002, val: haystack.NewNumber(0, "°F")},
+	)
+	seg := testSegmentRoundTrip(t, KindNumber, "°F", samples)
+
+	// 16 bytes per uncompressed sample
+	assert.Less(t, len(seg.data), len(samples)*16/2)
+}
+
+func TestSegment_runs(t *testing.T) {
+	bools := []sample{}
+	strs := []sample{}
+	for i := 0; i < 100; i++ {
+		bools = append(bools, sample{ts: int64(i * 1000), val: haystack.NewBool(i/10%2 == 0)})
+		strs = append(strs, sample{ts: int64(i * 1000), val: haystack.NewStr([]string{"off", "on", "", "fault ✗"}[i/7%4])})
+	}
+	seg := testSegmentRoundTrip(t, KindBool, "", bools)
+	assert.Less(t, len(seg.data), 100)
+	testSegmentRoundTrip(t, KindStr, "", strs)
+}
+
+func TestSegment_single(t *testing.T) {
+	testSegmentRoundTrip(t, KindNumber, "", []sample{{ts: -5, val: haystack.NewNumber(1, "")}})
+	testSegmentRoundTrip(t, KindBool, "", []sample{{ts: 0, val: haystack.NewBool(true)}})
+}
+
+func TestSegment_truncated(t *testing.T) {
+	seg := encodeSegment(KindNumber, []sample{
+		{ts: 0, val: haystack.NewNumber(1, "")},
+		{ts: 1000, val: haystack.NewNumber(2, "")},
+	})
+	seg.data = seg.data[:len(seg.data)-2]
+	_, err := seg.decode(KindNumber, "")
+	assert.NotNil(t, err)
+}
@@ -364,20 +364,11 @@ func (tokenizer *Tokenizer) dateTime(buf *strings.Builder) (Token, error) {
 	} else {
 		tokenizer.consume()
 		buf.WriteRune(' ')
-		for isIdPart(tokenizer.cur) {
+		// timezone names may include '-' (Port-au-Prince) and '+' (GMT+5)
+		for isTzPart(tokenizer.cur) {
 			buf.WriteRune(tokenizer.cur)
 			tokenizer.consume()
 		}
-
-		// handle GMT+xx or GMT-xx
-		if (tokenizer.cur == '+' || tokenizer.cur == '-') && strings.HasSuffix(buf.String(), "GMT") {
-			buf.WriteRune(tokenizer.cur)
-			tokenizer.consume()
-			for unicode.IsDigit(tokenizer.cur) {
-				buf.WriteRune(tokenizer.cur)
-				tokenizer.consume()
-			}
-		}
 	}
 
 	dateTime, err := haystack.NewDateTimeFromString(buf.String())
@@ -575,13 +566,11 @@ func isHex(char rune) bool {
 }
 
 func isRefPart(char rune) bool {
-	if isIdPart(char) {
-		return true
-	} else if char == '-' || char == ':' || char == '.' || char == '~' {
-		return true
-	} else {
-		return false
-	}
+	return haystack.IsRefChar(char)
+}
+
+func isTzPart(char rune) bool {
+	return isIdPart(char) || char == '-' || char == '+'
 }
 
 func isIdStart(char rune) bool {
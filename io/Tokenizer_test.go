@@ -91,6 +91,10 @@ func TestTokenizer_testDateTime(t *testing.T) {
 	testTokenizerSingle(t, "2010-03-01T23:55:00.013+10:00 GMT-10", DATETIME,
 		haystack.NewDateTimeFromGo(time.Date(2010, 3, 1, 23, 55, 00, 13e6, gmtMinus10Location)), true,
 	)
+	portAuPrinceLocation, _ := time.LoadLocation("America/Port-au-Prince")
+	testTokenizerSingle(t, "2010-03-01T23:55:00-05:00 Port-au-Prince", DATETIME,
+		haystack.NewDateTimeFromGo(time.Date(2010, 3, 1, 23, 55, 00, 0, portAuPrinceLocation)), true,
+	)
 }
 func TestTokenizer_testRef(t *testing.T) {
 	testTokenizerSingle(t, "@125b780e-0684e169", REF, haystack.NewRef("125b780e-0684e169", ""), false)
@@ -0,0 +1,209 @@
+package io
+
+import (
+	"bufio"
+	"errors"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// TrioReader reads Trio formatted text into Haystack Dicts. Trio is a line-oriented format where each line is a tag
+// and records are separated by lines beginning with "---":
+//
+//	def: ^site
+//	is: ^marker
+//	doc:
+//	  A site is a single facility.
+//	  Multi-line strings are indented.
+//	---
+//	def: ^siteRef
+//
+// A tag without a value is a Marker. Values are parsed as Zinc scalars or collections, and anything that is not valid
+// Zinc is read as a string. A value of "Zinc:" followed by indented lines is parsed as a nested Zinc value.
+type TrioReader struct {
+	lines []string
+	index int
+}
+
+// InitString initializes with a specific string
+func (reader *TrioReader) InitString(str string) {
+	reader.Init(strings.NewReader(str))
+}
+
+// Init initializes by reading all the lines of the input reader
+func (reader *TrioReader) Init(in *strings.Reader) {
+	reader.lines = []string{}
+	reader.index = 0
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		reader.lines = append(reader.lines, strings.TrimRight(scanner.Text(), " \t\r"))
+	}
+}
+
+// ReadDicts reads all the remaining records as Dicts
+func (reader *TrioReader) ReadDicts() ([]haystack.Dict, error) {
+	dicts := []haystack.Dict{}
+	for {
+		dict, ok, err := reader.ReadDict()
+		if err != nil {
+			return dicts, err
+		}
+		if !ok {
+			return dicts, nil
+		}
+		dicts = append(dicts, dict)
+	}
+}
+
+// ReadGrid reads all the remaining records into a Grid with a row per record
+func (reader *TrioReader) ReadGrid() (haystack.Grid, error) {
+	dicts, err := reader.ReadDicts()
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return haystack.NewGridFromDicts(dicts), nil
+}
+
+// ReadDict reads the next record. The boolean result is false if there are no more records.
+func (reader *TrioReader) ReadDict() (haystack.Dict, bool, error) {
+	tags := map[string]haystack.Val{}
+	for reader.index < len(reader.lines) {
+		lineNum := reader.index + 1
+		line := reader.lines[reader.index]
+		reader.index++
+
+		if strings.HasPrefix(line, "---") {
+			if len(tags) == 0 {
+				continue
+			}
+			return haystack.NewDict(tags), true, nil
+		}
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+		if isIndented(line) {
+			return haystack.EmptyDict(), false, trioError(lineNum, "unexpected indented line")
+		}
+
+		name, valStr, hasVal := splitTrioLine(line)
+		if !isTrioTagName(name) {
+			return haystack.EmptyDict(), false, trioError(lineNum, "invalid tag name: "+name)
+		}
+		if _, ok := tags[name]; ok {
+			return haystack.EmptyDict(), false, trioError(lineNum, "duplicate tag: "+name)
+		}
+
+		var val haystack.Val
+		var err error
+		switch {
+		case !hasVal:
+			val = haystack.NewMarker()
+		case valStr == "":
+			val = haystack.NewStr(reader.readIndented())
+		case valStr == "Zinc:":
+			val, err = readZincVal(reader.readIndented())
+			if err != nil {
+				return haystack.EmptyDict(), false, trioError(lineNum, err.Error())
+			}
+		default:
+			val = parseTrioVal(valStr)
+		}
+		tags[name] = val
+	}
+	if len(tags) == 0 {
+		return haystack.EmptyDict(), false, nil
+	}
+	return haystack.NewDict(tags), true, nil
+}
+
+// readIndented consumes the following indented lines, and returns them joined with their common indentation removed
+func (reader *TrioReader) readIndented() string {
+	block := []string{}
+	for reader.index < len(reader.lines) {
+		line := reader.lines[reader.index]
+		if line != "" && !isIndented(line) {
+			break
+		}
+		block = append(block, line)
+		reader.index++
+	}
+	// Trailing blank lines belong to the record, not the string
+	for len(block) > 0 && block[len(block)-1] == "" {
+		block = block[:len(block)-1]
+	}
+
+	indent := -1
+	for _, line := range block {
+		if line == "" {
+			continue
+		}
+		lineIndent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if indent < 0 || lineIndent < indent {
+			indent = lineIndent
+		}
+	}
+	for i, line := range block {
+		if line != "" {
+			block[i] = line[indent:]
+		}
+	}
+	return strings.Join(block, "\n")
+}
+
+func splitTrioLine(line string) (string, string, bool) {
+	colon := strings.Index(line, ":")
+	if colon < 0 {
+		return strings.TrimSpace(line), "", false
+	}
+	return strings.TrimSpace(line[:colon]), strings.TrimSpace(line[colon+1:]), true
+}
+
+// parseTrioVal parses the value as Zinc, falling back to a string if it is not valid Zinc
+func parseTrioVal(str string) haystack.Val {
+	var reader ZincReader
+	reader.InitString(str)
+	val, err := reader.parseVal()
+	if err != nil || reader.cur != EOF {
+		return haystack.NewStr(str)
+	}
+	return val
+}
+
+func readZincVal(zinc string) (haystack.Val, error) {
+	var reader ZincReader
+	reader.InitString(zinc)
+	if reader.cur == ID && reader.curVal.ToZinc() == "ver" {
+		return reader.ReadVal()
+	}
+	val, err := reader.parseVal()
+	if err != nil {
+		return haystack.NewNull(), err
+	}
+	if reader.cur != EOF {
+		return haystack.NewNull(), errors.New("Expecting EOF, not " + reader.cur.String())
+	}
+	return val, nil
+}
+
+func isIndented(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+}
+
+func isTrioTagName(name string) bool {
+	if name == "" || !unicode.IsLower([]rune(name)[0]) {
+		return false
+	}
+	for _, char := range name {
+		if !isIdPart(char) {
+			return false
+		}
+	}
+	return true
+}
+
+func trioError(lineNum int, msg string) error {
+	return errors.New("trio line " + strconv.Itoa(lineNum) + ": " + msg)
+}
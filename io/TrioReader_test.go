@@ -0,0 +1,76 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrioReader_ReadDicts(t *testing.T) {
+	input := "// Comment\n" +
+		"def: ^site\n" +
+		"is: ^entity\n" +
+		"mandatory\n" +
+		"doc:\n" +
+		"  A site is a single facility.\n" +
+		"\n" +
+		"    Indented more.\n" +
+		"---\n" +
+		"def: ^area\n" +
+		"is: [^number, ^marker]\n" +
+		"minVal: 0ft²\n" +
+		"dis: Unquoted text: with a colon\n" +
+		"quoted: \"Quoted\"\n" +
+		"enabled: T\n" +
+		"---\n" +
+		"---\n" +
+		"children: Zinc:\n" +
+		"  [{equip ahu}, {point}]\n"
+
+	var reader TrioReader
+	reader.InitString(input)
+	dicts, err := reader.ReadDicts()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(dicts))
+
+	assert.Equal(t, haystack.NewSymbol("site"), dicts[0].Get("def"))
+	assert.Equal(t, haystack.NewSymbol("entity"), dicts[0].Get("is"))
+	assert.Equal(t, haystack.NewMarker(), dicts[0].Get("mandatory"))
+	assert.Equal(t, haystack.NewStr("A site is a single facility.\n\n  Indented more."), dicts[0].Get("doc"))
+
+	assert.Equal(t, haystack.NewList([]haystack.Val{haystack.NewSymbol("number"), haystack.NewSymbol("marker")}), dicts[1].Get("is"))
+	assert.Equal(t, haystack.NewNumber(0, "ft²"), dicts[1].Get("minVal"))
+	assert.Equal(t, haystack.NewStr("Unquoted text: with a colon"), dicts[1].Get("dis"))
+	assert.Equal(t, haystack.NewStr("Quoted"), dicts[1].Get("quoted"))
+	assert.Equal(t, haystack.NewBool(true), dicts[1].Get("enabled"))
+
+	children := dicts[2].Get("children").(haystack.List)
+	assert.Equal(t, 2, children.Size())
+	assert.Equal(t, "{ahu equip}", children.Get(0).ToZinc())
+}
+
+func TestTrioReader_ReadGrid(t *testing.T) {
+	var reader TrioReader
+	reader.InitString("id: @a\nsite\n---\nid: @b\nequip\n")
+	grid, err := reader.ReadGrid()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, 3, grid.ColCount())
+	assert.Equal(t, haystack.NewMarker(), grid.RowAt(1).Get("equip"))
+}
+
+func TestTrioReader_errors(t *testing.T) {
+	var reader TrioReader
+	reader.InitString("def: ^site\n  indented\n")
+	_, err := reader.ReadDicts()
+	assert.NotNil(t, err)
+
+	reader.InitString("Bad: name\n")
+	_, err = reader.ReadDicts()
+	assert.EqualError(t, err, "trio line 1: invalid tag name: Bad")
+
+	reader.InitString("dis: a\ndis: b\n")
+	_, err = reader.ReadDicts()
+	assert.EqualError(t, err, "trio line 2: duplicate tag: dis")
+}
@@ -0,0 +1,87 @@
+package io
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// TrioWriter writes Haystack Dicts as Trio formatted text that can be read by TrioReader. Each record starts with
+// its `id`, followed by the other tags in alphabetical order. Markers are written as bare tag names, multi-line
+// strings as indented blocks, and Grids as nested Zinc. Null tags are omitted.
+type TrioWriter struct {
+	builder strings.Builder
+	count   int
+}
+
+// WriteDicts writes each Dict as a record
+func (writer *TrioWriter) WriteDicts(dicts []haystack.Dict) {
+	for _, dict := range dicts {
+		writer.WriteDict(dict)
+	}
+}
+
+// WriteGrid writes each row of the Grid as a record
+func (writer *TrioWriter) WriteGrid(grid haystack.Grid) {
+	for _, row := range grid.Rows() {
+		writer.WriteDict(row.ToDict())
+	}
+}
+
+// WriteDict writes the Dict as a record, separated from any previous record by "---"
+func (writer *TrioWriter) WriteDict(dict haystack.Dict) {
+	if writer.count > 0 {
+		writer.builder.WriteString("---\n")
+	}
+	writer.count++
+
+	names := dict.Names()
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "id") != (names[j] == "id") {
+			return names[i] == "id"
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names {
+		if dict.Missing(name) {
+			continue
+		}
+		writer.writeTag(name, dict.Get(name))
+	}
+}
+
+// String returns the Trio text written so far
+func (writer *TrioWriter) String() string {
+	return writer.builder.String()
+}
+
+func (writer *TrioWriter) writeTag(name string, val haystack.Val) {
+	builder := &writer.builder
+	builder.WriteString(name)
+	switch val := val.(type) {
+	case haystack.Marker:
+		builder.WriteString("\n")
+	case haystack.Str:
+		if strings.Contains(val.String(), "\n") {
+			builder.WriteString(":\n")
+			writeIndented(builder, val.String())
+		} else {
+			builder.WriteString(": " + val.ToZinc() + "\n")
+		}
+	case haystack.Grid:
+		builder.WriteString(": Zinc:\n")
+		writeIndented(builder, strings.TrimRight(val.ToZinc(), "\n"))
+	default:
+		builder.WriteString(": " + val.ToZinc() + "\n")
+	}
+}
+
+func writeIndented(builder *strings.Builder, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		if line != "" {
+			builder.WriteString("  " + line)
+		}
+		builder.WriteString("\n")
+	}
+}
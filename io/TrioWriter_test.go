@@ -0,0 +1,63 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrioWriter_WriteDicts(t *testing.T) {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("a")
+	gb.AddRow([]haystack.Val{haystack.NewNumber(1, "")})
+	dicts := []haystack.Dict{
+		haystack.NewDict(map[string]haystack.Val{
+			"id":    haystack.NewRef("a", "Site A"),
+			"site":  haystack.NewMarker(),
+			"dis":   haystack.NewStr("Site A"),
+			"code":  haystack.NewStr("72"),
+			"doc":   haystack.NewStr("Line one\n\n  Indented"),
+			"area":  haystack.NewNumber(5000, "ft²"),
+			"empty": haystack.NewNull(),
+		}),
+		haystack.NewDict(map[string]haystack.Val{
+			"equip":   haystack.NewMarker(),
+			"siteRef": haystack.NewRef("a", ""),
+			"data":    gb.ToGrid(),
+		}),
+	}
+
+	var writer TrioWriter
+	writer.WriteDicts(dicts)
+	assert.Equal(
+		t,
+		"id: @a \"Site A\"\n"+
+			"area: 5000ft²\n"+
+			"code: \"72\"\n"+
+			"dis: \"Site A\"\n"+
+			"doc:\n"+
+			"  Line one\n"+
+			"\n"+
+			"    Indented\n"+
+			"site\n"+
+			"---\n"+
+			"data: Zinc:\n"+
+			"  ver:\"3.0\"\n"+
+			"  a\n"+
+			"  1\n"+
+			"equip\n"+
+			"siteRef: @a\n",
+		writer.String(),
+	)
+
+	var reader TrioReader
+	reader.InitString(writer.String())
+	read, err := reader.ReadDicts()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(read))
+	assert.Equal(t, haystack.NewStr("72"), read[0].Get("code"))
+	assert.Equal(t, haystack.NewStr("Line one\n\n  Indented"), read[0].Get("doc"))
+	assert.True(t, read[0].Missing("empty"))
+	assert.Equal(t, dicts[1].ToZinc(), read[1].ToZinc())
+}
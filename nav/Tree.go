@@ -0,0 +1,245 @@
+package nav
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/filter"
+)
+
+// Source provides the entities of a nav tree. It is implemented by store.MemStore and store.FileStore, and by Dicts.
+type Source interface {
+	All() []haystack.Dict
+}
+
+// Dicts is a Source of a fixed list of entities
+type Dicts []haystack.Dict
+
+// All returns the entities
+func (dicts Dicts) All() []haystack.Dict {
+	return dicts
+}
+
+// Level is a level of a nav tree definition. Entities that match the filter are placed under the entity referenced by
+// the first of the parent tags that refers to another entity in the tree. A level without parent tags is a root level.
+// Entities are matched to the first level whose filter they match, so each appears at most once.
+type Level struct {
+	Name    string
+	Filter  filter.Filter
+	Parents []string
+}
+
+// NewLevel creates a Level from a filter string, like NewLevel("equip", "equip", "equipRef", "siteRef").
+func NewLevel(name string, filterStr string, parents ...string) (Level, error) {
+	parsed, err := filter.Parse(filterStr)
+	if err != nil {
+		return Level{}, err
+	}
+	return Level{Name: name, Filter: parsed, Parents: parents}, nil
+}
+
+// SiteEquipPoint returns the standard site → equip → point tree definition. Equipment with an `equipRef` is placed
+// under its parent equipment.
+func SiteEquipPoint() []Level {
+	return []Level{
+		{Name: "site", Filter: filter.Has(filter.Path{"site"})},
+		{Name: "equip", Filter: filter.Has(filter.Path{"equip"}), Parents: []string{"equipRef", "siteRef"}},
+		{Name: "point", Filter: filter.Has(filter.Path{"point"}), Parents: []string{"equipRef"}},
+	}
+}
+
+// SiteSpaceEquipPoint returns a site → floor/space → equip → point tree definition. Spaces with a `spaceRef` are
+// placed under their parent space, and equipment in no space is placed directly under its site.
+func SiteSpaceEquipPoint() []Level {
+	return []Level{
+		{Name: "site", Filter: filter.Has(filter.Path{"site"})},
+		{Name: "floor", Filter: filter.Has(filter.Path{"floor"}), Parents: []string{"siteRef"}},
+		{Name: "space", Filter: filter.Has(filter.Path{"space"}), Parents: []string{"spaceRef", "floorRef", "siteRef"}},
+		{Name: "equip", Filter: filter.Has(filter.Path{"equip"}), Parents: []string{"equipRef", "spaceRef", "floorRef", "siteRef"}},
+		{Name: "point", Filter: filter.Has(filter.Path{"point"}), Parents: []string{"equipRef"}},
+	}
+}
+
+// Tree is a navigation tree of entities, which answers 'nav' requests. The navId of an entity with children is its
+// `id` Ref, so navIds are stable across rebuilds. Call Rebuild after the source changes.
+//
+// A Tree is safe for concurrent use.
+type Tree struct {
+	lock     sync.RWMutex
+	levels   []Level
+	source   Source
+	ctx      filter.Context
+	roots    []haystack.Dict
+	children map[string][]haystack.Dict // entity id -> child entities
+	nodes    map[string]bool            // ids of the entities in the tree
+}
+
+// NewTree builds a tree of the source's entities using the level definitions.
+func NewTree(levels []Level, source Source) *Tree {
+	tree := &Tree{levels: levels, source: source}
+	tree.Rebuild()
+	return tree
+}
+
+// SetNamespace sets the defs used to match symbol terms in level filters, like `^ahu`. It takes effect on the next
+// Rebuild.
+func (tree *Tree) SetNamespace(ns filter.Reasoner) {
+	tree.lock.Lock()
+	defer tree.lock.Unlock()
+	tree.ctx.Namespace = ns
+}
+
+// Rebuild reads the entities from the source and rebuilds the tree.
+func (tree *Tree) Rebuild() {
+	entities := tree.source.All()
+	byId := make(map[string]haystack.Dict, len(entities))
+	for _, entity := range entities {
+		if id, err := entity.GetRef("id"); err == nil {
+			byId[id.Id()] = entity
+		}
+	}
+
+	tree.lock.Lock()
+	defer tree.lock.Unlock()
+	ctx := tree.ctx
+	ctx.Resolver = haystack.RefResolverFunc(func(ref haystack.Ref) (haystack.Dict, error) {
+		entity, ok := byId[ref.Id()]
+		if !ok {
+			return haystack.EmptyDict(), errors.New("unknown ref: @" + ref.Id())
+		}
+		return entity, nil
+	})
+
+	// Match each entity to its level first, so that parents can be found regardless of order
+	levelOf := map[string]int{}
+	for _, entity := range entities {
+		id, err := entity.GetRef("id")
+		if err != nil {
+			continue
+		}
+		for i, level := range tree.levels {
+			if level.Filter.Include(entity, ctx) {
+				levelOf[id.Id()] = i
+				break
+			}
+		}
+	}
+
+	parentOf := map[string]string{}
+	roots := []haystack.Dict{}
+	for _, entity := range entities {
+		id, _ := entity.GetRef("id")
+		index, ok := levelOf[id.Id()]
+		if !ok {
+			continue
+		}
+		level := tree.levels[index]
+		if len(level.Parents) == 0 {
+			roots = append(roots, entity)
+			continue
+		}
+		for _, tag := range level.Parents {
+			parent, err := entity.GetRef(tag)
+			if err != nil || parent.Id() == id.Id() {
+				continue
+			}
+			if _, inTree := levelOf[parent.Id()]; inTree {
+				parentOf[id.Id()] = parent.Id()
+				break
+			}
+		}
+	}
+
+	// Only keep the entities reachable from the roots, which excludes orphans and parent cycles
+	children := map[string][]haystack.Dict{}
+	for id, parent := range parentOf {
+		children[parent] = append(children[parent], byId[id])
+	}
+	reachable := map[string][]haystack.Dict{}
+	nodes := map[string]bool{}
+	queue := append([]haystack.Dict{}, roots...)
+	for len(queue) > 0 {
+		id, _ := queue[0].GetRef("id")
+		queue = queue[1:]
+		nodes[id.Id()] = true
+		if kids, ok := children[id.Id()]; ok {
+			sortEntities(kids)
+			reachable[id.Id()] = kids
+			queue = append(queue, kids...)
+		}
+	}
+	sortEntities(roots)
+	tree.roots = roots
+	tree.children = reachable
+	tree.nodes = nodes
+}
+
+// Nav returns the child entities of the navId as a grid, or the roots of the tree if the navId is Null or an empty
+// Str. The navId may be a Ref or a Str of the entity id. Children that have children of their own have a `navId`.
+func (tree *Tree) Nav(navId haystack.Val) (haystack.Grid, error) {
+	tree.lock.RLock()
+	defer tree.lock.RUnlock()
+
+	var entities []haystack.Dict
+	switch navId := navId.(type) {
+	case nil, haystack.Null:
+		entities = tree.roots
+	case haystack.Str:
+		if navId.String() == "" {
+			entities = tree.roots
+		} else {
+			return tree.childGrid(strings.TrimPrefix(navId.String(), "@"))
+		}
+	case haystack.Ref:
+		return tree.childGrid(navId.Id())
+	default:
+		return haystack.EmptyGrid(), errors.New("invalid navId: " + navId.ToZinc())
+	}
+	return tree.toGrid(entities), nil
+}
+
+// HandleNav handles a 'nav' request grid, which has an optional `navId` in its first row.
+func (tree *Tree) HandleNav(req haystack.Grid) (haystack.Grid, error) {
+	var navId haystack.Val = haystack.NewNull()
+	if req.RowCount() > 0 {
+		navId = req.RowAt(0).Get("navId")
+	}
+	return tree.Nav(navId)
+}
+
+// childGrid returns the grid of the entity's children, which is empty for leaves. The caller must hold the lock.
+func (tree *Tree) childGrid(id string) (haystack.Grid, error) {
+	if !tree.nodes[id] {
+		return haystack.EmptyGrid(), errors.New("unknown navId: " + id)
+	}
+	return tree.toGrid(tree.children[id]), nil
+}
+
+// toGrid returns the grid of the entities, with a navId for those with children. The caller must hold the lock.
+func (tree *Tree) toGrid(entities []haystack.Dict) haystack.Grid {
+	rows := make([]haystack.Dict, 0, len(entities))
+	for _, entity := range entities {
+		id, _ := entity.GetRef("id")
+		if _, ok := tree.children[id.Id()]; ok {
+			entity = entity.Set("navId", haystack.NewRef(id.Id(), ""))
+		}
+		rows = append(rows, entity)
+	}
+	return haystack.NewGridFromDicts(rows)
+}
+
+// sortEntities sorts by display name, then by id
+func sortEntities(entities []haystack.Dict) {
+	sort.SliceStable(entities, func(i, j int) bool {
+		disI, disJ := entities[i].Dis(), entities[j].Dis()
+		if disI != disJ {
+			return disI < disJ
+		}
+		idI, _ := entities[i].GetRef("id")
+		idJ, _ := entities[j].GetRef("id")
+		return idI.Id() < idJ.Id()
+	})
+}
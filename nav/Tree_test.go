@@ -0,0 +1,112 @@
+package nav
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/io"
+	"github.com/stretchr/testify/assert"
+)
+
+const testZinc = `ver:"3.0"
+id,dis,site,floor,space,equip,point,siteRef,floorRef,spaceRef,equipRef
+@s2,"Annex",M,,,,,,,,
+@s1,"HQ",M,,,,,,,,
+@f1,"Floor 1",,M,,,,@s1,,,
+@r1,"Room 101",,,M,,,@s1,@f1,,
+@ahu,"AHU-1",,,,M,,@s1,,,
+@vav,"VAV-1",,,,M,,@s1,,@r1,@ahu
+@dat,"DAT",,,,,M,@s1,,,@ahu
+@zat,"ZAT",,,,,M,@s1,,,@vav
+@orphan,"Orphan",,,,,M,@s1,,,@missing
+@loop1,"Loop 1",,,,M,,,,,@loop2
+@loop2,"Loop 2",,,,M,,,,,@loop1
+`
+
+func testSource(t *testing.T) Dicts {
+	var reader io.ZincReader
+	reader.InitString(testZinc)
+	val, err := reader.ReadVal()
+	assert.Nil(t, err)
+	dicts := Dicts{}
+	for _, row := range val.(haystack.Grid).Rows() {
+		dicts = append(dicts, haystack.EmptyDict().Patch(row.ToDict()))
+	}
+	return dicts
+}
+
+func navIds(t *testing.T, tree *Tree, navId haystack.Val) ([]string, []bool) {
+	grid, err := tree.Nav(navId)
+	assert.Nil(t, err)
+	ids := []string{}
+	navigable := []bool{}
+	for _, row := range grid.Rows() {
+		ids = append(ids, row.Get("id").(haystack.Ref).Id())
+		navigable = append(navigable, row.Get("navId") != haystack.NewNull())
+	}
+	return ids, navigable
+}
+
+func TestTree_SiteEquipPoint(t *testing.T) {
+	tree := NewTree(SiteEquipPoint(), testSource(t))
+
+	ids, navigable := navIds(t, tree, haystack.NewNull())
+	assert.Equal(t, []string{"s2", "s1"}, ids)
+	assert.Equal(t, []bool{false, true}, navigable)
+
+	ids, _ = navIds(t, tree, haystack.NewRef("s1", "HQ"))
+	assert.Equal(t, []string{"ahu"}, ids)
+	ids, navigable = navIds(t, tree, haystack.NewStr("@ahu"))
+	assert.Equal(t, []string{"dat", "vav"}, ids)
+	assert.Equal(t, []bool{false, true}, navigable)
+	ids, _ = navIds(t, tree, haystack.NewStr("vav"))
+	assert.Equal(t, []string{"zat"}, ids)
+
+	ids, _ = navIds(t, tree, haystack.NewRef("zat", ""))
+	assert.Empty(t, ids)
+	_, err := tree.Nav(haystack.NewRef("orphan", ""))
+	assert.NotNil(t, err)
+	_, err = tree.Nav(haystack.NewRef("loop1", ""))
+	assert.NotNil(t, err)
+	_, err = tree.Nav(haystack.NewNumber(1, ""))
+	assert.NotNil(t, err)
+}
+
+func TestTree_SiteSpaceEquipPoint(t *testing.T) {
+	tree := NewTree(SiteSpaceEquipPoint(), testSource(t))
+	ids, _ := navIds(t, tree, haystack.NewRef("s1", ""))
+	assert.Equal(t, []string{"ahu", "f1"}, ids)
+	ids, _ = navIds(t, tree, haystack.NewRef("f1", ""))
+	assert.Equal(t, []string{"r1"}, ids)
+
+	// vav has an equipRef, which takes precedence over its spaceRef
+	ids, _ = navIds(t, tree, haystack.NewRef("r1", ""))
+	assert.Empty(t, ids)
+}
+
+func TestTree_Custom(t *testing.T) {
+	source := testSource(t)
+	equips, err := NewLevel("equip", "equip and siteRef", "siteRef")
+	assert.Nil(t, err)
+	sites, err := NewLevel("site", "site")
+	assert.Nil(t, err)
+	tree := NewTree([]Level{sites, equips}, source)
+	ids, _ := navIds(t, tree, haystack.NewRef("s1", ""))
+	assert.Equal(t, []string{"ahu", "vav"}, ids)
+
+	_, err = NewLevel("bad", "equip and")
+	assert.NotNil(t, err)
+
+	tree.source = append(source, haystack.NewDict(map[string]haystack.Val{
+		"id":      haystack.NewRef("boiler", ""),
+		"equip":   haystack.NewMarker(),
+		"siteRef": haystack.NewRef("s2", ""),
+	}))
+	tree.Rebuild()
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("navId")
+	gb.AddRow([]haystack.Val{haystack.NewRef("s2", "")})
+	grid, err := tree.HandleNav(gb.ToGrid())
+	assert.Nil(t, err)
+	assert.Equal(t, 1, grid.RowCount())
+}
@@ -0,0 +1,307 @@
+package point
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Priority levels of a writable point. Level 1 is the highest priority, and level 17 holds the relinquish default.
+const (
+	LevelEmergency = 1
+	LevelManual    = 8
+	LevelDefault   = 17
+	Levels         = 17
+)
+
+// Level is the state of a single level of a PriorityArray. A level with a Null value is relinquished.
+type Level struct {
+	Level int
+	Val   haystack.Val
+	Who   string
+	// Expires is when a timed write is automatically relinquished, or Null if the write doesn't expire
+	Expires haystack.Val
+}
+
+// Dis returns the display name of the level, like "8 (Manual Override)" or "def"
+func (level Level) Dis() string {
+	switch level.Level {
+	case LevelEmergency:
+		return "1 (Emergency)"
+	case LevelManual:
+		return "8 (Manual Override)"
+	case LevelDefault:
+		return "def"
+	default:
+		return strconv.Itoa(level.Level)
+	}
+}
+
+// IsSet returns true if the level has a value
+func (level Level) IsSet() bool {
+	_, isNull := level.Val.(haystack.Null)
+	return level.Val != nil && !isNull
+}
+
+// Change describes a change in the effective value of a PriorityArray
+type Change struct {
+	OldVal   haystack.Val
+	OldLevel int
+	NewVal   haystack.Val
+	NewLevel int
+}
+
+// PriorityArray is the 17 level priority array of a writable point. The effective value is the value of the highest
+// priority level that is set, where level 1 is the highest and level 17 is the relinquish default. Writes may be
+// given a duration, after which they are relinquished automatically.
+//
+// A PriorityArray is safe for concurrent use. Call Close to stop its expiration timer when it is no longer needed.
+type PriorityArray struct {
+	lock      sync.Mutex
+	levels    [Levels]Level
+	listeners []func(Change)
+	now       func() time.Time
+	timer     *time.Timer
+	closed    bool
+}
+
+// NewPriorityArray creates a PriorityArray with the relinquish default value. The default may be Null.
+func NewPriorityArray(relinquishDefault haystack.Val) *PriorityArray {
+	if relinquishDefault == nil {
+		relinquishDefault = haystack.NewNull()
+	}
+	array := &PriorityArray{now: time.Now}
+	for i := range array.levels {
+		array.levels[i] = Level{Level: i + 1, Val: haystack.NewNull(), Expires: haystack.NewNull()}
+	}
+	array.levels[LevelDefault-1].Val = relinquishDefault
+	return array
+}
+
+// NewPriorityArrayFromGrid decodes the result of the 'pointWrite' op when it is called without a value, which has a
+// row for each level with the columns `level`, `val`, `who` and optionally `expires`. Expirations are not scheduled;
+// the decoded array describes the point's state at the time it was read.
+func NewPriorityArrayFromGrid(grid haystack.Grid) (*PriorityArray, error) {
+	array := NewPriorityArray(haystack.NewNull())
+	for _, row := range grid.Rows() {
+		dict := row.ToDict()
+		levelNumber, err := dict.GetNumber("level")
+		if err != nil {
+			return nil, err
+		}
+		level := int(levelNumber.Float())
+		if err := checkLevel(level); err != nil {
+			return nil, err
+		}
+		who := ""
+		if dict.Has("who") {
+			whoStr, err := dict.GetStr("who")
+			if err != nil {
+				return nil, err
+			}
+			who = whoStr.String()
+		}
+		expires := dict.Get("expires")
+		if dict.Has("expires") {
+			if _, err := dict.GetDateTime("expires"); err != nil {
+				return nil, err
+			}
+		}
+		array.levels[level-1] = Level{Level: level, Val: dict.Get("val"), Who: who, Expires: expires}
+	}
+	return array, nil
+}
+
+// SetClock sets the function used to get the current time, for simulations and tests. The default is time.Now.
+func (array *PriorityArray) SetClock(now func() time.Time) {
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	array.now = now
+}
+
+// OnChange adds a listener that is called after each change of the effective value or level. Listeners are called
+// in the goroutine that made the change, without the array locked.
+func (array *PriorityArray) OnChange(listener func(Change)) {
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	array.listeners = append(array.listeners, listener)
+}
+
+// Write sets the value of a level. A Null value relinquishes the level. If the duration is positive, the level is
+// relinquished automatically once it has passed.
+func (array *PriorityArray) Write(level int, val haystack.Val, who string, duration time.Duration) error {
+	if err := checkLevel(level); err != nil {
+		return err
+	}
+	if val == nil {
+		val = haystack.NewNull()
+	}
+	var expires haystack.Val = haystack.NewNull()
+
+	array.lock.Lock()
+	oldVal, oldLevel := array.effective()
+	if _, isNull := val.(haystack.Null); !isNull && duration > 0 {
+		if level == LevelDefault {
+			array.lock.Unlock()
+			return errors.New("the relinquish default can't be written with a duration")
+		}
+		expires = haystack.NewDateTimeFromGo(array.now().Add(duration))
+	}
+	array.levels[level-1] = Level{Level: level, Val: val, Who: who, Expires: expires}
+	array.schedule()
+	array.unlockAndNotify(oldVal, oldLevel)
+	return nil
+}
+
+// Relinquish clears the value of a level.
+func (array *PriorityArray) Relinquish(level int, who string) error {
+	return array.Write(level, haystack.NewNull(), who, 0)
+}
+
+// SetDefault sets the relinquish default value, at level 17.
+func (array *PriorityArray) SetDefault(val haystack.Val, who string) error {
+	return array.Write(LevelDefault, val, who, 0)
+}
+
+// Level returns the state of a level, from 1 to 17.
+func (array *PriorityArray) Level(level int) (Level, error) {
+	if err := checkLevel(level); err != nil {
+		return Level{}, err
+	}
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	return array.levels[level-1], nil
+}
+
+// Levels returns the state of all 17 levels, in priority order.
+func (array *PriorityArray) Levels() []Level {
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	levels := make([]Level, Levels)
+	copy(levels, array.levels[:])
+	return levels
+}
+
+// Effective returns the effective value and the level it is from. If no level is set, it returns Null and level 0.
+func (array *PriorityArray) Effective() (haystack.Val, int) {
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	return array.effective()
+}
+
+// Expire relinquishes every level whose expiration is at or before the time. This is done automatically by a timer,
+// but may be called directly when using a simulated clock.
+func (array *PriorityArray) Expire(now time.Time) {
+	array.lock.Lock()
+	oldVal, oldLevel := array.effective()
+	array.expire(now)
+	array.schedule()
+	array.unlockAndNotify(oldVal, oldLevel)
+}
+
+// Close stops the expiration timer. Timed writes are no longer relinquished automatically, but Expire may still be
+// called.
+func (array *PriorityArray) Close() {
+	array.lock.Lock()
+	defer array.lock.Unlock()
+	array.closed = true
+	if array.timer != nil {
+		array.timer.Stop()
+		array.timer = nil
+	}
+}
+
+// ToGrid encodes the array like the result of the 'pointWrite' op, with a row for each level and the columns
+// `level`, `levelDis`, `val`, `who` and `expires`.
+func (array *PriorityArray) ToGrid() haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("level")
+	gb.AddColNoMeta("levelDis")
+	gb.AddColNoMeta("val")
+	gb.AddColNoMeta("who")
+	gb.AddColNoMeta("expires")
+	for _, level := range array.Levels() {
+		var who haystack.Val = haystack.NewNull()
+		if level.Who != "" {
+			who = haystack.NewStr(level.Who)
+		}
+		gb.AddRow([]haystack.Val{
+			haystack.NewNumber(float64(level.Level), ""),
+			haystack.NewStr(level.Dis()),
+			level.Val,
+			who,
+			level.Expires,
+		})
+	}
+	return gb.ToGrid()
+}
+
+// effective returns the effective value and level. The caller must hold the lock.
+func (array *PriorityArray) effective() (haystack.Val, int) {
+	for _, level := range array.levels {
+		if level.IsSet() {
+			return level.Val, level.Level
+		}
+	}
+	return haystack.NewNull(), 0
+}
+
+// expire relinquishes the levels that have expired. The caller must hold the lock.
+func (array *PriorityArray) expire(now time.Time) {
+	for i, level := range array.levels {
+		expires, ok := level.Expires.(haystack.DateTime)
+		if ok && !expires.ToGo().After(now) {
+			array.levels[i] = Level{Level: level.Level, Val: haystack.NewNull(), Expires: haystack.NewNull()}
+		}
+	}
+}
+
+// schedule starts a timer for the next expiration, replacing any existing timer. The caller must hold the lock.
+func (array *PriorityArray) schedule() {
+	if array.timer != nil {
+		array.timer.Stop()
+		array.timer = nil
+	}
+	if array.closed {
+		return
+	}
+	var next time.Time
+	for _, level := range array.levels {
+		expires, ok := level.Expires.(haystack.DateTime)
+		if ok && (next.IsZero() || expires.ToGo().Before(next)) {
+			next = expires.ToGo()
+		}
+	}
+	if next.IsZero() {
+		return
+	}
+	now := array.now
+	array.timer = time.AfterFunc(next.Sub(now()), func() {
+		array.Expire(now())
+	})
+}
+
+// unlockAndNotify releases the lock and calls the listeners if the effective value or level has changed
+func (array *PriorityArray) unlockAndNotify(oldVal haystack.Val, oldLevel int) {
+	newVal, newLevel := array.effective()
+	listeners := array.listeners
+	array.lock.Unlock()
+
+	if newLevel == oldLevel && haystack.ValEquals(newVal, oldVal) {
+		return
+	}
+	change := Change{OldVal: oldVal, OldLevel: oldLevel, NewVal: newVal, NewLevel: newLevel}
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+func checkLevel(level int) error {
+	if level < 1 || level > Levels {
+		return errors.New("invalid priority level: " + strconv.Itoa(level))
+	}
+	return nil
+}
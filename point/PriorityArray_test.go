@@ -0,0 +1,110 @@
+package point
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityArray_Effective(t *testing.T) {
+	array := NewPriorityArray(haystack.NewNumber(70, "°F"))
+	defer array.Close()
+	val, level := array.Effective()
+	assert.Equal(t, haystack.NewNumber(70, "°F"), val)
+	assert.Equal(t, LevelDefault, level)
+
+	assert.Nil(t, array.Write(16, haystack.NewNumber(68, "°F"), "schedule", 0))
+	assert.Nil(t, array.Write(LevelManual, haystack.NewNumber(72, "°F"), "operator", 0))
+	val, level = array.Effective()
+	assert.Equal(t, haystack.NewNumber(72, "°F"), val)
+	assert.Equal(t, LevelManual, level)
+
+	assert.Nil(t, array.Relinquish(LevelManual, "operator"))
+	val, level = array.Effective()
+	assert.Equal(t, haystack.NewNumber(68, "°F"), val)
+	assert.Equal(t, 16, level)
+
+	assert.Nil(t, array.Relinquish(16, "schedule"))
+	assert.Nil(t, array.SetDefault(haystack.NewNull(), "admin"))
+	val, level = array.Effective()
+	assert.Equal(t, haystack.NewNull(), val)
+	assert.Equal(t, 0, level)
+
+	assert.NotNil(t, array.Write(0, haystack.NewBool(true), "", 0))
+	assert.NotNil(t, array.Write(18, haystack.NewBool(true), "", 0))
+	assert.NotNil(t, array.Write(LevelDefault, haystack.NewBool(true), "", time.Minute))
+}
+
+func TestPriorityArray_Expire(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	array := NewPriorityArray(haystack.NewBool(false))
+	array.Close()
+	array.SetClock(func() time.Time { return now })
+
+	changes := []Change{}
+	array.OnChange(func(change Change) {
+		changes = append(changes, change)
+	})
+	assert.Nil(t, array.Write(LevelManual, haystack.NewBool(true), "operator", time.Hour))
+	assert.Nil(t, array.Write(10, haystack.NewBool(true), "operator", 2*time.Hour))
+	manual, _ := array.Level(LevelManual)
+	assert.Equal(t, "2021-01-01T01:00:00Z UTC", manual.Expires.ToZinc())
+
+	array.Expire(now.Add(30 * time.Minute))
+	_, level := array.Effective()
+	assert.Equal(t, LevelManual, level)
+
+	array.Expire(now.Add(time.Hour))
+	val, level := array.Effective()
+	assert.Equal(t, haystack.NewBool(true), val)
+	assert.Equal(t, 10, level)
+	manual, _ = array.Level(LevelManual)
+	assert.False(t, manual.IsSet())
+
+	array.Expire(now.Add(3 * time.Hour))
+	assert.Equal(t, []Change{
+		{OldVal: haystack.NewBool(false), OldLevel: 17, NewVal: haystack.NewBool(true), NewLevel: 8},
+		{OldVal: haystack.NewBool(true), OldLevel: 8, NewVal: haystack.NewBool(true), NewLevel: 10},
+		{OldVal: haystack.NewBool(true), OldLevel: 10, NewVal: haystack.NewBool(false), NewLevel: 17},
+	}, changes)
+}
+
+func TestPriorityArray_timer(t *testing.T) {
+	array := NewPriorityArray(haystack.NewStr("auto"))
+	defer array.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	array.OnChange(func(change Change) {
+		wg.Done()
+	})
+	assert.Nil(t, array.Write(LevelManual, haystack.NewStr("hand"), "operator", 20*time.Millisecond))
+	wg.Wait()
+	val, level := array.Effective()
+	assert.Equal(t, haystack.NewStr("auto"), val)
+	assert.Equal(t, LevelDefault, level)
+}
+
+func TestPriorityArray_Grid(t *testing.T) {
+	array := NewPriorityArray(haystack.NewNumber(70, "°F"))
+	array.Close()
+	assert.Nil(t, array.Write(LevelManual, haystack.NewNumber(72, "°F"), "operator", time.Hour))
+
+	grid := array.ToGrid()
+	assert.Equal(t, Levels, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("8 (Manual Override)"), grid.RowAt(7).Get("levelDis"))
+	assert.Equal(t, haystack.NewStr("def"), grid.RowAt(16).Get("levelDis"))
+
+	decoded, err := NewPriorityArrayFromGrid(grid)
+	assert.Nil(t, err)
+	assert.Equal(t, array.Levels(), decoded.Levels())
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("level")
+	gb.AddRow([]haystack.Val{haystack.NewNumber(20, "")})
+	_, err = NewPriorityArrayFromGrid(gb.ToGrid())
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,322 @@
+package push
+
+import (
+	"bufio"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/server"
+)
+
+// Formats of pushed grids
+const (
+	FormatZinc   = "zinc"
+	FormatHayson = "hayson"
+)
+
+const (
+	// DefaultInterval is the default interval at which watches are polled for changes
+	DefaultInterval = time.Second
+	// DefaultKeepalive is the default interval of keepalive messages on idle connections
+	DefaultKeepalive = 30 * time.Second
+)
+
+// Notifier signals changes to watches, so that they can be pushed without waiting for the next poll. It is implemented
+// by watch.Manager.
+type Notifier interface {
+	Notify(user string, watchId string) (<-chan struct{}, func(), error)
+}
+
+// Server is an http.Handler that pushes the changes of a watch to clients over a WebSocket or Server-Sent Events.
+// Clients create watches with 'watchSub' as usual, and then connect with the `watchId` query parameter. A WebSocket
+// is used if the request asks to upgrade, and SSE is used if it accepts "text/event-stream". The optional `format`
+// query parameter is "zinc", the default, or "hayson".
+//
+// Each message is a grid, like the result of 'watchPoll'. The first message has every record of the watch, so a
+// client that reconnects with the same watchId resumes with the current state. Later messages have the records that
+// have changed. If the watch is unknown or closed, an err grid is sent and the connection is closed. Over SSE, grids
+// are sent as "grid" events, whose data has a line for each line of the grid.
+//
+// Watches are polled through the Handler, so that its authorization applies, and polling renews their leases. With a
+// Notifier, changes are also pushed as soon as they are reported.
+type Server struct {
+	lock         sync.Mutex
+	handler      server.Handler
+	authenticate func(r *http.Request) (string, error)
+	notifier     Notifier
+	interval     time.Duration
+	keepalive    time.Duration
+	origins      map[string]bool
+}
+
+// NewServer creates a Server that polls watches through the handler. The authenticate function returns the user of
+// a request, or an error if it isn't authenticated.
+func NewServer(handler server.Handler, authenticate func(r *http.Request) (string, error)) *Server {
+	return &Server{
+		handler:      handler,
+		authenticate: authenticate,
+		interval:     DefaultInterval,
+		keepalive:    DefaultKeepalive,
+	}
+}
+
+// SetNotifier sets the Notifier used to push changes immediately.
+func (srv *Server) SetNotifier(notifier Notifier) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.notifier = notifier
+}
+
+// SetInterval sets the interval at which watches are polled. The default is DefaultInterval.
+func (srv *Server) SetInterval(interval time.Duration) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.interval = interval
+}
+
+// SetAllowedOrigins sets the origins, like "https://dashboard.example.com", of the web pages that may open WebSockets
+// to the server, besides pages served from its own host. Browsers send cookies with WebSockets from any page, so other
+// origins are rejected to prevent cross-site WebSocket hijacking. "*" allows every origin. Clients that aren't
+// browsers don't send an Origin, and are always allowed.
+func (srv *Server) SetAllowedOrigins(origins ...string) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.origins = map[string]bool{}
+	for _, origin := range origins {
+		srv.origins[strings.TrimSuffix(origin, "/")] = true
+	}
+}
+
+// SetKeepalive sets the interval of keepalive messages on idle connections. The default is DefaultKeepalive.
+func (srv *Server) SetKeepalive(keepalive time.Duration) {
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	srv.keepalive = keepalive
+}
+
+// ServeHTTP streams the changes of the requested watch until the client disconnects or the watch is closed.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	user, err := srv.authenticate(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	watchId := r.URL.Query().Get("watchId")
+	if watchId == "" {
+		http.Error(w, "Missing watchId", http.StatusBadRequest)
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = FormatZinc
+	}
+	if format != FormatZinc && format != FormatHayson {
+		http.Error(w, "Unsupported format: "+format, http.StatusBadRequest)
+		return
+	}
+
+	var conn stream
+	switch {
+	case isWebSocket(r):
+		if !srv.allowsOrigin(r) {
+			http.Error(w, "Origin not allowed", http.StatusForbidden)
+			return
+		}
+		ws, err := upgrade(w, r)
+		if err != nil {
+			return
+		}
+		conn = newWebSocketStream(ws)
+	case strings.Contains(r.Header.Get("Accept"), "text/event-stream"):
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "Streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+		conn = &sseStream{w: w, flusher: flusher, done: r.Context().Done()}
+	default:
+		http.Error(w, "Push requires a WebSocket or text/event-stream", http.StatusNotAcceptable)
+		return
+	}
+	defer conn.Close()
+	srv.stream(user, watchId, format, conn)
+}
+
+// allowsOrigin returns true if the request has no Origin, or one from the server's host or the allowed origins
+func (srv *Server) allowsOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if parsed, err := url.Parse(origin); err == nil && strings.EqualFold(parsed.Host, r.Host) {
+		return true
+	}
+	srv.lock.Lock()
+	defer srv.lock.Unlock()
+	return srv.origins["*"] || srv.origins[origin]
+}
+
+// stream sends the changes of the watch until the connection or the watch is closed
+func (srv *Server) stream(user string, watchId string, format string, conn stream) {
+	srv.lock.Lock()
+	notifier, interval, keepalive := srv.notifier, srv.interval, srv.keepalive
+	srv.lock.Unlock()
+
+	var notify <-chan struct{}
+	if notifier != nil {
+		ch, cancel, err := notifier.Notify(user, watchId)
+		if err == nil {
+			notify = ch
+			defer cancel()
+		}
+	}
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+	idle := time.NewTicker(keepalive)
+	defer idle.Stop()
+
+	refresh := true
+	for {
+		grid := srv.handler.Handle(user, "watchPoll", pollReq(watchId, refresh))
+		if refresh || grid.RowCount() > 0 || server.IsErrGrid(grid) {
+			message, err := encode(grid, format)
+			if err != nil {
+				grid = server.ErrGrid(err)
+				message, _ = encode(grid, FormatZinc)
+			}
+			if err := conn.Send(message); err != nil || server.IsErrGrid(grid) {
+				return
+			}
+		}
+		refresh = false
+
+		select {
+		case _, open := <-notify:
+			if !open {
+				// The watch was closed, so the next poll sends the error
+				notify = nil
+			}
+		case <-poll.C:
+		case <-idle.C:
+			if err := conn.Keepalive(); err != nil {
+				return
+			}
+			continue
+		case <-conn.Done():
+			return
+		}
+	}
+}
+
+func pollReq(watchId string, refresh bool) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchId", haystack.NewStr(watchId))
+	if refresh {
+		gb.AddMetaVal("refresh", haystack.NewMarker())
+	}
+	gb.AddColNoMeta("empty")
+	return gb.ToGrid()
+}
+
+func encode(grid haystack.Grid, format string) (string, error) {
+	if format == FormatHayson {
+		hayson, err := grid.MarshalHayson()
+		return string(hayson), err
+	}
+	return grid.ToZinc(), nil
+}
+
+// stream is the server side of a push connection
+type stream interface {
+	Send(message string) error
+	Keepalive() error
+	// Done is closed when the client disconnects
+	Done() <-chan struct{}
+	Close() error
+}
+
+type webSocketStream struct {
+	ws   *wsConn
+	done chan struct{}
+}
+
+// newWebSocketStream starts reading from the WebSocket, to answer pings and notice when the client disconnects
+func newWebSocketStream(ws *wsConn) *webSocketStream {
+	str := &webSocketStream{ws: ws, done: make(chan struct{})}
+	go func() {
+		defer close(str.done)
+		for {
+			if _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+	return str
+}
+
+func (str *webSocketStream) Send(message string) error {
+	return str.ws.WriteMessage(message)
+}
+
+func (str *webSocketStream) Keepalive() error {
+	return str.ws.Ping()
+}
+
+func (str *webSocketStream) Done() <-chan struct{} {
+	return str.done
+}
+
+func (str *webSocketStream) Close() error {
+	return str.ws.Close()
+}
+
+type sseStream struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    <-chan struct{}
+}
+
+func (str *sseStream) Send(message string) error {
+	var buf strings.Builder
+	buf.WriteString("event: grid\n")
+	scanner := bufio.NewScanner(strings.NewReader(message))
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	for scanner.Scan() {
+		buf.WriteString("data: ")
+		buf.WriteString(scanner.Text())
+		buf.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	return str.write(buf.String())
+}
+
+func (str *sseStream) Keepalive() error {
+	return str.write(": keepalive\n\n")
+}
+
+func (str *sseStream) write(data string) error {
+	if _, err := str.w.Write([]byte(data)); err != nil {
+		return err
+	}
+	str.flusher.Flush()
+	return nil
+}
+
+func (str *sseStream) Done() <-chan struct{} {
+	return str.done
+}
+
+func (str *sseStream) Close() error {
+	return nil
+}
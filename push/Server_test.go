@@ -0,0 +1,198 @@
+package push
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/server"
+	"github.com/NeedleInAJayStack/haystack/store"
+	"github.com/NeedleInAJayStack/haystack/watch"
+	"github.com/stretchr/testify/assert"
+)
+
+type testEnv struct {
+	recs    *store.MemStore
+	manager *watch.Manager
+	ops     server.Ops
+	push    *Server
+	http    *httptest.Server
+	deny    bool // set before connecting, to deny every push poll
+}
+
+func newTestEnv(t *testing.T) *testEnv {
+	recs := store.NewMemStore()
+	assert.Nil(t, recs.LoadZinc(`ver:"3.0"
+id,dis,curVal
+@a,"A",1
+@b,"B",2
+`))
+	manager := watch.NewManager(recs)
+	ops := server.Ops{
+		"watchSub":   manager.HandleSub,
+		"watchUnsub": manager.HandleUnsub,
+		"watchPoll":  manager.HandlePoll,
+	}
+	env := &testEnv{recs: recs, manager: manager, ops: ops}
+	handler := server.HandlerFunc(func(user string, op string, req haystack.Grid) haystack.Grid {
+		if env.deny {
+			return server.ErrGrid(server.NewDeniedError(user, op, haystack.Ref{}, "op not permitted"))
+		}
+		return ops.Handle(user, op, req)
+	})
+	push := NewServer(handler, func(r *http.Request) (string, error) {
+		user := r.Header.Get("Authorization")
+		if user == "" {
+			return "", errors.New("not authenticated")
+		}
+		return user, nil
+	})
+	push.SetNotifier(manager)
+	push.SetInterval(20 * time.Millisecond)
+
+	mux := http.NewServeMux()
+	mux.Handle("/push", push)
+	env.push = push
+	env.http = httptest.NewServer(mux)
+	t.Cleanup(env.http.Close)
+	return env
+}
+
+func (env *testEnv) sub(t *testing.T, user string) string {
+	grid, err := env.manager.Sub(user, "", "test", time.Minute, []haystack.Ref{haystack.NewRef("a", ""), haystack.NewRef("b", "")})
+	assert.Nil(t, err)
+	return grid.Meta().Get("watchId").(haystack.Str).String()
+}
+
+func (env *testEnv) change(t *testing.T, id string, curVal float64) {
+	ref := haystack.NewRef(id, "")
+	_, err := env.recs.Update(ref, haystack.NewDict(map[string]haystack.Val{"curVal": haystack.NewNumber(curVal, "")}))
+	assert.Nil(t, err)
+	env.manager.Changed(ref)
+}
+
+func TestServer_Rejects(t *testing.T) {
+	env := newTestEnv(t)
+	watchId := env.sub(t, "alice")
+
+	get := func(query string, header http.Header) int {
+		req, _ := http.NewRequest("GET", env.http.URL+"/push"+query, nil)
+		for name, vals := range header {
+			req.Header[name] = vals
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.Nil(t, err)
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	alice := http.Header{"Authorization": {"alice"}}
+	assert.Equal(t, http.StatusUnauthorized, get("?watchId="+watchId, nil))
+	assert.Equal(t, http.StatusBadRequest, get("", alice))
+	assert.Equal(t, http.StatusBadRequest, get("?watchId="+watchId+"&format=csv", alice))
+	assert.Equal(t, http.StatusNotAcceptable, get("?watchId="+watchId, alice))
+}
+
+func TestServer_SSE(t *testing.T) {
+	env := newTestEnv(t)
+	watchId := env.sub(t, "alice")
+
+	conn, err := dialSSE(env.http.URL+"/push?watchId="+watchId, http.Header{"Authorization": {"alice"}})
+	assert.Nil(t, err)
+	defer conn.Close()
+
+	message, err := conn.ReadMessage()
+	assert.Nil(t, err)
+	grid, err := decode(message)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, haystack.NewStr(watchId), grid.Meta().Get("watchId"))
+
+	env.change(t, "b", 20)
+	message, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	grid, err = decode(message)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, grid.RowCount())
+	assert.Equal(t, haystack.NewNumber(20, ""), grid.RowAt(0).Get("curVal"))
+
+	// Closing the watch ends the stream with an err grid
+	assert.Nil(t, env.manager.Unsub("alice", watchId, nil, true))
+	message, err = conn.ReadMessage()
+	assert.Nil(t, err)
+	grid, err = decode(message)
+	assert.Nil(t, err)
+	assert.True(t, server.IsErrGrid(grid))
+}
+
+func TestServer_WebSocket(t *testing.T) {
+	env := newTestEnv(t)
+	watchId := env.sub(t, "alice")
+	uri := strings.Replace(env.http.URL, "http://", "ws://", 1) + "/push?format=hayson&watchId=" + watchId
+
+	ws, err := dialWebSocket(uri, http.Header{"Authorization": {"alice"}})
+	assert.Nil(t, err)
+	message, err := ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.True(t, strings.HasPrefix(message, `{"_kind":"grid"`))
+
+	env.change(t, "a", 10)
+	message, err = ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.Contains(t, message, `"curVal":{"_kind":"number","val":10}`)
+	assert.Nil(t, ws.Close())
+
+	// Another user can't stream the watch
+	ws, err = dialWebSocket(uri, http.Header{"Authorization": {"bob"}})
+	assert.Nil(t, err)
+	defer ws.Close()
+	message, err = ws.ReadMessage()
+	assert.Nil(t, err)
+	assert.Contains(t, message, `"err"`)
+	_, err = ws.ReadMessage()
+	assert.NotNil(t, err)
+
+	_, err = dialWebSocket(env.http.URL+"/missing", nil)
+	assert.Equal(t, NewHTTPStatusError(http.StatusNotFound, "404 Not Found"), err)
+}
+
+func TestServer_WebSocket_origin(t *testing.T) {
+	env := newTestEnv(t)
+	watchId := env.sub(t, "alice")
+	uri := env.http.URL + "/push?watchId=" + watchId
+	dial := func(origin string) error {
+		ws, err := dialWebSocket(uri, http.Header{"Authorization": {"alice"}, "Origin": {origin}})
+		if err == nil {
+			ws.Close()
+		}
+		return err
+	}
+
+	assert.Nil(t, dial(env.http.URL))
+	assert.Equal(t, NewHTTPStatusError(http.StatusForbidden, "403 Forbidden"), dial("https://evil.example"))
+	env.push.SetAllowedOrigins("https://dashboard.example/")
+	assert.Nil(t, dial("https://dashboard.example"))
+	assert.NotNil(t, dial("https://evil.example"))
+	env.push.SetAllowedOrigins("*")
+	assert.Nil(t, dial("https://evil.example"))
+}
+
+func TestServer_WebSocket_unmasked(t *testing.T) {
+	env := newTestEnv(t)
+	watchId := env.sub(t, "alice")
+	ws, err := dialWebSocket(env.http.URL+"/push?watchId="+watchId, http.Header{"Authorization": {"alice"}})
+	assert.Nil(t, err)
+	defer ws.Close()
+	_, err = ws.ReadMessage()
+	assert.Nil(t, err)
+
+	// The server closes the connection when a client frame isn't masked
+	ws.isClient = false
+	assert.Nil(t, ws.WriteMessage("hello"))
+	ws.isClient = true
+	_, err = ws.ReadMessage()
+	assert.NotNil(t, err)
+}
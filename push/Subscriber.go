@@ -0,0 +1,452 @@
+package push
+
+import (
+	"bufio"
+	"errors"
+	goio "io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/client"
+	"github.com/NeedleInAJayStack/haystack/io"
+	"github.com/NeedleInAJayStack/haystack/watch"
+)
+
+// Transport is the kind of push connection a Subscriber makes
+type Transport int
+
+// Transports
+const (
+	WebSocket Transport = iota
+	SSE
+)
+
+const (
+	// DefaultPollInterval is the default interval at which a Subscriber polls when push isn't available
+	DefaultPollInterval = 5 * time.Second
+	// DefaultRetry is the default delay before a Subscriber reconnects after its push connection is lost
+	DefaultRetry = 5 * time.Second
+)
+
+// Watcher makes watch requests. It is implemented by client.Client. Server side errors must be returned as a
+// client.CallError, so that a lost watch can be subscribed again.
+type Watcher interface {
+	WatchSubCreate(watchDis string, lease haystack.Number, ids []haystack.Ref) (haystack.Grid, error)
+	WatchPoll(watchId string, refresh bool) (haystack.Grid, error)
+	WatchUnsub(watchId string, ids []haystack.Ref) (haystack.Grid, error)
+}
+
+// errWatchLost means that the server no longer has the watch
+var errWatchLost = errors.New("watch lost")
+
+// isWatchLost returns true if the err grid means that the watch is unknown or has expired. Servers that don't send an
+// `errType` are recognized by the message.
+func isWatchLost(grid haystack.Grid) bool {
+	if errType, ok := grid.Meta().Get("errType").(haystack.Str); ok {
+		return errType.String() == watch.UnknownWatchErrType
+	}
+	dis, _ := grid.Meta().Get("dis").(haystack.Str)
+	return strings.Contains(strings.ToLower(dis.String()), "unknown watch")
+}
+
+// Subscriber receives the changes of a watch from a push Server, like:
+//
+//	sub := push.NewSubscriber(haystackClient, "https://host/api/demo/push", push.WebSocket)
+//	sub.SetHeader(http.Header{"Authorization": {haystackClient.AuthHeader()}})
+//	err := sub.Subscribe("dashboard", time.Minute, ids, func(changes haystack.Grid) { ... })
+//
+// The watch is created with the Watcher, and the Subscriber then connects to the push URI. If the server doesn't offer
+// push, the Subscriber falls back to polling with the Watcher. A lost connection is reopened with the same watch id,
+// and if the server no longer has the watch, it is subscribed again with a new id. The listener is called with every
+// grid received, including the full state after each reconnection. Other errors from the server, like denials, stop
+// the Subscriber, and are reported to the OnError listeners as a client.CallError.
+//
+// A Subscriber is safe for concurrent use.
+type Subscriber struct {
+	lock         sync.Mutex
+	watcher      Watcher
+	pushUri      string
+	transport    Transport
+	header       http.Header
+	pollInterval time.Duration
+	retry        time.Duration
+	errListeners []func(error)
+
+	dis      string
+	lease    time.Duration
+	ids      []haystack.Ref
+	listener func(haystack.Grid)
+	watchId  string
+	pushing  bool
+	conn     goio.Closer // the open push connection
+	stop     chan struct{}
+	done     chan struct{}
+	closed   bool
+}
+
+// NewSubscriber creates a Subscriber that makes watch requests with the watcher, and connects to the push URI with
+// the transport. If the push URI is empty, the Subscriber only polls.
+func NewSubscriber(watcher Watcher, pushUri string, transport Transport) *Subscriber {
+	return &Subscriber{
+		watcher:      watcher,
+		pushUri:      pushUri,
+		transport:    transport,
+		header:       http.Header{},
+		pollInterval: DefaultPollInterval,
+		retry:        DefaultRetry,
+	}
+}
+
+// SetHeader sets the headers of push connections, such as the Authorization header of the Watcher's connection.
+func (sub *Subscriber) SetHeader(header http.Header) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	sub.header = header
+}
+
+// SetPollInterval sets the interval at which the Subscriber polls when push isn't available. The default is
+// DefaultPollInterval.
+func (sub *Subscriber) SetPollInterval(interval time.Duration) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	sub.pollInterval = interval
+}
+
+// SetRetry sets the delay before reconnecting after the push connection is lost. The default is DefaultRetry.
+func (sub *Subscriber) SetRetry(retry time.Duration) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	sub.retry = retry
+}
+
+// OnError adds a listener that is called with the errors that the Subscriber recovers from, like lost connections.
+func (sub *Subscriber) OnError(listener func(error)) {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	sub.errListeners = append(sub.errListeners, listener)
+}
+
+// Subscribe creates a watch of the ids, calls the listener with their current records, and then calls it with the
+// changes on a background goroutine until Close is called. If the lease is 0 or less, the server's default is used.
+func (sub *Subscriber) Subscribe(watchDis string, lease time.Duration, ids []haystack.Ref, listener func(haystack.Grid)) error {
+	sub.lock.Lock()
+	if sub.stop != nil {
+		sub.lock.Unlock()
+		return errors.New("already subscribed")
+	}
+	sub.dis = watchDis
+	sub.lease = lease
+	sub.ids = append([]haystack.Ref{}, ids...)
+	sub.listener = listener
+	sub.lock.Unlock()
+
+	if err := sub.resubscribe(); err != nil {
+		return err
+	}
+	sub.lock.Lock()
+	sub.stop = make(chan struct{})
+	sub.done = make(chan struct{})
+	sub.lock.Unlock()
+	go sub.run()
+	return nil
+}
+
+// WatchId returns the id of the current watch
+func (sub *Subscriber) WatchId() string {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	return sub.watchId
+}
+
+// Pushing returns true if changes are currently received from a push connection, rather than by polling
+func (sub *Subscriber) Pushing() bool {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	return sub.pushing
+}
+
+// Close stops receiving changes and closes the watch.
+func (sub *Subscriber) Close() error {
+	sub.lock.Lock()
+	if sub.stop == nil || sub.closed {
+		sub.lock.Unlock()
+		return nil
+	}
+	sub.closed = true
+	close(sub.stop)
+	done, conn := sub.done, sub.conn
+	sub.lock.Unlock()
+	if conn != nil {
+		conn.Close()
+	}
+	<-done
+	_, err := sub.watcher.WatchUnsub(sub.WatchId(), nil)
+	return err
+}
+
+// run receives changes until the Subscriber is closed
+func (sub *Subscriber) run() {
+	defer close(sub.done)
+	pushAvailable := sub.pushUri != ""
+	for !sub.stopped() {
+		var err error
+		if pushAvailable {
+			err = sub.receive()
+			if _, ok := err.(HTTPStatusError); ok {
+				pushAvailable = false
+			}
+		} else {
+			err = sub.poll()
+		}
+		if sub.stopped() {
+			return
+		}
+		if err == errWatchLost {
+			err = sub.resubscribe()
+		}
+		if _, ok := err.(client.CallError); ok {
+			// Retrying won't help with errors reported by the server
+			sub.reportError(err)
+			return
+		}
+		if err != nil {
+			sub.reportError(err)
+			if pushAvailable {
+				sub.wait(sub.retryDelay())
+			}
+		}
+	}
+}
+
+// poll waits for the poll interval and polls the watch
+func (sub *Subscriber) poll() error {
+	sub.lock.Lock()
+	interval := sub.pollInterval
+	sub.lock.Unlock()
+	if !sub.wait(interval) {
+		return nil
+	}
+	grid, err := sub.watcher.WatchPoll(sub.WatchId(), false)
+	if err != nil {
+		if callErr, ok := err.(client.CallError); ok && isWatchLost(callErr.Grid) {
+			return errWatchLost
+		}
+		return err
+	}
+	if grid.RowCount() > 0 {
+		sub.listener(grid)
+	}
+	return nil
+}
+
+// receive connects to the push server and delivers the grids it sends until the connection is lost
+func (sub *Subscriber) receive() error {
+	sub.lock.Lock()
+	query := url.Values{"watchId": {sub.watchId}, "format": {FormatZinc}}
+	uri := sub.pushUri
+	if strings.Contains(uri, "?") {
+		uri += "&" + query.Encode()
+	} else {
+		uri += "?" + query.Encode()
+	}
+	header := sub.header
+	transport := sub.transport
+	sub.lock.Unlock()
+
+	var conn messageReader
+	var err error
+	if transport == WebSocket {
+		conn, err = dialWebSocket(uri, header)
+	} else {
+		conn, err = dialSSE(uri, header)
+	}
+	if err != nil {
+		return err
+	}
+	if !sub.setConn(conn) {
+		conn.Close()
+		return nil
+	}
+	defer func() {
+		sub.setConn(nil)
+		conn.Close()
+	}()
+
+	for {
+		message, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		grid, err := decode(message)
+		if err != nil {
+			return err
+		}
+		if grid.Meta().Has("err") {
+			if isWatchLost(grid) {
+				return errWatchLost
+			}
+			return client.NewCallError(grid)
+		}
+		sub.listener(grid)
+	}
+}
+
+// resubscribe creates a new watch of the ids, and delivers their current records
+func (sub *Subscriber) resubscribe() error {
+	sub.lock.Lock()
+	dis, lease, ids := sub.dis, sub.lease, sub.ids
+	sub.lock.Unlock()
+
+	grid, err := sub.watcher.WatchSubCreate(dis, haystack.NewNumberFromDuration(lease), ids)
+	if err != nil {
+		return err
+	}
+	watchId, err := grid.Meta().GetStr("watchId")
+	if err != nil {
+		return err
+	}
+	sub.lock.Lock()
+	sub.watchId = watchId.String()
+	sub.lock.Unlock()
+	sub.listener(grid)
+	return nil
+}
+
+// setConn sets the open push connection, returning false if the Subscriber has been closed
+func (sub *Subscriber) setConn(conn messageReader) bool {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if conn == nil {
+		sub.conn = nil
+		sub.pushing = false
+		return true
+	}
+	select {
+	case <-sub.stop:
+		return false
+	default:
+	}
+	sub.conn = conn
+	sub.pushing = true
+	return true
+}
+
+func (sub *Subscriber) stopped() bool {
+	select {
+	case <-sub.stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait waits for the duration, returning false if the Subscriber is closed first
+func (sub *Subscriber) wait(duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-sub.stop:
+		return false
+	}
+}
+
+func (sub *Subscriber) retryDelay() time.Duration {
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	return sub.retry
+}
+
+func (sub *Subscriber) reportError(err error) {
+	sub.lock.Lock()
+	listeners := sub.errListeners
+	sub.lock.Unlock()
+	for _, listener := range listeners {
+		listener(err)
+	}
+}
+
+// messageReader is the client side of a push connection
+type messageReader interface {
+	ReadMessage() (string, error)
+	Close() error
+}
+
+// sseConn reads the grid events of a Server-Sent Events response
+type sseConn struct {
+	body    goio.ReadCloser
+	scanner *bufio.Scanner
+}
+
+// dialSSE makes a Server-Sent Events request. An HTTPStatusError is returned if the server doesn't respond with an
+// event stream.
+func dialSSE(uri string, header http.Header) (*sseConn, error) {
+	req, err := http.NewRequest("GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, vals := range header {
+		req.Header[name] = vals
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		resp.Body.Close()
+		return nil, NewHTTPStatusError(resp.StatusCode, resp.Status)
+	}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	return &sseConn{body: resp.Body, scanner: scanner}, nil
+}
+
+// ReadMessage returns the data of the next grid event
+func (conn *sseConn) ReadMessage() (string, error) {
+	event := ""
+	data := []string{}
+	for conn.scanner.Scan() {
+		line := conn.scanner.Text()
+		switch {
+		case line == "":
+			if event == "grid" && len(data) > 0 {
+				return strings.Join(data, "\n") + "\n", nil
+			}
+			event = ""
+			data = data[:0]
+		case strings.HasPrefix(line, ":"):
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := conn.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", goio.EOF
+}
+
+func (conn *sseConn) Close() error {
+	return conn.body.Close()
+}
+
+func decode(message string) (haystack.Grid, error) {
+	var reader io.ZincReader
+	reader.InitString(message)
+	val, err := reader.ReadVal()
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	grid, ok := val.(haystack.Grid)
+	if !ok {
+		return haystack.EmptyGrid(), errors.New("push message is not a grid")
+	}
+	return grid, nil
+}
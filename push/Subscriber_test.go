@@ -0,0 +1,173 @@
+package push
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/client"
+	"github.com/NeedleInAJayStack/haystack/server"
+	"github.com/stretchr/testify/assert"
+)
+
+// testWatcher calls the watch ops directly, returning errors like client.Client
+type testWatcher struct {
+	handler server.Handler
+	user    string
+}
+
+func (watcher *testWatcher) call(op string, gb *haystack.GridBuilder) (haystack.Grid, error) {
+	grid := watcher.handler.Handle(watcher.user, op, gb.ToGrid())
+	if server.IsErrGrid(grid) {
+		return haystack.EmptyGrid(), client.NewCallError(grid)
+	}
+	return grid, nil
+}
+
+func (watcher *testWatcher) WatchSubCreate(watchDis string, lease haystack.Number, ids []haystack.Ref) (haystack.Grid, error) {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchDis", haystack.NewStr(watchDis))
+	gb.AddMetaVal("lease", lease)
+	gb.AddColNoMeta("ids")
+	for _, id := range ids {
+		gb.AddRow([]haystack.Val{id})
+	}
+	return watcher.call("watchSub", gb)
+}
+
+func (watcher *testWatcher) WatchPoll(watchId string, refresh bool) (haystack.Grid, error) {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchId", haystack.NewStr(watchId))
+	gb.AddColNoMeta("empty")
+	return watcher.call("watchPoll", gb)
+}
+
+func (watcher *testWatcher) WatchUnsub(watchId string, ids []haystack.Ref) (haystack.Grid, error) {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchId", haystack.NewStr(watchId))
+	gb.AddMetaVal("close", haystack.NewMarker())
+	gb.AddColNoMeta("ids")
+	return watcher.call("watchUnsub", gb)
+}
+
+func newTestSubscriber(env *testEnv, pushUri string, transport Transport) (*Subscriber, chan haystack.Grid) {
+	sub := NewSubscriber(&testWatcher{handler: env.ops, user: "alice"}, pushUri, transport)
+	sub.SetHeader(http.Header{"Authorization": {"alice"}})
+	sub.SetPollInterval(20 * time.Millisecond)
+	sub.SetRetry(20 * time.Millisecond)
+	grids := make(chan haystack.Grid, 100)
+	return sub, grids
+}
+
+func nextGrid(t *testing.T, grids chan haystack.Grid) haystack.Grid {
+	select {
+	case grid := <-grids:
+		return grid
+	case <-time.After(5 * time.Second):
+		t.Fatal("no grid received")
+		return haystack.EmptyGrid()
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSubscriber_Push(t *testing.T) {
+	for _, transport := range []Transport{WebSocket, SSE} {
+		env := newTestEnv(t)
+		sub, grids := newTestSubscriber(env, env.http.URL+"/push", transport)
+		err := sub.Subscribe("test", time.Minute, []haystack.Ref{haystack.NewRef("a", "")}, func(grid haystack.Grid) {
+			grids <- grid
+		})
+		assert.Nil(t, err)
+		assert.NotNil(t, sub.Subscribe("again", 0, nil, nil))
+		assert.Equal(t, 1, nextGrid(t, grids).RowCount())
+		waitFor(t, sub.Pushing)
+		assert.Equal(t, 1, nextGrid(t, grids).RowCount()) // the refresh when connecting
+
+		env.change(t, "a", 10)
+		grid := nextGrid(t, grids)
+		assert.Equal(t, haystack.NewNumber(10, ""), grid.RowAt(0).Get("curVal"))
+
+		// A lost watch is subscribed again
+		watchId := sub.WatchId()
+		assert.Nil(t, env.manager.Unsub("alice", watchId, nil, true))
+		grid = nextGrid(t, grids)
+		assert.NotEqual(t, haystack.NewStr(watchId), grid.Meta().Get("watchId"))
+		assert.NotEqual(t, watchId, sub.WatchId())
+		waitFor(t, sub.Pushing)
+		env.change(t, "a", 11)
+		for {
+			grid = nextGrid(t, grids)
+			if grid.RowAt(0).Get("curVal") == haystack.NewNumber(11, "") {
+				break
+			}
+		}
+
+		assert.Nil(t, sub.Close())
+		assert.Empty(t, env.manager.WatchIds("alice"))
+		assert.Nil(t, sub.Close())
+	}
+}
+
+func TestSubscriber_Denied(t *testing.T) {
+	env := newTestEnv(t)
+	env.deny = true
+	sub, grids := newTestSubscriber(env, env.http.URL+"/push", SSE)
+	errs := make(chan error, 10)
+	sub.OnError(func(err error) {
+		errs <- err
+	})
+	err := sub.Subscribe("test", 0, []haystack.Ref{haystack.NewRef("a", "")}, func(grid haystack.Grid) {
+		grids <- grid
+	})
+	assert.Nil(t, err)
+	watchId := sub.WatchId()
+	assert.Equal(t, 1, nextGrid(t, grids).RowCount())
+
+	// A denial is reported, and the watch isn't subscribed again
+	select {
+	case err := <-errs:
+		callErr, ok := err.(client.CallError)
+		assert.True(t, ok)
+		assert.True(t, callErr.Grid.Meta().Has("denied"))
+	case <-time.After(5 * time.Second):
+		t.Fatal("no error reported")
+	}
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, watchId, sub.WatchId())
+	assert.Equal(t, []string{watchId}, env.manager.WatchIds("alice"))
+	assert.Empty(t, errs)
+	assert.Nil(t, sub.Close())
+}
+
+func TestSubscriber_Fallback(t *testing.T) {
+	env := newTestEnv(t)
+	sub, grids := newTestSubscriber(env, strings.Replace(env.http.URL, "http://", "ws://", 1)+"/missing", WebSocket)
+	errs := make(chan error, 10)
+	sub.OnError(func(err error) {
+		errs <- err
+	})
+	err := sub.Subscribe("test", 0, []haystack.Ref{haystack.NewRef("a", ""), haystack.NewRef("b", "")}, func(grid haystack.Grid) {
+		grids <- grid
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 2, nextGrid(t, grids).RowCount())
+	_, ok := (<-errs).(HTTPStatusError)
+	assert.True(t, ok)
+
+	env.change(t, "b", 20)
+	grid := nextGrid(t, grids)
+	assert.Equal(t, haystack.NewNumber(20, ""), grid.RowAt(0).Get("curVal"))
+	assert.False(t, sub.Pushing())
+	assert.Nil(t, sub.Close())
+}
@@ -0,0 +1,22 @@
+package push
+
+import "strconv"
+
+// HTTPStatusError occurs when a server responds to a push connection with an HTTP error, which means that it doesn't
+// offer push for the watch.
+type HTTPStatusError struct {
+	Code   int
+	Status string
+}
+
+// NewHTTPStatusError creates a new HTTPStatusError object.
+func NewHTTPStatusError(code int, status string) HTTPStatusError {
+	return HTTPStatusError{Code: code, Status: status}
+}
+
+func (err HTTPStatusError) Error() string {
+	if err.Status == "" {
+		return "Push not available: HTTP " + strconv.Itoa(err.Code)
+	}
+	return "Push not available: HTTP " + err.Status
+}
@@ -0,0 +1,297 @@
+package push
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// This is the subset of the WebSocket protocol (RFC 6455) that push needs: text messages of any size, ping/pong
+// and the closing handshake. Extensions and subprotocols are not supported.
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xA
+
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	maxMessageSize = 64 * 1024 * 1024
+)
+
+// wsConn is one end of a WebSocket connection
+type wsConn struct {
+	conn      net.Conn
+	reader    *bufio.Reader
+	writeLock sync.Mutex
+	isClient  bool // clients mask the frames they send
+}
+
+// isWebSocket returns true if the request asks to upgrade to a WebSocket
+func isWebSocket(r *http.Request) bool {
+	return headerHas(r.Header, "Connection", "upgrade") && headerHas(r.Header, "Upgrade", "websocket")
+}
+
+// upgrade completes the server side of the opening handshake
+func upgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != "GET" || key == "" || r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "Bad WebSocket handshake", http.StatusBadRequest)
+		return nil, errors.New("bad websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported", http.StatusInternalServerError)
+		return nil, errors.New("response can't be hijacked")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+	_, err = rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + acceptKey(key) + "\r\n\r\n")
+	if err == nil {
+		err = rw.Flush()
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, reader: rw.Reader}, nil
+}
+
+// dialWebSocket opens a client connection to a ws, wss, http or https URI. An HTTPStatusError is returned if the
+// server doesn't upgrade the connection.
+func dialWebSocket(uri string, header http.Header) (*wsConn, error) {
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	secure := parsed.Scheme == "wss" || parsed.Scheme == "https"
+	host := parsed.Host
+	if parsed.Port() == "" {
+		if secure {
+			host += ":443"
+		} else {
+			host += ":80"
+		}
+	}
+	var conn net.Conn
+	if secure {
+		conn, err = tls.Dial("tcp", host, &tls.Config{ServerName: parsed.Hostname()})
+	} else {
+		conn, err = net.Dial("tcp", host)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+	if secure {
+		parsed.Scheme = "https"
+	} else {
+		parsed.Scheme = "http"
+	}
+	req, err := http.NewRequest("GET", parsed.String(), nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	for name, vals := range header {
+		req.Header[name] = vals
+	}
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		resp.Body.Close()
+		conn.Close()
+		return nil, NewHTTPStatusError(resp.StatusCode, resp.Status)
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, errors.New("bad websocket handshake")
+	}
+	return &wsConn{conn: conn, reader: reader, isClient: true}, nil
+}
+
+// ReadMessage returns the next text message. Pings are answered while waiting. io.EOF is returned once the peer
+// closes the connection.
+func (ws *wsConn) ReadMessage() (string, error) {
+	message := []byte{}
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return "", err
+		}
+		switch opcode {
+		case opPing:
+			if err := ws.writeFrame(opPong, payload); err != nil {
+				return "", err
+			}
+		case opPong:
+		case opClose:
+			ws.writeFrame(opClose, payload)
+			return "", io.EOF
+		case opText, opContinuation:
+			message = append(message, payload...)
+			if len(message) > maxMessageSize {
+				return "", errors.New("websocket message is too large")
+			}
+			if fin {
+				return string(message), nil
+			}
+		default:
+			return "", errors.New("unsupported websocket frame")
+		}
+	}
+}
+
+// WriteMessage sends a text message
+func (ws *wsConn) WriteMessage(message string) error {
+	return ws.writeFrame(opText, []byte(message))
+}
+
+// Ping sends a ping, to keep the connection open through proxies
+func (ws *wsConn) Ping() error {
+	return ws.writeFrame(opPing, nil)
+}
+
+// Close sends a close frame and closes the connection
+func (ws *wsConn) Close() error {
+	ws.writeFrame(opClose, nil)
+	return ws.conn.Close()
+}
+
+func (ws *wsConn) readFrame() (bool, byte, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(ws.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+	fin := header[0]&0x80 != 0
+	opcode := header[0] & 0x0F
+	masked := header[1]&0x80 != 0
+	// Clients must mask every frame, and servers must not mask any (RFC 6455 5.1)
+	if masked == ws.isClient {
+		return false, 0, nil, errors.New("websocket frame masking is invalid")
+	}
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(ws.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(ws.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, errors.New("websocket frame is too large")
+	}
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(ws.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(ws.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		if masked {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+func (ws *wsConn) writeFrame(opcode byte, payload []byte) error {
+	ws.writeLock.Lock()
+	defer ws.writeLock.Unlock()
+
+	frame := []byte{0x80 | opcode}
+	maskBit := byte(0)
+	if ws.isClient {
+		maskBit = 0x80
+	}
+	length := len(payload)
+	switch {
+	case length < 126:
+		frame = append(frame, maskBit|byte(length))
+	case length <= 0xFFFF:
+		frame = append(frame, maskBit|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(length))
+	default:
+		frame = append(frame, maskBit|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(length))
+	}
+	if ws.isClient {
+		mask := make([]byte, 4)
+		if _, err := rand.Read(mask); err != nil {
+			return err
+		}
+		frame = append(frame, mask...)
+		masked := make([]byte, length)
+		for i := range payload {
+			masked[i] = payload[i] ^ mask[i%4]
+		}
+		payload = masked
+	}
+	_, err := ws.conn.Write(append(frame, payload...))
+	return err
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// headerHas returns true if the comma separated header contains the token, ignoring case
+func headerHas(header http.Header, name string, token string) bool {
+	for _, val := range header[http.CanonicalHeaderKey(name)] {
+		for _, part := range strings.Split(val, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
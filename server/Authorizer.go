@@ -0,0 +1,362 @@
+package server
+
+import (
+	"strconv"
+	"sync"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/filter"
+	"github.com/NeedleInAJayStack/haystack/point"
+)
+
+// AllOps may be used in Role.Ops to allow every op
+const AllOps = "*"
+
+// Role is a set of permissions that can be granted to users. A user may call an op if one of their roles allows it,
+// and may access a record through that op if one of those roles also includes the record.
+type Role struct {
+	Name string
+	// Filter selects the records the role can access, like `siteRef==@hq`. A nil Filter includes every record.
+	Filter filter.Filter
+	// Ops are the ops the role may call, or AllOps
+	Ops []string
+	// MinLevel and MaxLevel are the range of priority levels the role may write with 'pointWrite', like 8 to 16 for
+	// operators. If both are 0, every level may be written.
+	MinLevel int
+	MaxLevel int
+}
+
+// NewRole creates a Role that can call the ops on the records that match the filter string. An empty filter string
+// includes every record.
+func NewRole(name string, filterStr string, ops ...string) (Role, error) {
+	role := Role{Name: name, Ops: ops}
+	if filterStr != "" {
+		parsed, err := filter.Parse(filterStr)
+		if err != nil {
+			return Role{}, err
+		}
+		role.Filter = parsed
+	}
+	return role, nil
+}
+
+// WithLevels returns a copy of the role limited to writing the priority levels from min to max, inclusive.
+func (role Role) WithLevels(min int, max int) Role {
+	role.MinLevel = min
+	role.MaxLevel = max
+	return role
+}
+
+func (role Role) allowsOp(op string) bool {
+	for _, allowed := range role.Ops {
+		if allowed == op || allowed == AllOps {
+			return true
+		}
+	}
+	return false
+}
+
+func (role Role) allowsLevel(level int) bool {
+	if role.MinLevel == 0 && role.MaxLevel == 0 {
+		return true
+	}
+	return level >= role.MinLevel && level <= role.MaxLevel
+}
+
+// Authorizer is a Handler that applies the roles of each user before passing requests to the next Handler:
+//
+//   - Ops that none of the user's roles allow are denied.
+//   - The results of 'read', 'nav', 'watchSub' and 'watchPoll' only contain the records the user may access. Records
+//     read by id are replaced by empty rows, like unknown ids, so that the rows still line up with the ids.
+//   - 'hisRead', 'hisWrite', 'pointWrite' and 'invokeAction' are denied unless the user may access their target
+//     records, and 'pointWrite' writes are denied unless the level is allowed.
+//   - 'commit' is denied unless the user may access every record it updates or removes, and the records that result
+//     from its adds and updates.
+//   - Other ops are denied unless the user may access every `id` Ref in the request meta and rows.
+//
+// Denials are returned as err grids with a `reason`, and are reported to the OnDeny listeners. Users without roles
+// are denied every op.
+//
+// An Authorizer is safe for concurrent use.
+type Authorizer struct {
+	lock      sync.RWMutex
+	next      Handler
+	ctx       filter.Context
+	roles     map[string][]Role
+	listeners []func(DeniedError)
+}
+
+// NewAuthorizer creates an Authorizer in front of the next Handler. The resolver looks up the target records of ops,
+// and the Refs in role filter paths. It is typically the server's store.MemStore.
+func NewAuthorizer(next Handler, resolver haystack.RefResolver) *Authorizer {
+	return &Authorizer{
+		next:  next,
+		ctx:   filter.Context{Resolver: resolver},
+		roles: map[string][]Role{},
+	}
+}
+
+// SetNamespace sets the defs used to match symbol terms in role filters, like `^ahu`.
+func (authorizer *Authorizer) SetNamespace(ns filter.Reasoner) {
+	authorizer.lock.Lock()
+	defer authorizer.lock.Unlock()
+	authorizer.ctx.Namespace = ns
+}
+
+// SetRoles sets the roles of the user, replacing any previous roles. Calling it without roles revokes all access.
+func (authorizer *Authorizer) SetRoles(user string, roles ...Role) {
+	authorizer.lock.Lock()
+	defer authorizer.lock.Unlock()
+	if len(roles) == 0 {
+		delete(authorizer.roles, user)
+		return
+	}
+	authorizer.roles[user] = append([]Role{}, roles...)
+}
+
+// OnDeny adds a listener that is called with each denial, for auditing.
+func (authorizer *Authorizer) OnDeny(listener func(DeniedError)) {
+	authorizer.lock.Lock()
+	defer authorizer.lock.Unlock()
+	authorizer.listeners = append(authorizer.listeners, listener)
+}
+
+// CheckOp returns a DeniedError if the user may not call the op.
+func (authorizer *Authorizer) CheckOp(user string, op string) error {
+	if len(authorizer.opRoles(user, op)) == 0 {
+		return NewDeniedError(user, op, haystack.Ref{}, "op not permitted")
+	}
+	return nil
+}
+
+// CheckRec returns a DeniedError if the user may not access the record through the op.
+func (authorizer *Authorizer) CheckRec(user string, op string, rec haystack.Dict) error {
+	roles := authorizer.opRoles(user, op)
+	id, _ := rec.GetRef("id")
+	if len(roles) == 0 {
+		return NewDeniedError(user, op, haystack.Ref{}, "op not permitted")
+	}
+	if len(authorizer.recRoles(roles, rec)) == 0 {
+		return NewDeniedError(user, op, id, "rec not permitted")
+	}
+	return nil
+}
+
+// CheckWrite returns a DeniedError if the user may not write the point record at the priority level.
+func (authorizer *Authorizer) CheckWrite(user string, rec haystack.Dict, level int) error {
+	const op = "pointWrite"
+	if err := authorizer.CheckRec(user, op, rec); err != nil {
+		return err
+	}
+	for _, role := range authorizer.recRoles(authorizer.opRoles(user, op), rec) {
+		if role.allowsLevel(level) {
+			return nil
+		}
+	}
+	id, _ := rec.GetRef("id")
+	return NewDeniedError(user, op, id, "level "+strconv.Itoa(level)+" not permitted")
+}
+
+// Handle checks the request, calls the next Handler if it is permitted, and filters the result.
+func (authorizer *Authorizer) Handle(user string, op string, req haystack.Grid) haystack.Grid {
+	if err := authorizer.checkReq(user, op, req); err != nil {
+		authorizer.deny(err)
+		return ErrGrid(err)
+	}
+	resp := authorizer.next.Handle(user, op, req)
+	switch op {
+	case "read", "nav", "watchSub", "watchPoll":
+		if !IsErrGrid(resp) {
+			// Rows of reads by id and subscribes line up with the requested ids
+			byId := op == "watchSub" || (op == "read" && req.Col("id") != nil)
+			resp = authorizer.filterRecs(user, op, resp, byId)
+		}
+	}
+	return resp
+}
+
+// checkReq checks the op, and the targets of the ops that have them
+func (authorizer *Authorizer) checkReq(user string, op string, req haystack.Grid) error {
+	if err := authorizer.CheckOp(user, op); err != nil {
+		return err
+	}
+	switch op {
+	case "hisRead":
+		for _, row := range req.Rows() {
+			if _, err := authorizer.checkTarget(user, op, row.Get("id")); err != nil {
+				return err
+			}
+		}
+	case "pointWrite":
+		isWrite := req.Col("level") != nil
+		for _, row := range req.Rows() {
+			rec, err := authorizer.checkTarget(user, op, row.Get("id"))
+			if err != nil {
+				return err
+			}
+			if !isWrite {
+				continue
+			}
+			level := point.LevelDefault
+			if number, ok := row.Get("level").(haystack.Number); ok {
+				level = int(number.Float())
+			}
+			if err := authorizer.CheckWrite(user, rec, level); err != nil {
+				return err
+			}
+		}
+	case "hisWrite", "invokeAction":
+		_, err := authorizer.checkTarget(user, op, req.Meta().Get("id"))
+		return err
+	case "commit":
+		return authorizer.checkCommit(user, req)
+	case "about", "ops", "formats", "close", "defs", "libs", "filetypes",
+		"read", "nav", "watchSub", "watchUnsub", "watchPoll":
+		// These have no targets, or their results are filtered
+	default:
+		if id, ok := req.Meta().Get("id").(haystack.Ref); ok {
+			if _, err := authorizer.checkTarget(user, op, id); err != nil {
+				return err
+			}
+		}
+		if req.Col("id") != nil {
+			for _, row := range req.Rows() {
+				if id, ok := row.Get("id").(haystack.Ref); ok {
+					if _, err := authorizer.checkTarget(user, op, id); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// checkCommit checks the records that a commit changes, before and after the change, so that records can't be
+// changed or moved outside of the user's roles
+func (authorizer *Authorizer) checkCommit(user string, req haystack.Grid) error {
+	const op = "commit"
+	mode, _ := req.Meta().GetStr("commit")
+	authorizer.lock.RLock()
+	resolver := authorizer.ctx.Resolver
+	authorizer.lock.RUnlock()
+	for _, row := range req.Rows() {
+		current := haystack.EmptyDict()
+		if mode.String() == "add" {
+			if id, ok := row.Get("id").(haystack.Ref); ok {
+				if rec, err := resolver.Resolve(id); err == nil {
+					if err := authorizer.CheckRec(user, op, rec); err != nil {
+						return err
+					}
+				}
+			}
+		} else {
+			rec, err := authorizer.checkTarget(user, op, row.Get("id"))
+			if err != nil {
+				return err
+			}
+			current = rec
+		}
+		if mode.String() == "remove" {
+			continue
+		}
+
+		// Empty cells leave tags unchanged
+		changes := map[string]haystack.Val{}
+		dict := row.ToDict()
+		for _, name := range dict.Names() {
+			if _, isNull := dict.Get(name).(haystack.Null); !isNull {
+				changes[name] = dict.Get(name)
+			}
+		}
+		if err := authorizer.CheckRec(user, op, current.Patch(haystack.NewDict(changes))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkTarget resolves the target of the op and checks that the user may access it
+func (authorizer *Authorizer) checkTarget(user string, op string, val haystack.Val) (haystack.Dict, error) {
+	id, ok := val.(haystack.Ref)
+	if !ok {
+		return haystack.EmptyDict(), NewDeniedError(user, op, haystack.Ref{}, "missing target id")
+	}
+	authorizer.lock.RLock()
+	resolver := authorizer.ctx.Resolver
+	authorizer.lock.RUnlock()
+	rec, err := resolver.Resolve(id)
+	if err != nil {
+		// Unknown recs are denied like inaccessible ones, so that denials don't reveal which ids exist
+		return haystack.EmptyDict(), NewDeniedError(user, op, id, "rec not permitted")
+	}
+	return rec, authorizer.CheckRec(user, op, rec)
+}
+
+// filterRecs removes the rows of records the user may not access. Empty rows, which stand for unknown ids, are kept.
+// If the rows are by id, the rows of records the user may not access are emptied instead, so that they look unknown.
+func (authorizer *Authorizer) filterRecs(user string, op string, grid haystack.Grid, byId bool) haystack.Grid {
+	roles := authorizer.opRoles(user, op)
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaDict(grid.Meta())
+	for _, col := range grid.Cols() {
+		gb.AddColDict(col.Name(), col.Meta())
+	}
+	for _, row := range grid.Rows() {
+		rec := haystack.EmptyDict().Patch(row.ToDict())
+		denied := !rec.IsEmpty() && len(authorizer.recRoles(roles, rec)) == 0
+		if denied && !byId {
+			continue
+		}
+		vals := make([]haystack.Val, 0, grid.ColCount())
+		for _, col := range grid.Cols() {
+			if denied {
+				vals = append(vals, haystack.NewNull())
+			} else {
+				vals = append(vals, row.Get(col.Name()))
+			}
+		}
+		gb.AddRow(vals)
+	}
+	return gb.ToGrid()
+}
+
+// opRoles returns the user's roles that allow the op
+func (authorizer *Authorizer) opRoles(user string, op string) []Role {
+	authorizer.lock.RLock()
+	defer authorizer.lock.RUnlock()
+	roles := []Role{}
+	for _, role := range authorizer.roles[user] {
+		if role.allowsOp(op) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// recRoles returns the roles that include the record
+func (authorizer *Authorizer) recRoles(roles []Role, rec haystack.Dict) []Role {
+	authorizer.lock.RLock()
+	ctx := authorizer.ctx
+	authorizer.lock.RUnlock()
+	matched := []Role{}
+	for _, role := range roles {
+		if role.Filter == nil || role.Filter.Include(rec, ctx) {
+			matched = append(matched, role)
+		}
+	}
+	return matched
+}
+
+func (authorizer *Authorizer) deny(err error) {
+	denied, ok := err.(DeniedError)
+	if !ok {
+		return
+	}
+	authorizer.lock.RLock()
+	listeners := authorizer.listeners
+	authorizer.lock.RUnlock()
+	for _, listener := range listeners {
+		listener(denied)
+	}
+}
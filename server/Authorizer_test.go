@@ -0,0 +1,248 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/store"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRecs = `ver:"3.0"
+id,dis,site,equip,point,writable,siteRef,equipRef
+@hq,"HQ",M,,,,,
+@annex,"Annex",M,,,,,
+@ahu1,"AHU-1",,M,,,@hq,
+@ahu2,"AHU-2",,M,,,@annex,
+@sp1,"SP-1",,,M,M,@hq,@ahu1
+@sp2,"SP-2",,,M,M,@annex,@ahu2
+`
+
+func testAuthorizer(t *testing.T) (*Authorizer, *[]string) {
+	recs := store.NewMemStore()
+	assert.Nil(t, recs.LoadZinc(testRecs))
+	called := []string{}
+	next := HandlerFunc(func(user string, op string, req haystack.Grid) haystack.Grid {
+		called = append(called, op)
+		if op == "read" && req.Col("id") != nil {
+			ids := []haystack.Ref{}
+			for _, row := range req.Rows() {
+				ids = append(ids, row.Get("id").(haystack.Ref))
+			}
+			return recs.ReadByIds(ids)
+		}
+		if op == "read" {
+			grid, err := recs.Read(req.RowAt(0).Get("filter").(haystack.Str).String())
+			if err != nil {
+				return ErrGrid(err)
+			}
+			return grid
+		}
+		return haystack.EmptyGrid()
+	})
+	authorizer := NewAuthorizer(next, recs)
+
+	viewer, err := NewRole("hqViewer", "siteRef==@hq or id==@hq", "read", "hisRead")
+	assert.Nil(t, err)
+	operator, err := NewRole("operator", "point and writable", "pointWrite", "invokeAction")
+	assert.Nil(t, err)
+	admin, err := NewRole("admin", "", AllOps)
+	assert.Nil(t, err)
+	authorizer.SetRoles("viewer", viewer)
+	authorizer.SetRoles("operator", viewer, operator.WithLevels(8, 16))
+	editor, err := NewRole("hqEditor", "siteRef==@hq", "commit", "invokeAction")
+	assert.Nil(t, err)
+	authorizer.SetRoles("admin", admin)
+	authorizer.SetRoles("editor", editor)
+	return authorizer, &called
+}
+
+func readReq(filterStr string) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("filter")
+	gb.AddRow([]haystack.Val{haystack.NewStr(filterStr)})
+	return gb.ToGrid()
+}
+
+func pointWriteReq(id string, level int) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("level")
+	gb.AddColNoMeta("val")
+	gb.AddRow([]haystack.Val{haystack.NewRef(id, ""), haystack.NewNumber(float64(level), ""), haystack.NewNumber(72, "°F")})
+	return gb.ToGrid()
+}
+
+func rowIds(grid haystack.Grid) []string {
+	ids := []string{}
+	for _, row := range grid.Rows() {
+		ids = append(ids, row.Get("id").(haystack.Ref).Id())
+	}
+	return ids
+}
+
+func TestAuthorizer_FilterRead(t *testing.T) {
+	authorizer, _ := testAuthorizer(t)
+
+	grid := authorizer.Handle("viewer", "read", readReq("equip or site"))
+	assert.False(t, IsErrGrid(grid))
+	assert.ElementsMatch(t, []string{"hq", "ahu1"}, rowIds(grid))
+
+	grid = authorizer.Handle("admin", "read", readReq("equip or site"))
+	assert.ElementsMatch(t, []string{"hq", "annex", "ahu1", "ahu2"}, rowIds(grid))
+
+	grid = authorizer.Handle("viewer", "read", readReq("not a filter ("))
+	assert.True(t, IsErrGrid(grid))
+	assert.False(t, grid.Meta().Has("denied"))
+}
+
+func TestAuthorizer_ReadById(t *testing.T) {
+	authorizer, _ := testAuthorizer(t)
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	for _, id := range []string{"ahu2", "ahu1", "missing", "sp2"} {
+		gb.AddRow([]haystack.Val{haystack.NewRef(id, "")})
+	}
+	grid := authorizer.Handle("viewer", "read", gb.ToGrid())
+	assert.False(t, IsErrGrid(grid))
+	assert.Equal(t, 4, grid.RowCount())
+	assert.Equal(t, haystack.NewRef("ahu1", ""), grid.RowAt(1).Get("id"))
+	// Inaccessible records look like unknown ones
+	for _, i := range []int{0, 2, 3} {
+		assert.True(t, haystack.EmptyDict().Patch(grid.RowAt(i).ToDict()).IsEmpty())
+	}
+}
+
+func commitReq(mode string, rows ...haystack.Dict) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("commit", haystack.NewStr(mode))
+	names := map[string]bool{}
+	for _, row := range rows {
+		for _, name := range row.Names() {
+			if !names[name] {
+				names[name] = true
+				gb.AddColNoMeta(name)
+			}
+		}
+	}
+	gb.AddRowDicts(rows)
+	return gb.ToGrid()
+}
+
+func TestAuthorizer_Commit(t *testing.T) {
+	authorizer, called := testAuthorizer(t)
+	hq := haystack.NewRef("hq", "")
+	annex := haystack.NewRef("annex", "")
+	rec := func(id string, siteRef haystack.Ref) haystack.Dict {
+		return haystack.NewDict(map[string]haystack.Val{"id": haystack.NewRef(id, ""), "equip": haystack.NewMarker(), "siteRef": siteRef})
+	}
+	update := func(id string, dis string) haystack.Dict {
+		return haystack.NewDict(map[string]haystack.Val{"id": haystack.NewRef(id, ""), "dis": haystack.NewStr(dis)})
+	}
+
+	assert.False(t, IsErrGrid(authorizer.Handle("editor", "commit", commitReq("add", rec("ahu3", hq)))))
+	assert.False(t, IsErrGrid(authorizer.Handle("editor", "commit", commitReq("update", update("ahu1", "AHU")))))
+	assert.False(t, IsErrGrid(authorizer.Handle("editor", "commit", commitReq("remove", update("ahu1", "")))))
+	assert.Equal(t, []string{"commit", "commit", "commit"}, *called)
+
+	// Records of other sites can't be added, edited, removed, or moved into
+	for _, req := range []haystack.Grid{
+		commitReq("add", rec("ahu3", annex)),
+		commitReq("add", rec("ahu2", hq)),
+		commitReq("update", update("ahu1", "AHU"), update("ahu2", "AHU")),
+		commitReq("update", rec("ahu2", hq)),
+		commitReq("update", rec("ahu1", annex)),
+		commitReq("update", update("missing", "Missing")),
+		commitReq("remove", update("ahu2", "")),
+	} {
+		grid := authorizer.Handle("editor", "commit", req)
+		assert.Equal(t, haystack.NewStr("rec not permitted"), grid.Meta().Get("reason"), req.ToZinc())
+	}
+	assert.Equal(t, 3, len(*called))
+
+	// Other ops are checked against the ids they target
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddRow([]haystack.Val{haystack.NewRef("sp2", "")})
+	authorizer.SetRoles("custom", Role{Name: "custom", Filter: authorizer.roles["editor"][0].Filter, Ops: []string{"myOp"}})
+	grid := authorizer.Handle("custom", "myOp", gb.ToGrid())
+	assert.Equal(t, haystack.NewStr("rec not permitted"), grid.Meta().Get("reason"))
+}
+
+func TestAuthorizer_DenyOp(t *testing.T) {
+	authorizer, called := testAuthorizer(t)
+	denials := []DeniedError{}
+	authorizer.OnDeny(func(err DeniedError) {
+		denials = append(denials, err)
+	})
+
+	grid := authorizer.Handle("viewer", "pointWrite", pointWriteReq("sp1", 8))
+	assert.True(t, grid.Meta().Has("denied"))
+	grid = authorizer.Handle("nobody", "read", readReq("site"))
+	assert.True(t, grid.Meta().Has("denied"))
+
+	assert.Empty(t, *called)
+	assert.Equal(t, []DeniedError{
+		NewDeniedError("viewer", "pointWrite", haystack.Ref{}, "op not permitted"),
+		NewDeniedError("nobody", "read", haystack.Ref{}, "op not permitted"),
+	}, denials)
+}
+
+func TestAuthorizer_PointWrite(t *testing.T) {
+	authorizer, called := testAuthorizer(t)
+
+	grid := authorizer.Handle("operator", "pointWrite", pointWriteReq("sp1", 8))
+	assert.False(t, IsErrGrid(grid))
+	grid = authorizer.Handle("operator", "pointWrite", pointWriteReq("sp2", 8))
+	assert.False(t, IsErrGrid(grid))
+	assert.Equal(t, []string{"pointWrite", "pointWrite"}, *called)
+
+	grid = authorizer.Handle("operator", "pointWrite", pointWriteReq("sp1", 1))
+	assert.Equal(t, haystack.NewStr("level 1 not permitted"), grid.Meta().Get("reason"))
+	grid = authorizer.Handle("operator", "pointWrite", pointWriteReq("ahu1", 8))
+	assert.Equal(t, haystack.NewStr("rec not permitted"), grid.Meta().Get("reason"))
+	grid = authorizer.Handle("operator", "pointWrite", pointWriteReq("missing", 8))
+	assert.Equal(t, haystack.NewStr("rec not permitted"), grid.Meta().Get("reason"))
+	grid = authorizer.Handle("admin", "pointWrite", pointWriteReq("sp1", 1))
+	assert.False(t, IsErrGrid(grid))
+
+	// Reading the priority array has no level
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddRow([]haystack.Val{haystack.NewRef("sp2", "")})
+	grid = authorizer.Handle("operator", "pointWrite", gb.ToGrid())
+	assert.False(t, IsErrGrid(grid))
+}
+
+func TestAuthorizer_Targets(t *testing.T) {
+	authorizer, _ := testAuthorizer(t)
+
+	gb := haystack.NewGridBuilder()
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("range")
+	gb.AddRow([]haystack.Val{haystack.NewRef("sp1", ""), haystack.NewStr("today")})
+	assert.False(t, IsErrGrid(authorizer.Handle("viewer", "hisRead", gb.ToGrid())))
+	gb.AddRow([]haystack.Val{haystack.NewRef("sp2", ""), haystack.NewStr("today")})
+	assert.True(t, IsErrGrid(authorizer.Handle("viewer", "hisRead", gb.ToGrid())))
+
+	gb = haystack.NewGridBuilder()
+	gb.AddMetaVal("id", haystack.NewRef("sp2", ""))
+	gb.AddMetaVal("action", haystack.NewStr("reset"))
+	gb.AddColNoMeta("empty")
+	assert.False(t, IsErrGrid(authorizer.Handle("operator", "invokeAction", gb.ToGrid())))
+	assert.True(t, IsErrGrid(authorizer.Handle("viewer", "invokeAction", gb.ToGrid())))
+
+	gb = haystack.NewGridBuilder()
+	gb.AddColNoMeta("ts")
+	gb.AddColNoMeta("val")
+	grid := authorizer.Handle("admin", "hisWrite", gb.ToGrid())
+	assert.Equal(t, haystack.NewStr("missing target id"), grid.Meta().Get("reason"))
+}
+
+func TestAuthorizer_SetRoles(t *testing.T) {
+	authorizer, _ := testAuthorizer(t)
+	assert.Nil(t, authorizer.CheckOp("viewer", "read"))
+	authorizer.SetRoles("viewer")
+	assert.NotNil(t, authorizer.CheckOp("viewer", "read"))
+}
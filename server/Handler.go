@@ -0,0 +1,68 @@
+package server
+
+import (
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Handler answers Haystack ops on behalf of a user. Errors are returned as err grids, as they are to clients, so
+// Handlers can be layered to add authorization, auditing and routing.
+type Handler interface {
+	Handle(user string, op string, req haystack.Grid) haystack.Grid
+}
+
+// HandlerFunc adapts a function to the Handler interface.
+type HandlerFunc func(user string, op string, req haystack.Grid) haystack.Grid
+
+// Handle calls the function
+func (f HandlerFunc) Handle(user string, op string, req haystack.Grid) haystack.Grid {
+	return f(user, op, req)
+}
+
+// OpFunc implements a single op. It has the signature of watch.Manager's HandleSub, HandleUnsub and HandlePoll.
+type OpFunc func(user string, req haystack.Grid) (haystack.Grid, error)
+
+// Ops is a Handler that routes each op by name, like:
+//
+//	server.Ops{
+//		"read":     readOp,
+//		"watchSub": manager.HandleSub,
+//	}
+//
+// Unknown ops and errors are returned as err grids.
+type Ops map[string]OpFunc
+
+// Handle calls the function of the op
+func (ops Ops) Handle(user string, op string, req haystack.Grid) haystack.Grid {
+	opFunc, ok := ops[op]
+	if !ok {
+		return ErrGrid(NewUnknownOpError(op))
+	}
+	grid, err := opFunc(user, req)
+	if err != nil {
+		return ErrGrid(err)
+	}
+	return grid
+}
+
+// ErrGrid creates an err grid describing the error. Its meta has the `err` marker and a `dis` message. Denials also
+// have the `denied` marker and their `reason`, and errors with an ErrType method, like watch.UnknownWatchError, have
+// an `errType` Str.
+func ErrGrid(err error) haystack.Grid {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("err", haystack.NewMarker())
+	gb.AddMetaVal("dis", haystack.NewStr(err.Error()))
+	if typed, ok := err.(interface{ ErrType() string }); ok {
+		gb.AddMetaVal("errType", haystack.NewStr(typed.ErrType()))
+	}
+	if denied, ok := err.(DeniedError); ok {
+		gb.AddMetaVal("denied", haystack.NewMarker())
+		gb.AddMetaVal("reason", haystack.NewStr(denied.Reason))
+	}
+	gb.AddColNoMeta("empty")
+	return gb.ToGrid()
+}
+
+// IsErrGrid returns true if the grid is an err grid
+func IsErrGrid(grid haystack.Grid) bool {
+	return grid.Meta().Has("err")
+}
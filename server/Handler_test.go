@@ -0,0 +1,62 @@
+package server
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOps_Handle(t *testing.T) {
+	ops := Ops{
+		"about": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			return haystack.NewGridFromDicts([]haystack.Dict{
+				haystack.NewDict(map[string]haystack.Val{"user": haystack.NewStr(user)}),
+			}), nil
+		},
+		"fail": func(user string, req haystack.Grid) (haystack.Grid, error) {
+			return haystack.EmptyGrid(), errors.New("failed")
+		},
+	}
+
+	grid := ops.Handle("alice", "about", haystack.EmptyGrid())
+	assert.False(t, IsErrGrid(grid))
+	assert.Equal(t, haystack.NewStr("alice"), grid.RowAt(0).Get("user"))
+
+	grid = ops.Handle("alice", "fail", haystack.EmptyGrid())
+	assert.True(t, IsErrGrid(grid))
+	assert.Equal(t, haystack.NewStr("failed"), grid.Meta().Get("dis"))
+
+	grid = ops.Handle("alice", "eval", haystack.EmptyGrid())
+	assert.True(t, IsErrGrid(grid))
+	assert.Equal(t, haystack.NewStr("Unknown op: eval"), grid.Meta().Get("dis"))
+}
+
+func TestErrGrid_Denied(t *testing.T) {
+	grid := ErrGrid(NewDeniedError("bob", "pointWrite", haystack.NewRef("p1", ""), "level 1 not permitted"))
+	assert.True(t, IsErrGrid(grid))
+	assert.True(t, grid.Meta().Has("denied"))
+	assert.Equal(t, haystack.NewStr("level 1 not permitted"), grid.Meta().Get("reason"))
+	assert.Equal(
+		t,
+		haystack.NewStr("Access denied: bob may not call pointWrite on @p1: level 1 not permitted"),
+		grid.Meta().Get("dis"),
+	)
+}
+
+type typedError struct{}
+
+func (typedError) Error() string {
+	return "typed"
+}
+
+func (typedError) ErrType() string {
+	return "TypedErr"
+}
+
+func TestErrGrid_ErrType(t *testing.T) {
+	grid := ErrGrid(typedError{})
+	assert.Equal(t, haystack.NewStr("TypedErr"), grid.Meta().Get("errType"))
+	assert.False(t, ErrGrid(NewUnknownOpError("x")).Meta().Has("errType"))
+}
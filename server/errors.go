@@ -0,0 +1,39 @@
+package server
+
+import "github.com/NeedleInAJayStack/haystack"
+
+// UnknownOpError occurs when a Handler doesn't implement the requested op.
+type UnknownOpError struct {
+	Op string
+}
+
+// NewUnknownOpError creates a new UnknownOpError object.
+func NewUnknownOpError(op string) UnknownOpError {
+	return UnknownOpError{Op: op}
+}
+
+func (err UnknownOpError) Error() string {
+	return "Unknown op: " + err.Op
+}
+
+// DeniedError occurs when a user isn't authorized to call an op, or to access its target. Id is the zero Ref if the
+// op itself is denied.
+type DeniedError struct {
+	User   string
+	Op     string
+	Id     haystack.Ref
+	Reason string
+}
+
+// NewDeniedError creates a new DeniedError object.
+func NewDeniedError(user string, op string, id haystack.Ref, reason string) DeniedError {
+	return DeniedError{User: user, Op: op, Id: id, Reason: reason}
+}
+
+func (err DeniedError) Error() string {
+	msg := "Access denied: " + err.User + " may not call " + err.Op
+	if err.Id.Id() != "" {
+		msg += " on @" + err.Id.Id()
+	}
+	return msg + ": " + err.Reason
+}
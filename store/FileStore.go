@@ -0,0 +1,573 @@
+package store
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	goio "io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/filter"
+	"github.com/NeedleInAJayStack/haystack/io"
+)
+
+const (
+	snapshotFile = "snapshot.zinc"
+	logFile      = "commits.log"
+
+	// DefaultCompactAfter is the default number of commits in the log that triggers a compaction
+	DefaultCompactAfter = 1000
+)
+
+// DiffOp is the kind of change that a Diff makes to a record
+type DiffOp string
+
+// Diff operations
+const (
+	DiffAdd    DiffOp = "add"
+	DiffUpdate DiffOp = "update"
+	DiffRemove DiffOp = "remove"
+)
+
+// Diff is a change to a single record in a commit.
+type Diff struct {
+	Op DiffOp
+	Id haystack.Ref
+	// Mod is the `mod` of the record that the change was based on. Updates and removes are rejected if the record
+	// has been changed since.
+	Mod haystack.Val
+	// Changes are the tags of a new record, or the tags to set on an existing one. Tags with a Remove or Null value are
+	// removed. See haystack.Dict.Patch.
+	Changes haystack.Dict
+}
+
+// NewAddDiff creates a Diff that adds a new record. The record must have an `id` Ref.
+func NewAddDiff(rec haystack.Dict) Diff {
+	id, _ := rec.GetRef("id")
+	return Diff{Op: DiffAdd, Id: id, Mod: haystack.NewNull(), Changes: rec}
+}
+
+// NewUpdateDiff creates a Diff that applies the changes to a record. The record is the version that the changes were
+// based on, as read from the store.
+func NewUpdateDiff(rec haystack.Dict, changes haystack.Dict) Diff {
+	id, _ := rec.GetRef("id")
+	return Diff{Op: DiffUpdate, Id: id, Mod: rec.Get("mod"), Changes: changes}
+}
+
+// NewRemoveDiff creates a Diff that removes a record. The record is the version that was read from the store.
+func NewRemoveDiff(rec haystack.Dict) Diff {
+	id, _ := rec.GetRef("id")
+	return Diff{Op: DiffRemove, Id: id, Mod: rec.Get("mod"), Changes: haystack.EmptyDict()}
+}
+
+// FileStore is a persistent record store kept in a directory. Records are held in memory for queries, like a
+// MemStore, and each commit is validated, appended to a log file and synced to disk before it is applied. When the
+// log grows, it is compacted into a snapshot of every record. If the process stops while writing, the partial commit
+// at the end of the log is discarded when the store is next opened.
+//
+// Every record is given a `mod` DateTime tag when it is added or updated. A FileStore is safe for concurrent use.
+type FileStore struct {
+	lock         sync.Mutex // held by writers
+	dir          string
+	mem          *MemStore
+	log          *os.File
+	seq          int64 // sequence number of the last commit
+	logCommits   int   // commits in the log since the last snapshot
+	compactAfter int
+	lastMod      time.Time
+}
+
+// OpenFileStore opens the store in the directory, creating it if it doesn't exist. The snapshot is loaded and the
+// log is replayed. A partial or corrupt commit at the end of the log is truncated, but an error is returned if a
+// commit before the end is corrupt, so that the commits after it aren't lost.
+func OpenFileStore(dir string) (*FileStore, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	store := &FileStore{dir: dir, mem: NewMemStore(), compactAfter: DefaultCompactAfter}
+	err = store.loadSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	err = store.replayLog()
+	if err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// Close closes the log file. The store must not be used afterwards.
+func (store *FileStore) Close() error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return store.log.Close()
+}
+
+// SetCompactAfter sets the number of commits in the log that triggers a compaction. A value of 0 or less disables
+// automatic compaction.
+func (store *FileStore) SetCompactAfter(commits int) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.compactAfter = commits
+}
+
+// SetNamespace sets the defs used to match symbol terms in filters. See MemStore.SetNamespace.
+func (store *FileStore) SetNamespace(ns filter.Reasoner) {
+	store.mem.SetNamespace(ns)
+}
+
+// OnChange adds a listener that is called with the ids of the records changed by each commit. See MemStore.OnChange.
+func (store *FileStore) OnChange(listener func(ids []haystack.Ref)) {
+	store.mem.OnChange(listener)
+}
+
+// Size returns the number of records.
+func (store *FileStore) Size() int {
+	return store.mem.Size()
+}
+
+// ReadById returns the record with the id. An UnknownRecError is returned if there is none.
+func (store *FileStore) ReadById(id haystack.Ref) (haystack.Dict, error) {
+	return store.mem.ReadById(id)
+}
+
+// Resolve returns the record with the id, so that a FileStore can be used as a haystack.RefResolver.
+func (store *FileStore) Resolve(ref haystack.Ref) (haystack.Dict, error) {
+	return store.mem.Resolve(ref)
+}
+
+// ReadByIds returns a Grid with a row for each id. See MemStore.ReadByIds.
+func (store *FileStore) ReadByIds(ids []haystack.Ref) haystack.Grid {
+	return store.mem.ReadByIds(ids)
+}
+
+// Read returns a Grid of the records that match the filter string.
+func (store *FileStore) Read(filterStr string) (haystack.Grid, error) {
+	return store.mem.Read(filterStr)
+}
+
+// ReadLimit returns a Grid of at most limit records that match the filter string. See MemStore.ReadLimit.
+func (store *FileStore) ReadLimit(filterStr string, limit int) (haystack.Grid, error) {
+	return store.mem.ReadLimit(filterStr, limit)
+}
+
+// Select returns at most limit records that match the filter. See MemStore.Select.
+func (store *FileStore) Select(query filter.Filter, limit int) []haystack.Dict {
+	return store.mem.Select(query, limit)
+}
+
+// All returns every record, ordered by id.
+func (store *FileStore) All() []haystack.Dict {
+	return store.mem.All()
+}
+
+// Commit applies the diffs atomically: either all of them are saved, or none are. Every added or updated record is
+// given the same new `mod`, and the new records are returned in the order of the diffs, with an empty Dict for
+// removes. A ConcurrentChangeError is returned if an update or remove is based on a stale `mod`.
+func (store *FileStore) Commit(diffs ...Diff) ([]haystack.Dict, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	mod := haystack.NewDateTimeFromGo(store.nextMod())
+	entries := make([]Diff, 0, len(diffs))
+	touched := map[string]bool{}
+	for _, diff := range diffs {
+		if touched[diff.Id.Id()] {
+			return nil, errors.New("rec is changed more than once in the commit: @" + diff.Id.Id())
+		}
+		touched[diff.Id.Id()] = true
+
+		entry, err := store.check(diff, mod)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	result, err := store.prepare(entries)
+	if err != nil {
+		return nil, err
+	}
+	err = store.appendLog(store.seq+1, entries)
+	if err != nil {
+		return nil, err
+	}
+	store.seq++
+	store.logCommits++
+	store.mem.swap(entries, result)
+
+	if store.compactAfter > 0 && store.logCommits >= store.compactAfter {
+		err = store.compact()
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// Compact writes a snapshot of every record and empties the log.
+func (store *FileStore) Compact() error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	return store.compact()
+}
+
+// check validates the diff against the current records and returns the log entry for it, with the new mod.
+func (store *FileStore) check(diff Diff, mod haystack.DateTime) (Diff, error) {
+	if diff.Id.Id() == "" {
+		return diff, errors.New("diff has no rec id")
+	}
+	if diff.Changes.Has("id") {
+		changedId, err := diff.Changes.GetRef("id")
+		if err != nil || changedId.Id() != diff.Id.Id() {
+			return diff, errors.New("rec id can't be changed: @" + diff.Id.Id())
+		}
+	}
+
+	current, err := store.mem.ReadById(diff.Id)
+	switch diff.Op {
+	case DiffAdd:
+		if err == nil {
+			return diff, NewDuplicateRecError(diff.Id)
+		}
+		return Diff{Op: DiffAdd, Id: diff.Id, Changes: diff.Changes.Set("id", diff.Id).Set("mod", mod)}, nil
+	case DiffUpdate, DiffRemove:
+		if err != nil {
+			return diff, err
+		}
+		if !haystack.ValEquals(current.Get("mod"), diff.Mod) {
+			return diff, NewConcurrentChangeError(diff.Id)
+		}
+		if diff.Op == DiffRemove {
+			return Diff{Op: DiffRemove, Id: diff.Id, Changes: haystack.EmptyDict()}, nil
+		}
+		return Diff{Op: DiffUpdate, Id: diff.Id, Changes: diff.Changes.Set("mod", mod)}, nil
+	default:
+		return diff, errors.New("unknown diff op: " + string(diff.Op))
+	}
+}
+
+// prepare returns the new records that the log entries of a commit make, with an empty Dict for removes, without
+// changing the records in memory. An error is returned if any entry can't be applied, so that a commit is applied
+// with MemStore.swap either completely or not at all.
+func (store *FileStore) prepare(entries []Diff) ([]haystack.Dict, error) {
+	result := make([]haystack.Dict, 0, len(entries))
+	for _, entry := range entries {
+		current, err := store.mem.ReadById(entry.Id)
+		switch entry.Op {
+		case DiffAdd:
+			if err == nil {
+				return nil, NewDuplicateRecError(entry.Id)
+			}
+			result = append(result, haystack.EmptyDict().Patch(entry.Changes))
+		case DiffUpdate:
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, current.Patch(entry.Changes))
+		case DiffRemove:
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, haystack.EmptyDict())
+		default:
+			return nil, errors.New("unknown diff op: " + string(entry.Op))
+		}
+	}
+	return result, nil
+}
+
+// nextMod returns the current time, truncated to milliseconds and after the previous mod, so that mods always change.
+func (store *FileStore) nextMod() time.Time {
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if !now.After(store.lastMod) {
+		now = store.lastMod.Add(time.Millisecond)
+	}
+	store.lastMod = now
+	return now
+}
+
+// Log entries are framed by a header line of the body length and its CRC-32, followed by the body: a Zinc grid with
+// a `seq` in its meta and a row for each diff.
+//
+//	<length> <crc32 hex>
+//	ver:"3.0" seq:<n>
+//	op,id,changes
+//	"update",@abc,{dis:"AHU-1"}
+
+func encodeEntry(seq int64, entries []Diff) []byte {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("seq", haystack.NewNumber(float64(seq), ""))
+	gb.AddColNoMeta("op")
+	gb.AddColNoMeta("id")
+	gb.AddColNoMeta("changes")
+	for _, entry := range entries {
+		gb.AddRow([]haystack.Val{haystack.NewStr(string(entry.Op)), entry.Id, entry.Changes})
+	}
+	body := gb.ToGrid().ToZinc()
+	header := strconv.Itoa(len(body)) + " " + fmt.Sprintf("%08x", crc32.ChecksumIEEE([]byte(body))) + "\n"
+	return []byte(header + body)
+}
+
+func decodeEntry(grid haystack.Grid) (int64, []Diff, error) {
+	seq, err := grid.Meta().GetNumber("seq")
+	if err != nil {
+		return 0, nil, err
+	}
+	entries := make([]Diff, 0, grid.RowCount())
+	for _, row := range grid.Rows() {
+		dict := row.ToDict()
+		op, err := dict.GetStr("op")
+		if err != nil {
+			return 0, nil, err
+		}
+		id, err := dict.GetRef("id")
+		if err != nil {
+			return 0, nil, err
+		}
+		changes := haystack.EmptyDict()
+		if dict.Has("changes") {
+			changes, err = dict.GetDict("changes")
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+		entries = append(entries, Diff{Op: DiffOp(op.String()), Id: id, Changes: changes})
+	}
+	return int64(seq.Float()), entries, nil
+}
+
+// appendLog writes the commit to the end of the log and syncs it. If the write fails, the log is truncated back to
+// its previous end, so that later commits aren't lost behind a partial one.
+func (store *FileStore) appendLog(seq int64, entries []Diff) error {
+	offset, err := store.log.Seek(0, goio.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	_, err = store.log.Write(encodeEntry(seq, entries))
+	if err == nil {
+		err = store.log.Sync()
+	}
+	if err != nil {
+		store.log.Truncate(offset)
+		store.log.Seek(offset, goio.SeekStart)
+		return err
+	}
+	return nil
+}
+
+// replayLog applies the commits in the log that are newer than the snapshot, truncates any partial commit at the
+// end, and opens the log for appending.
+func (store *FileStore) replayLog() error {
+	path := filepath.Join(store.dir, logFile)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(file)
+	var good int64
+	for {
+		size, seq, entries, err := readEntry(reader)
+		if err != nil {
+			// Only the last entry can be partial. Anything after a bad entry means the log is corrupt.
+			if _, peekErr := reader.Peek(1); peekErr == goio.EOF {
+				break
+			}
+			file.Close()
+			return fmt.Errorf("corrupt commit at offset %d of %s: %v", good, path, err)
+		}
+		if seq > store.seq {
+			recs, err := store.prepare(entries)
+			if err != nil {
+				file.Close()
+				return fmt.Errorf("replaying commit %d: %v", seq, err)
+			}
+			store.mem.swap(entries, recs)
+			for _, entry := range entries {
+				if mod, err := entry.Changes.GetDateTime("mod"); err == nil && mod.ToGo().After(store.lastMod) {
+					store.lastMod = mod.ToGo()
+				}
+			}
+			store.seq = seq
+			store.logCommits++
+		}
+		good += size
+	}
+
+	err = file.Truncate(good)
+	if err == nil {
+		_, err = file.Seek(good, goio.SeekStart)
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	store.log = file
+	return nil
+}
+
+// readEntry reads a log entry and returns its size in bytes. An error is returned at the end of the log, or if the
+// entry is partial or corrupt.
+func readEntry(reader *bufio.Reader) (int64, int64, []Diff, error) {
+	header, err := reader.ReadString('\n')
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	fields := strings.Fields(header)
+	if len(fields) != 2 {
+		return 0, 0, nil, errors.New("invalid log entry header")
+	}
+	length, err := strconv.Atoi(fields[0])
+	if err != nil || length < 0 {
+		return 0, 0, nil, errors.New("invalid log entry length")
+	}
+	checksum, err := strconv.ParseUint(fields[1], 16, 32)
+	if err != nil {
+		return 0, 0, nil, errors.New("invalid log entry checksum")
+	}
+
+	body := make([]byte, length)
+	_, err = goio.ReadFull(reader, body)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	if crc32.ChecksumIEEE(body) != uint32(checksum) {
+		return 0, 0, nil, errors.New("log entry checksum mismatch")
+	}
+
+	grid, err := readZincGrid(string(body))
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	seq, entries, err := decodeEntry(grid)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return int64(len(header) + length), seq, entries, nil
+}
+
+func (store *FileStore) loadSnapshot() error {
+	content, err := ioutil.ReadFile(filepath.Join(store.dir, snapshotFile))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	grid, err := readZincGrid(string(content))
+	if err != nil {
+		return err
+	}
+	seq, err := grid.Meta().GetNumber("seq")
+	if err != nil {
+		return err
+	}
+	store.seq = int64(seq.Float())
+	if mod, err := grid.Meta().GetDateTime("lastMod"); err == nil {
+		store.lastMod = mod.ToGo()
+	}
+	return store.mem.Load(grid)
+}
+
+// compact writes the snapshot to a temporary file and renames it into place, so that a crash leaves either the old
+// or the new snapshot. The log is emptied afterwards; commits still in it are skipped on replay by their seq.
+func (store *FileStore) compact() error {
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("seq", haystack.NewNumber(float64(store.seq), ""))
+	if !store.lastMod.IsZero() {
+		gb.AddMetaVal("lastMod", haystack.NewDateTimeFromGo(store.lastMod))
+	}
+	recs := store.mem.All()
+	names := map[string]bool{"id": true}
+	for _, rec := range recs {
+		for _, name := range rec.Names() {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	for _, name := range sorted {
+		gb.AddColNoMeta(name)
+	}
+	gb.AddRowDicts(recs)
+
+	path := filepath.Join(store.dir, snapshotFile)
+	tmpPath := path + ".tmp"
+	err := writeSynced(tmpPath, []byte(gb.ToGrid().ToZinc()))
+	if err != nil {
+		return err
+	}
+	err = os.Rename(tmpPath, path)
+	if err != nil {
+		return err
+	}
+	err = syncDir(store.dir)
+	if err != nil {
+		return err
+	}
+
+	err = store.log.Truncate(0)
+	if err == nil {
+		_, err = store.log.Seek(0, goio.SeekStart)
+	}
+	if err == nil {
+		err = store.log.Sync()
+	}
+	if err != nil {
+		return err
+	}
+	store.logCommits = 0
+	return nil
+}
+
+func readZincGrid(zinc string) (haystack.Grid, error) {
+	var reader io.ZincReader
+	reader.InitString(zinc)
+	val, err := reader.ReadVal()
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	grid, ok := val.(haystack.Grid)
+	if !ok {
+		return haystack.EmptyGrid(), errors.New("zinc is not a grid")
+	}
+	return grid, nil
+}
+
+func writeSynced(path string, content []byte) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(content)
+	if err == nil {
+		err = file.Sync()
+	}
+	closeErr := file.Close()
+	if err != nil {
+		return err
+	}
+	return closeErr
+}
+
+func syncDir(dir string) error {
+	file, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return file.Sync()
+}
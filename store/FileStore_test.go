@@ -0,0 +1,177 @@
+package store
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/stretchr/testify/assert"
+)
+
+func openTestFileStore(t *testing.T, dir string) *FileStore {
+	store, err := OpenFileStore(dir)
+	assert.Nil(t, err)
+	return store
+}
+
+func testRec(id string, dis string) haystack.Dict {
+	return haystack.NewDict(map[string]haystack.Val{
+		"id":    haystack.NewRef(id, ""),
+		"dis":   haystack.NewStr(dis),
+		"equip": haystack.NewMarker(),
+	})
+}
+
+func TestFileStore_Commit(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestore")
+	defer os.RemoveAll(dir)
+	store := openTestFileStore(t, dir)
+
+	recs, err := store.Commit(NewAddDiff(testRec("a", "A")), NewAddDiff(testRec("b", "B")))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(recs))
+	mod, err := recs[0].GetDateTime("mod")
+	assert.Nil(t, err)
+	assert.Equal(t, mod, recs[1].Get("mod"))
+
+	a := recs[0]
+	updated, err := store.Commit(NewUpdateDiff(a, haystack.NewDict(map[string]haystack.Val{
+		"dis":  haystack.NewStr("A2"),
+		"area": haystack.NewNumber(100, "ft²"),
+	})))
+	assert.Nil(t, err)
+	assert.Equal(t, haystack.NewStr("A2"), updated[0].Get("dis"))
+	newMod, _ := updated[0].GetDateTime("mod")
+	assert.True(t, newMod.After(mod))
+
+	// The original version of a is stale
+	_, err = store.Commit(NewUpdateDiff(a, haystack.NewDict(map[string]haystack.Val{"dis": haystack.NewStr("A3")})))
+	assert.Equal(t, NewConcurrentChangeError(haystack.NewRef("a", "")), err)
+	_, err = store.Commit(NewRemoveDiff(a))
+	assert.Equal(t, NewConcurrentChangeError(haystack.NewRef("a", "")), err)
+
+	// A commit with any failing diff changes nothing
+	b, _ := store.ReadById(haystack.NewRef("b", ""))
+	_, err = store.Commit(NewRemoveDiff(b), NewAddDiff(testRec("a", "dup")))
+	assert.Equal(t, NewDuplicateRecError(haystack.NewRef("a", "")), err)
+	assert.Equal(t, 2, store.Size())
+
+	_, err = store.Commit(NewRemoveDiff(b))
+	assert.Nil(t, err)
+	grid, err := store.Read("equip")
+	assert.Nil(t, err)
+	assert.Equal(t, 1, grid.RowCount())
+	assert.Nil(t, store.Close())
+
+	reopened := openTestFileStore(t, dir)
+	defer reopened.Close()
+	assert.Equal(t, store.All(), reopened.All())
+
+	// Mods survive reopening, so the reread record can be updated
+	a, _ = reopened.ReadById(haystack.NewRef("a", ""))
+	_, err = reopened.Commit(NewUpdateDiff(a, haystack.NewDict(map[string]haystack.Val{"area": haystack.NewRemove()})))
+	assert.Nil(t, err)
+}
+
+func TestFileStore_Compact(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestore")
+	defer os.RemoveAll(dir)
+	store := openTestFileStore(t, dir)
+	store.SetCompactAfter(3)
+
+	for _, id := range []string{"a", "b", "c", "d"} {
+		_, err := store.Commit(NewAddDiff(testRec(id, id)))
+		assert.Nil(t, err)
+	}
+	assert.FileExists(t, filepath.Join(dir, snapshotFile))
+
+	// Simulate a crash after the snapshot was written but before the log was emptied
+	logBefore, _ := ioutil.ReadFile(filepath.Join(dir, logFile))
+	assert.Nil(t, store.Compact())
+	assert.Nil(t, store.Close())
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(dir, logFile), logBefore, 0644))
+
+	reopened := openTestFileStore(t, dir)
+	defer reopened.Close()
+	assert.Equal(t, store.All(), reopened.All())
+	d, _ := reopened.ReadById(haystack.NewRef("d", ""))
+	recs, err := reopened.Commit(NewUpdateDiff(d, haystack.NewDict(map[string]haystack.Val{"dis": haystack.NewStr("D")})))
+	assert.Nil(t, err)
+	newMod, _ := recs[0].GetDateTime("mod")
+	oldMod, _ := d.GetDateTime("mod")
+	assert.True(t, newMod.After(oldMod))
+}
+
+func TestFileStore_Recovery(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestore")
+	defer os.RemoveAll(dir)
+	store := openTestFileStore(t, dir)
+	_, err := store.Commit(NewAddDiff(testRec("a", "A")))
+	assert.Nil(t, err)
+	_, err = store.Commit(NewAddDiff(testRec("b", "B")), NewAddDiff(testRec("c", "C")))
+	assert.Nil(t, err)
+	assert.Nil(t, store.Close())
+
+	path := filepath.Join(dir, logFile)
+	full, _ := ioutil.ReadFile(path)
+	for _, cut := range []int{1, 10, len(full) / 3} {
+		assert.Nil(t, ioutil.WriteFile(path, full[:len(full)-cut], 0644))
+		truncated := openTestFileStore(t, dir)
+		assert.Equal(t, 1, truncated.Size())
+		_, err = truncated.ReadById(haystack.NewRef("b", ""))
+		assert.NotNil(t, err)
+
+		// New commits are written after the last complete one
+		_, err = truncated.Commit(NewAddDiff(testRec("d", "D")))
+		assert.Nil(t, err)
+		assert.Nil(t, truncated.Close())
+		recovered := openTestFileStore(t, dir)
+		assert.Equal(t, 2, recovered.Size())
+		assert.Nil(t, recovered.Close())
+	}
+
+	// A corrupt commit at the end is discarded like a partial one
+	corrupt := append([]byte{}, full...)
+	corrupt[len(corrupt)-5] = 'X'
+	assert.Nil(t, ioutil.WriteFile(path, corrupt, 0644))
+	store = openTestFileStore(t, dir)
+	assert.Equal(t, 1, store.Size())
+	assert.Nil(t, store.Close())
+
+	// A corrupt commit before the end is an error, and the log is left as it is
+	corrupt = append([]byte{}, full...)
+	corrupt[20] = 'X'
+	assert.Nil(t, ioutil.WriteFile(path, corrupt, 0644))
+	_, err = OpenFileStore(dir)
+	assert.NotNil(t, err)
+	unchanged, _ := ioutil.ReadFile(path)
+	assert.Equal(t, corrupt, unchanged)
+}
+
+func TestFileStore_Commit_allOrNothing(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "filestore")
+	defer os.RemoveAll(dir)
+	store := openTestFileStore(t, dir)
+	_, err := store.Commit(NewAddDiff(testRec("a", "A")))
+	assert.Nil(t, err)
+	a, _ := store.ReadById(haystack.NewRef("a", ""))
+
+	_, err = store.Commit(
+		NewRemoveDiff(a),
+		NewAddDiff(testRec("b", "B")),
+		NewUpdateDiff(testRec("missing", "Missing"), haystack.EmptyDict()),
+	)
+	assert.Equal(t, NewUnknownRecError(haystack.NewRef("missing", "")), err)
+	assert.Equal(t, 1, store.Size())
+	_, err = store.ReadById(haystack.NewRef("a", ""))
+	assert.Nil(t, err)
+	assert.Nil(t, store.Close())
+
+	reopened := openTestFileStore(t, dir)
+	assert.Equal(t, 1, reopened.Size())
+	_, err = reopened.ReadById(haystack.NewRef("b", ""))
+	assert.NotNil(t, err)
+	assert.Nil(t, reopened.Close())
+}
@@ -0,0 +1,393 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/filter"
+)
+
+// MemStore is an in-memory database of entity records keyed by their `id` Ref. Filter queries use an index of tag
+// names and an index of Ref-valued tags, so that queries like `equip and siteRef == @hq` only check the records that
+// have the tags, instead of every record.
+//
+// A MemStore is safe for concurrent use. Readers run in parallel, and writers have exclusive access.
+type MemStore struct {
+	lock sync.RWMutex
+	recs map[string]haystack.Dict
+	tags map[string]idSet            // tag name -> ids of recs with the tag
+	refs map[string]map[string]idSet // tag name -> ref id -> ids of recs with the tag set to the ref
+	ns   filter.Reasoner
+
+	listeners []func(ids []haystack.Ref)
+}
+
+type idSet map[string]struct{}
+
+// NewMemStore creates an empty MemStore.
+func NewMemStore() *MemStore {
+	return &MemStore{
+		recs: map[string]haystack.Dict{},
+		tags: map[string]idSet{},
+		refs: map[string]map[string]idSet{},
+	}
+}
+
+// SetNamespace sets the defs used to match symbol terms in filters, like `^ahu`. It is typically a *defs.Namespace.
+func (store *MemStore) SetNamespace(ns filter.Reasoner) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.ns = ns
+}
+
+// OnChange adds a listener that is called with the ids of the records that are added, updated or removed, like a
+// watch.Manager's Changed. Listeners are called in the goroutine that made the change, without the store locked.
+func (store *MemStore) OnChange(listener func(ids []haystack.Ref)) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+	store.listeners = append(store.listeners, listener)
+}
+
+// Size returns the number of records.
+func (store *MemStore) Size() int {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return len(store.recs)
+}
+
+// ReadById returns the record with the id. An UnknownRecError is returned if there is none.
+func (store *MemStore) ReadById(id haystack.Ref) (haystack.Dict, error) {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return store.resolve(id)
+}
+
+// Resolve returns the record with the id, so that a MemStore can be used as a haystack.RefResolver.
+func (store *MemStore) Resolve(ref haystack.Ref) (haystack.Dict, error) {
+	return store.ReadById(ref)
+}
+
+// ReadByIds returns a Grid with a row for each id, in order, like the 'read' op. The rows of unknown ids are empty.
+func (store *MemStore) ReadByIds(ids []haystack.Ref) haystack.Grid {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	dicts := make([]haystack.Dict, 0, len(ids))
+	for _, id := range ids {
+		rec, err := store.resolve(id)
+		if err != nil {
+			rec = haystack.EmptyDict()
+		}
+		dicts = append(dicts, rec)
+	}
+	return haystack.NewGridFromDicts(dicts)
+}
+
+// Read returns a Grid of the records that match the filter string, like the 'read' op.
+func (store *MemStore) Read(filterStr string) (haystack.Grid, error) {
+	return store.ReadLimit(filterStr, 0)
+}
+
+// ReadLimit returns a Grid of at most limit records that match the filter string. A limit of 0 or less is no limit.
+func (store *MemStore) ReadLimit(filterStr string, limit int) (haystack.Grid, error) {
+	parsed, err := filter.Parse(filterStr)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return haystack.NewGridFromDicts(store.Select(parsed, limit)), nil
+}
+
+// Select returns at most limit records that match the filter, ordered by id. A limit of 0 or less is no limit.
+// Paths like `equipRef->siteRef` are resolved against the store.
+func (store *MemStore) Select(query filter.Filter, limit int) []haystack.Dict {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	ctx := filter.Context{
+		Resolver:  haystack.RefResolverFunc(store.resolve),
+		Namespace: store.ns,
+	}
+	result := []haystack.Dict{}
+	for _, id := range store.candidates(query) {
+		rec := store.recs[id]
+		if !query.Include(rec, ctx) {
+			continue
+		}
+		result = append(result, rec)
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+	return result
+}
+
+// All returns every record, ordered by id.
+func (store *MemStore) All() []haystack.Dict {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	result := make([]haystack.Dict, 0, len(store.recs))
+	for _, id := range store.sortedIds() {
+		result = append(result, store.recs[id])
+	}
+	return result
+}
+
+// Add adds a new record. The record must have an `id` Ref that is not in use, or a DuplicateRecError is returned.
+// Null tags are dropped.
+func (store *MemStore) Add(rec haystack.Dict) error {
+	id, err := rec.GetRef("id")
+	if err != nil {
+		return err
+	}
+	store.lock.Lock()
+	if _, ok := store.recs[id.Id()]; ok {
+		store.lock.Unlock()
+		return NewDuplicateRecError(id)
+	}
+	store.put(id, haystack.EmptyDict().Patch(rec))
+	store.unlockAndNotify([]haystack.Ref{id})
+	return nil
+}
+
+// Update applies the changes to the record with the id, and returns the updated record. Tags with a Remove or Null
+// value are removed, and all others are set. See haystack.Dict.Patch. The `id` tag can't be changed.
+func (store *MemStore) Update(id haystack.Ref, changes haystack.Dict) (haystack.Dict, error) {
+	if changes.Has("id") {
+		changedId, err := changes.GetRef("id")
+		if err != nil || changedId.Id() != id.Id() {
+			return haystack.EmptyDict(), errors.New("rec id can't be changed: @" + id.Id())
+		}
+	}
+	store.lock.Lock()
+	rec, err := store.resolve(id)
+	if err != nil {
+		store.lock.Unlock()
+		return haystack.EmptyDict(), err
+	}
+	updated := rec.Patch(changes)
+	store.remove(id)
+	store.put(id, updated)
+	store.unlockAndNotify([]haystack.Ref{id})
+	return updated, nil
+}
+
+// Remove removes the record with the id. An UnknownRecError is returned if there is none.
+func (store *MemStore) Remove(id haystack.Ref) error {
+	store.lock.Lock()
+	if _, ok := store.recs[id.Id()]; !ok {
+		store.lock.Unlock()
+		return NewUnknownRecError(id)
+	}
+	store.remove(id)
+	store.unlockAndNotify([]haystack.Ref{id})
+	return nil
+}
+
+// Load adds the rows of the grid as records, like those returned by client.Read. Records with the same id are
+// replaced, and null tags are dropped. An error is returned, and nothing is loaded, if any row has no `id` Ref.
+func (store *MemStore) Load(grid haystack.Grid) error {
+	recs := make([]haystack.Dict, 0, grid.RowCount())
+	for _, row := range grid.Rows() {
+		rec := haystack.EmptyDict().Patch(row.ToDict())
+		if _, err := rec.GetRef("id"); err != nil {
+			return err
+		}
+		recs = append(recs, rec)
+	}
+
+	ids := make([]haystack.Ref, 0, len(recs))
+	store.lock.Lock()
+	for _, rec := range recs {
+		id, _ := rec.GetRef("id")
+		store.remove(id)
+		store.put(id, rec)
+		ids = append(ids, id)
+	}
+	store.unlockAndNotify(ids)
+	return nil
+}
+
+// LoadZinc loads the records of a Zinc-encoded grid. See Load.
+func (store *MemStore) LoadZinc(zinc string) error {
+	grid, err := readZincGrid(zinc)
+	if err != nil {
+		return err
+	}
+	return store.Load(grid)
+}
+
+// swap replaces the records of the diffs with the recs in one step, removing those whose rec is empty. The recs must
+// be the validated results of the diffs, like those from FileStore.prepare.
+func (store *MemStore) swap(diffs []Diff, recs []haystack.Dict) {
+	ids := make([]haystack.Ref, 0, len(diffs))
+	store.lock.Lock()
+	for i, diff := range diffs {
+		store.remove(diff.Id)
+		if !recs[i].IsEmpty() {
+			store.put(diff.Id, recs[i])
+		}
+		ids = append(ids, diff.Id)
+	}
+	store.unlockAndNotify(ids)
+}
+
+// unlockAndNotify releases the write lock and calls the listeners with the ids of the changed records
+func (store *MemStore) unlockAndNotify(ids []haystack.Ref) {
+	listeners := store.listeners
+	store.lock.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+	for _, listener := range listeners {
+		listener(ids)
+	}
+}
+
+// Snapshot returns a copy of the store. Changes to either store are not visible in the other.
+func (store *MemStore) Snapshot() *MemStore {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	snapshot := NewMemStore()
+	snapshot.ns = store.ns
+	for id, rec := range store.recs {
+		snapshot.recs[id] = rec
+	}
+	for name, ids := range store.tags {
+		snapshot.tags[name] = ids.copy()
+	}
+	for name, byRef := range store.refs {
+		snapshotByRef := make(map[string]idSet, len(byRef))
+		for refId, ids := range byRef {
+			snapshotByRef[refId] = ids.copy()
+		}
+		snapshot.refs[name] = snapshotByRef
+	}
+	return snapshot
+}
+
+// resolve returns the record with the id. The caller must hold the lock.
+func (store *MemStore) resolve(id haystack.Ref) (haystack.Dict, error) {
+	rec, ok := store.recs[id.Id()]
+	if !ok {
+		return haystack.EmptyDict(), NewUnknownRecError(id)
+	}
+	return rec, nil
+}
+
+// candidates returns the sorted ids of the records that may match the filter, using the smallest index set of the
+// filter's required terms, or every id if it has none.
+func (store *MemStore) candidates(query filter.Filter) []string {
+	tags, refs := filter.Required(query)
+	var best idSet
+	found := false
+	consider := func(ids idSet) {
+		if !found || len(ids) < len(best) {
+			best = ids
+			found = true
+		}
+	}
+	for _, name := range tags {
+		consider(store.tags[name])
+	}
+	for name, ref := range refs {
+		if name == "id" {
+			// Ids aren't in the refs index, since each record is found directly by its id
+			ids := idSet{}
+			if _, ok := store.recs[ref.Id()]; ok {
+				ids[ref.Id()] = struct{}{}
+			}
+			consider(ids)
+			continue
+		}
+		consider(store.refs[name][ref.Id()])
+	}
+	if !found {
+		return store.sortedIds()
+	}
+	return best.sorted()
+}
+
+// put adds the record and indexes it. The caller must hold the write lock.
+func (store *MemStore) put(id haystack.Ref, rec haystack.Dict) {
+	store.recs[id.Id()] = rec
+	for _, name := range rec.Names() {
+		ids, ok := store.tags[name]
+		if !ok {
+			ids = idSet{}
+			store.tags[name] = ids
+		}
+		ids[id.Id()] = struct{}{}
+
+		ref, ok := rec.Get(name).(haystack.Ref)
+		if !ok || name == "id" {
+			continue
+		}
+		byRef, ok := store.refs[name]
+		if !ok {
+			byRef = map[string]idSet{}
+			store.refs[name] = byRef
+		}
+		refIds, ok := byRef[ref.Id()]
+		if !ok {
+			refIds = idSet{}
+			byRef[ref.Id()] = refIds
+		}
+		refIds[id.Id()] = struct{}{}
+	}
+}
+
+// remove removes the record, if any, and its index entries. The caller must hold the write lock.
+func (store *MemStore) remove(id haystack.Ref) {
+	rec, ok := store.recs[id.Id()]
+	if !ok {
+		return
+	}
+	delete(store.recs, id.Id())
+	for _, name := range rec.Names() {
+		delete(store.tags[name], id.Id())
+		if len(store.tags[name]) == 0 {
+			delete(store.tags, name)
+		}
+
+		ref, ok := rec.Get(name).(haystack.Ref)
+		if !ok || name == "id" {
+			continue
+		}
+		byRef := store.refs[name]
+		delete(byRef[ref.Id()], id.Id())
+		if len(byRef[ref.Id()]) == 0 {
+			delete(byRef, ref.Id())
+		}
+		if len(byRef) == 0 {
+			delete(store.refs, name)
+		}
+	}
+}
+
+func (ids idSet) copy() idSet {
+	result := make(idSet, len(ids))
+	for id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
+
+func (ids idSet) sorted() []string {
+	result := make([]string, 0, len(ids))
+	for id := range ids {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// sortedIds returns the ids of all records, sorted. The caller must hold the lock.
+func (store *MemStore) sortedIds() []string {
+	result := make([]string, 0, len(store.recs))
+	for id := range store.recs {
+		result = append(result, id)
+	}
+	sort.Strings(result)
+	return result
+}
@@ -0,0 +1,166 @@
+package store
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/filter"
+	"github.com/stretchr/testify/assert"
+)
+
+const testZinc = `ver:"3.0"
+id,dis,site,equip,ahu,point,siteRef,equipRef,area
+@s,"HQ",M,,,,,,5000ft²
+@ahu1,"AHU-1",,M,M,,@s,,
+@ahu2,"AHU-2",,M,M,,@other,,
+@p1,"DAT",,,,M,@s,@ahu1,
+@p2,"RAT",,,,M,@s,@ahu2,
+`
+
+func testStore(t *testing.T) *MemStore {
+	store := NewMemStore()
+	assert.Nil(t, store.LoadZinc(testZinc))
+	return store
+}
+
+func ids(dicts []haystack.Dict) []string {
+	result := []string{}
+	for _, dict := range dicts {
+		id, _ := dict.GetRef("id")
+		result = append(result, id.Id())
+	}
+	return result
+}
+
+func TestMemStore_Select(t *testing.T) {
+	store := testStore(t)
+	assert.Equal(t, 5, store.Size())
+
+	tests := map[string][]string{
+		"ahu":                                 {"ahu1", "ahu2"},
+		"equip and siteRef == @s":             {"ahu1"},
+		"point and equipRef == @ahu2":         {"p2"},
+		"equipRef->siteRef == @s":             {"p1"},
+		"equipRef->siteRef->area > 1000ft²":   {"p1"},
+		"not point":                           {"ahu1", "ahu2", "s"},
+		"site or equipRef == @ahu1":           {"p1", "s"},
+		"siteRef == @missing":                 {},
+		"missingTag and siteRef == @s":        {},
+		"point and siteRef == @s and navName": {},
+		"id == @p1":                           {"p1"},
+		"point and id == @p1":                 {"p1"},
+		"equip and id == @p1":                 {},
+		"id == @missing":                      {},
+	}
+	for filterStr, expected := range tests {
+		parsed, err := filter.Parse(filterStr)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, ids(store.Select(parsed, 0)), filterStr)
+	}
+
+	grid, err := store.ReadLimit("siteRef", 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, grid.RowCount())
+
+	_, err = store.Read("equip and")
+	assert.NotNil(t, err)
+}
+
+func TestMemStore_ReadById(t *testing.T) {
+	store := testStore(t)
+	rec, err := store.ReadById(haystack.NewRef("ahu1", ""))
+	assert.Nil(t, err)
+	assert.Equal(t, haystack.NewStr("AHU-1"), rec.Get("dis"))
+
+	_, err = store.ReadById(haystack.NewRef("x", ""))
+	assert.Equal(t, NewUnknownRecError(haystack.NewRef("x", "")), err)
+
+	grid := store.ReadByIds([]haystack.Ref{haystack.NewRef("p2", ""), haystack.NewRef("x", "")})
+	assert.Equal(t, 2, grid.RowCount())
+	assert.Equal(t, haystack.NewStr("RAT"), grid.RowAt(0).Get("dis"))
+	assert.Equal(t, haystack.NewNull(), grid.RowAt(1).Get("id"))
+}
+
+func TestMemStore_Edit(t *testing.T) {
+	store := testStore(t)
+	point := haystack.NewDict(map[string]haystack.Val{
+		"id":       haystack.NewRef("p3", ""),
+		"point":    haystack.NewMarker(),
+		"equipRef": haystack.NewRef("ahu1", ""),
+	})
+	assert.Nil(t, store.Add(point))
+	assert.Equal(t, NewDuplicateRecError(haystack.NewRef("p3", "")), store.Add(point))
+	assert.NotNil(t, store.Add(haystack.EmptyDict()))
+
+	ahu1 := filter.Cmp(filter.Path{"equipRef"}, filter.OpEq, haystack.NewRef("ahu1", ""))
+	assert.Equal(t, []string{"p1", "p3"}, ids(store.Select(ahu1, 0)))
+
+	updated, err := store.Update(haystack.NewRef("p1", ""), haystack.NewDict(map[string]haystack.Val{
+		"equipRef": haystack.NewRef("ahu2", ""),
+		"dis":      haystack.NewRemove(),
+	}))
+	assert.Nil(t, err)
+	assert.True(t, updated.Missing("dis"))
+	assert.Equal(t, []string{"p3"}, ids(store.Select(ahu1, 0)))
+
+	_, err = store.Update(haystack.NewRef("p1", ""), haystack.NewDict(map[string]haystack.Val{
+		"id": haystack.NewRef("p9", ""),
+	}))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, store.Remove(haystack.NewRef("p3", "")))
+	assert.NotNil(t, store.Remove(haystack.NewRef("p3", "")))
+	assert.Empty(t, store.Select(ahu1, 0))
+}
+
+func TestMemStore_OnChange(t *testing.T) {
+	store := NewMemStore()
+	changed := []string{}
+	store.OnChange(func(ids []haystack.Ref) {
+		// The store isn't locked while listeners run
+		for _, id := range ids {
+			_, err := store.ReadById(id)
+			changed = append(changed, id.Id()+":"+strconv.FormatBool(err == nil))
+		}
+	})
+	a := haystack.NewRef("a", "")
+	assert.Nil(t, store.Add(haystack.NewDict(map[string]haystack.Val{"id": a})))
+	_, err := store.Update(a, haystack.NewDict(map[string]haystack.Val{"dis": haystack.NewStr("A")}))
+	assert.Nil(t, err)
+	assert.Nil(t, store.Remove(a))
+	assert.NotNil(t, store.Remove(a))
+	assert.Nil(t, store.LoadZinc("ver:\"3.0\"\nid\n@b\n@c\n"))
+	assert.Equal(t, []string{"a:true", "a:true", "a:false", "b:true", "c:true"}, changed)
+}
+
+func TestMemStore_Snapshot(t *testing.T) {
+	store := testStore(t)
+	snapshot := store.Snapshot()
+	assert.Nil(t, store.Remove(haystack.NewRef("ahu1", "")))
+
+	ahu, _ := filter.Parse("ahu")
+	assert.Equal(t, []string{"ahu2"}, ids(store.Select(ahu, 0)))
+	assert.Equal(t, []string{"ahu1", "ahu2"}, ids(snapshot.Select(ahu, 0)))
+}
+
+func TestMemStore_Concurrent(t *testing.T) {
+	store := testStore(t)
+	point, _ := filter.Parse("point and siteRef == @s")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			store.Select(point, 0)
+		}()
+		go func(i int) {
+			defer wg.Done()
+			id := haystack.NewRef("c"+string(rune('a'+i)), "")
+			store.Add(haystack.NewDict(map[string]haystack.Val{"id": id, "point": haystack.NewMarker()}))
+		}(i)
+	}
+	wg.Wait()
+	assert.Equal(t, 15, store.Size())
+}
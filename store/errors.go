@@ -0,0 +1,45 @@
+package store
+
+import "github.com/NeedleInAJayStack/haystack"
+
+// UnknownRecError occurs when no record has the requested id.
+type UnknownRecError struct {
+	Id haystack.Ref
+}
+
+// NewUnknownRecError creates a new UnknownRecError object.
+func NewUnknownRecError(id haystack.Ref) UnknownRecError {
+	return UnknownRecError{Id: id}
+}
+
+func (err UnknownRecError) Error() string {
+	return "Unknown rec: @" + err.Id.Id()
+}
+
+// DuplicateRecError occurs when adding a record with an id that is already in use.
+type DuplicateRecError struct {
+	Id haystack.Ref
+}
+
+// NewDuplicateRecError creates a new DuplicateRecError object.
+func NewDuplicateRecError(id haystack.Ref) DuplicateRecError {
+	return DuplicateRecError{Id: id}
+}
+
+func (err DuplicateRecError) Error() string {
+	return "Duplicate rec: @" + err.Id.Id()
+}
+
+// ConcurrentChangeError occurs when a change is based on a version of a record that has since been modified.
+type ConcurrentChangeError struct {
+	Id haystack.Ref
+}
+
+// NewConcurrentChangeError creates a new ConcurrentChangeError object.
+func NewConcurrentChangeError(id haystack.Ref) ConcurrentChangeError {
+	return ConcurrentChangeError{Id: id}
+}
+
+func (err ConcurrentChangeError) Error() string {
+	return "Rec has been modified: @" + err.Id.Id()
+}
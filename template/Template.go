@@ -0,0 +1,281 @@
+package template
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/defs"
+	"github.com/NeedleInAJayStack/haystack/filter"
+)
+
+// Namer returns the `navName` and `dis` of a new child record of the parent.
+type Namer func(parent haystack.Dict, child haystack.Dict) (navName string, dis string)
+
+// Expander creates the child records of equipment and other entities from templates. The children of an entity are
+// taken from the templates added for its type, or if there are none, from the `children` protos of its defs. For
+// example, an `ahu` expands to the discharge, return and outside air points declared by ^ahu.
+type Expander struct {
+	ns        *defs.Namespace
+	templates []template
+	newId     func() (haystack.Ref, error)
+	namer     Namer
+}
+
+type template struct {
+	entityType string
+	children   []haystack.Dict
+}
+
+// NewExpander creates an Expander that uses the defs of the namespace. The namespace may be nil, in which case only
+// the added templates are used and entity types are matched by their marker tags.
+func NewExpander(ns *defs.Namespace) *Expander {
+	expander := &Expander{ns: ns, newId: genRef}
+	expander.namer = expander.defaultNames
+	return expander
+}
+
+// AddTemplate adds child templates for entities of the type, like "ahu" or "elec-meter". These are used instead of
+// the def protos for matching entities. Templates may include any tags, like `kind`, `unit` or `navName`.
+func (expander *Expander) AddTemplate(entityType string, children ...haystack.Dict) {
+	expander.templates = append(expander.templates, template{entityType: entityType, children: children})
+}
+
+// SetIdGenerator sets the function used to create the ids of new records. The default is haystack.GenRef.
+func (expander *Expander) SetIdGenerator(newId func() (haystack.Ref, error)) {
+	expander.newId = newId
+}
+
+// SetNamer sets the naming rule for new records. By default, the `navName` is built from the record's marker tags,
+// like "Discharge Air Temp Sensor", and the `dis` is the parent's `dis` followed by the `navName`. A navName in a
+// template is kept.
+func (expander *Expander) SetNamer(namer Namer) {
+	expander.namer = namer
+}
+
+// Expand creates the child records of the parents, and returns them as a Grid. Children are given new ids and are
+// wired to their parent with `equipRef`, `siteRef` and `spaceRef`. Children that are equipment are expanded in
+// turn. A parent without an id is considered new: it is given an id and included in the result before its children.
+func (expander *Expander) Expand(parents ...haystack.Dict) (haystack.Grid, error) {
+	dicts, err := expander.ExpandDicts(parents...)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return haystack.NewGridFromDicts(dicts), nil
+}
+
+// ExpandDicts is like Expand, but returns the records as Dicts.
+func (expander *Expander) ExpandDicts(parents ...haystack.Dict) ([]haystack.Dict, error) {
+	used := map[string]bool{}
+	for _, parent := range parents {
+		if id, ok := parent.Get("id").(haystack.Ref); ok {
+			used[id.Id()] = true
+		}
+	}
+
+	result := []haystack.Dict{}
+	for _, parent := range parents {
+		if parent.Missing("id") {
+			id, err := expander.uniqueId(used)
+			if err != nil {
+				return nil, err
+			}
+			parent = parent.Set("id", id)
+			result = append(result, parent)
+		} else if _, ok := parent.Get("id").(haystack.Ref); !ok {
+			return nil, haystack.NewTagTypeError("id", "Ref", parent.Get("id"))
+		}
+
+		children, err := expander.expand(parent, used, map[string]bool{markerKey(parent): true})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, children...)
+	}
+	return result, nil
+}
+
+// Children returns the child templates of the entity, from the added templates or the def protos
+func (expander *Expander) Children(parent haystack.Dict) []haystack.Dict {
+	children := []haystack.Dict{}
+	for _, template := range expander.templates {
+		if expander.fits(parent, template.entityType) {
+			children = append(children, template.children...)
+		}
+	}
+	if len(children) > 0 || expander.ns == nil {
+		return children
+	}
+	return expander.ns.Protos(parent)
+}
+
+// expand creates the records for the children of the parent, which must have an id. The expanding set holds the
+// marker keys of the records being expanded further up the tree, to stop templates that contain themselves.
+func (expander *Expander) expand(parent haystack.Dict, used map[string]bool, expanding map[string]bool) ([]haystack.Dict, error) {
+	parentRef := parent.Get("id").(haystack.Ref)
+	if dis, ok := parent.Get("dis").(haystack.Str); ok {
+		parentRef = haystack.NewRef(parentRef.Id(), dis.String())
+	}
+
+	result := []haystack.Dict{}
+	for _, child := range expander.Children(parent) {
+		key := markerKey(child)
+		if expanding[key] {
+			continue
+		}
+
+		id, err := expander.uniqueId(used)
+		if err != nil {
+			return nil, err
+		}
+		record := child.Set("id", id)
+		record = expander.wire(record, parent, parentRef)
+		navName, dis := expander.namer(parent, record)
+		if navName != "" {
+			record = record.Set("navName", haystack.NewStr(navName))
+		}
+		if dis != "" {
+			record = record.Set("dis", haystack.NewStr(dis))
+		}
+		result = append(result, record)
+
+		if expander.fits(record, "equip") {
+			expanding[key] = true
+			grandchildren, err := expander.expand(record, used, expanding)
+			delete(expanding, key)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, grandchildren...)
+		}
+	}
+	return result, nil
+}
+
+// wire sets the refs from the child to the parent, and the refs inherited from the parent
+func (expander *Expander) wire(child haystack.Dict, parent haystack.Dict, parentRef haystack.Ref) haystack.Dict {
+	for _, wiring := range []struct {
+		tag        string
+		entityType string
+	}{
+		{"siteRef", "site"},
+		{"spaceRef", "space"},
+		{"equipRef", "equip"},
+	} {
+		if expander.fits(parent, wiring.entityType) {
+			child = child.Set(wiring.tag, parentRef)
+		} else if ref, ok := parent.Get(wiring.tag).(haystack.Ref); ok {
+			child = child.Set(wiring.tag, ref)
+		}
+	}
+	return child
+}
+
+func (expander *Expander) fits(dict haystack.Dict, entityType string) bool {
+	ctx := filter.Context{}
+	if expander.ns != nil {
+		ctx.Namespace = expander.ns
+	}
+	return filter.Symbol(entityType).Include(dict, ctx)
+}
+
+func (expander *Expander) uniqueId(used map[string]bool) (haystack.Ref, error) {
+	for {
+		id, err := expander.newId()
+		if err != nil {
+			return haystack.Ref{}, err
+		}
+		if !used[id.Id()] {
+			used[id.Id()] = true
+			return id, nil
+		}
+	}
+}
+
+// markerKey identifies the kind of a record by its marker tags
+func markerKey(dict haystack.Dict) string {
+	names := []string{}
+	for _, name := range dict.Names() {
+		if _, ok := dict.Get(name).(haystack.Marker); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+// genRef is the default id generator, which uses haystack.GenRef
+func genRef() (haystack.Ref, error) {
+	return haystack.GenRef(), nil
+}
+
+// nonNamingTags are markers that classify a record without describing it
+var nonNamingTags = map[string]bool{
+	"site": true, "space": true, "equip": true, "point": true, "his": true, "cur": true, "writable": true,
+	"hisTotalized": true,
+}
+
+// defaultNames builds the navName from the marker tags of the child, ordered from the most general to the most
+// specific: sections like `discharge`, then other modifiers, substances, quantities, and finally the point function.
+func (expander *Expander) defaultNames(parent haystack.Dict, child haystack.Dict) (string, string) {
+	navName := ""
+	if str, ok := child.Get("navName").(haystack.Str); ok {
+		navName = str.String()
+	} else {
+		tags := []string{}
+		for _, name := range child.Names() {
+			if _, ok := child.Get(name).(haystack.Marker); ok && !nonNamingTags[name] {
+				tags = append(tags, name)
+			}
+		}
+		sort.Strings(tags)
+		sort.SliceStable(tags, func(i, j int) bool {
+			return expander.nameRank(tags[i]) < expander.nameRank(tags[j])
+		})
+		words := []string{}
+		for _, tag := range tags {
+			words = append(words, titleCase(tag))
+		}
+		navName = strings.Join(words, " ")
+	}
+
+	dis := navName
+	if parentDis, ok := parent.Get("dis").(haystack.Str); ok && parentDis.String() != "" {
+		dis = parentDis.String() + " " + navName
+	}
+	return navName, dis
+}
+
+func (expander *Expander) nameRank(tag string) int {
+	if tag == "sensor" || tag == "cmd" || tag == "sp" {
+		return 4
+	}
+	ns := expander.ns
+	switch {
+	case ns == nil:
+		return 1
+	case ns.Is(tag, "airSection") || ns.Is(tag, "waterSection"):
+		return 0
+	case ns.Is(tag, "quantity"):
+		return 3
+	case ns.Is(tag, "substance"):
+		return 2
+	default:
+		return 1
+	}
+}
+
+// titleCase splits a camel case tag name into capitalized words, like "naturalGas" to "Natural Gas"
+func titleCase(tag string) string {
+	builder := strings.Builder{}
+	for i, char := range tag {
+		if i == 0 {
+			char = unicode.ToUpper(char)
+		} else if unicode.IsUpper(char) {
+			builder.WriteRune(' ')
+		}
+		builder.WriteRune(char)
+	}
+	return builder.String()
+}
@@ -0,0 +1,157 @@
+package template
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/defs"
+	"github.com/stretchr/testify/assert"
+)
+
+func testExpander(t *testing.T, withDefs bool) *Expander {
+	var ns *defs.Namespace
+	if withDefs {
+		var err error
+		ns, err = defs.StandardNamespace()
+		assert.Nil(t, err)
+	}
+	expander := NewExpander(ns)
+	count := 0
+	expander.SetIdGenerator(func() (haystack.Ref, error) {
+		count++
+		return haystack.NewRef("r"+strconv.Itoa(count), ""), nil
+	})
+	return expander
+}
+
+func TestExpander_Expand_defs(t *testing.T) {
+	expander := testExpander(t, true)
+	ahu := haystack.NewDict(map[string]haystack.Val{
+		"id":       haystack.NewRef("ahu1", ""),
+		"dis":      haystack.NewStr("AHU-1"),
+		"ahu":      haystack.NewMarker(),
+		"equip":    haystack.NewMarker(),
+		"siteRef":  haystack.NewRef("site", "HQ"),
+		"spaceRef": haystack.NewRef("floor1", ""),
+	})
+	dicts, err := expander.ExpandDicts(ahu)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(dicts))
+
+	navNames := []string{}
+	for _, dict := range dicts {
+		navName, _ := dict.GetStr("navName")
+		navNames = append(navNames, navName.String())
+	}
+	assert.Equal(t, []string{
+		"Discharge Air Temp Sensor",
+		"Discharge Air Temp Sp",
+		"Return Air Temp Sensor",
+		"Outside Air Temp Sensor",
+		"Discharge Fan Run Cmd",
+	}, navNames)
+
+	first := dicts[0]
+	assert.Equal(t, haystack.NewRef("r1", ""), first.Get("id"))
+	assert.Equal(t, haystack.NewStr("AHU-1 Discharge Air Temp Sensor"), first.Get("dis"))
+	assert.Equal(t, haystack.NewRef("ahu1", "AHU-1"), first.Get("equipRef"))
+	assert.Equal(t, haystack.NewRef("site", "HQ"), first.Get("siteRef"))
+	assert.Equal(t, haystack.NewRef("floor1", ""), first.Get("spaceRef"))
+	assert.Equal(t, haystack.NewMarker(), first.Get("point"))
+
+	grid, err := expander.Expand(ahu)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, grid.RowCount())
+}
+
+func TestExpander_Expand_templates(t *testing.T) {
+	expander := testExpander(t, false)
+	expander.AddTemplate(
+		"pump",
+		haystack.NewDict(map[string]haystack.Val{
+			"run": haystack.NewMarker(), "cmd": haystack.NewMarker(), "point": haystack.NewMarker(),
+			"kind": haystack.NewStr("Bool"),
+		}),
+	)
+	expander.AddTemplate(
+		"plant",
+		haystack.NewDict(map[string]haystack.Val{
+			"pump": haystack.NewMarker(), "equip": haystack.NewMarker(), "navName": haystack.NewStr("P1"),
+		}),
+		haystack.NewDict(map[string]haystack.Val{"plant": haystack.NewMarker(), "equip": haystack.NewMarker()}),
+	)
+	site := haystack.NewDict(map[string]haystack.Val{
+		"id":   haystack.NewRef("site", ""),
+		"site": haystack.NewMarker(),
+	})
+	plant := haystack.NewDict(map[string]haystack.Val{
+		"dis":     haystack.NewStr("Plant"),
+		"plant":   haystack.NewMarker(),
+		"equip":   haystack.NewMarker(),
+		"siteRef": haystack.NewRef("site", ""),
+	})
+	dicts, err := expander.ExpandDicts(site, plant)
+	assert.Nil(t, err)
+
+	// The plant in the plant template is skipped, since it would expand forever
+	zinc := []string{}
+	for _, dict := range dicts {
+		zinc = append(zinc, dict.ToZinc())
+	}
+	assert.Equal(t, []string{
+		"{dis:\"Plant\" equip id:@r1 plant siteRef:@site}",
+		"{dis:\"Plant P1\" equip equipRef:@r1 \"Plant\" id:@r2 navName:\"P1\" pump siteRef:@site}",
+		"{cmd dis:\"Plant P1 Run Cmd\" equipRef:@r2 \"Plant P1\" id:@r3 kind:\"Bool\" navName:\"Run Cmd\" point run siteRef:@site}",
+	}, zinc)
+}
+
+func TestExpander_SetNamer(t *testing.T) {
+	expander := testExpander(t, true)
+	expander.SetNamer(func(parent haystack.Dict, child haystack.Dict) (string, string) {
+		id, _ := child.GetRef("id")
+		return "", "Point " + id.Id()
+	})
+	dicts, err := expander.ExpandDicts(haystack.NewDict(map[string]haystack.Val{
+		"id":    haystack.NewRef("vav", ""),
+		"vav":   haystack.NewMarker(),
+		"equip": haystack.NewMarker(),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(dicts))
+	assert.Equal(t, haystack.NewStr("Point r1"), dicts[0].Get("dis"))
+	assert.True(t, dicts[0].Missing("navName"))
+}
+
+func TestExpander_Expand_errors(t *testing.T) {
+	expander := testExpander(t, true)
+	_, err := expander.Expand(haystack.NewDict(map[string]haystack.Val{"id": haystack.NewStr("ahu")}))
+	assert.NotNil(t, err)
+
+	expander.SetIdGenerator(func() (haystack.Ref, error) {
+		return haystack.Ref{}, errors.New("no ids")
+	})
+	_, err = expander.Expand(haystack.NewDict(map[string]haystack.Val{"ahu": haystack.NewMarker()}))
+	assert.EqualError(t, err, "no ids")
+}
+
+func TestExpander_Expand_genRef(t *testing.T) {
+	expander := NewExpander(nil)
+	expander.AddTemplate("meter", haystack.NewDict(map[string]haystack.Val{
+		"point": haystack.NewMarker(),
+	}))
+	dicts, err := expander.ExpandDicts(haystack.NewDict(map[string]haystack.Val{
+		"meter": haystack.NewMarker(),
+		"equip": haystack.NewMarker(),
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(dicts))
+
+	parentId := dicts[0].Get("id").(haystack.Ref).Id()
+	childId := dicts[1].Get("id").(haystack.Ref).Id()
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{8}$", parentId)
+	assert.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{8}$", childId)
+	// GenRef ids sort in the order they were generated
+	assert.True(t, parentId < childId)
+}
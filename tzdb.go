@@ -0,0 +1,748 @@
+// Code generated by "go run tzgen.go"; DO NOT EDIT.
+
+package haystack
+
+// tzdbVersion is the version of the IANA timezone database the tables were generated from.
+const tzdbVersion = "2025b"
+
+// tzNames maps Haystack timezone names to canonical IANA zone names.
+var tzNames = map[string]string{
+	"Abidjan":        "Africa/Abidjan",
+	"Accra":          "Africa/Accra",
+	"Adak":           "America/Adak",
+	"Addis_Ababa":    "Africa/Addis_Ababa",
+	"Adelaide":       "Australia/Adelaide",
+	"Aden":           "Asia/Aden",
+	"Algiers":        "Africa/Algiers",
+	"Almaty":         "Asia/Almaty",
+	"Amman":          "Asia/Amman",
+	"Amsterdam":      "Europe/Amsterdam",
+	"Anadyr":         "Asia/Anadyr",
+	"Anchorage":      "America/Anchorage",
+	"Andorra":        "Europe/Andorra",
+	"Anguilla":       "America/Anguilla",
+	"Antananarivo":   "Indian/Antananarivo",
+	"Antigua":        "America/Antigua",
+	"Apia":           "Pacific/Apia",
+	"Aqtau":          "Asia/Aqtau",
+	"Aqtobe":         "Asia/Aqtobe",
+	"Araguaina":      "America/Araguaina",
+	"Aruba":          "America/Aruba",
+	"Ashgabat":       "Asia/Ashgabat",
+	"Asmara":         "Africa/Asmara",
+	"Astrakhan":      "Europe/Astrakhan",
+	"Asuncion":       "America/Asuncion",
+	"Athens":         "Europe/Athens",
+	"Atikokan":       "America/Atikokan",
+	"Atyrau":         "Asia/Atyrau",
+	"Auckland":       "Pacific/Auckland",
+	"Azores":         "Atlantic/Azores",
+	"Baghdad":        "Asia/Baghdad",
+	"Bahia":          "America/Bahia",
+	"Bahia_Banderas": "America/Bahia_Banderas",
+	"Bahrain":        "Asia/Bahrain",
+	"Baku":           "Asia/Baku",
+	"Bamako":         "Africa/Bamako",
+	"Bangkok":        "Asia/Bangkok",
+	"Bangui":         "Africa/Bangui",
+	"Banjul":         "Africa/Banjul",
+	"Barbados":       "America/Barbados",
+	"Barnaul":        "Asia/Barnaul",
+	"Beirut":         "Asia/Beirut",
+	"Belem":          "America/Belem",
+	"Belgrade":       "Europe/Belgrade",
+	"Belize":         "America/Belize",
+	"Berlin":         "Europe/Berlin",
+	"Bermuda":        "Atlantic/Bermuda",
+	"Beulah":         "America/North_Dakota/Beulah",
+	"Bishkek":        "Asia/Bishkek",
+	"Bissau":         "Africa/Bissau",
+	"Blanc-Sablon":   "America/Blanc-Sablon",
+	"Blantyre":       "Africa/Blantyre",
+	"Boa_Vista":      "America/Boa_Vista",
+	"Bogota":         "America/Bogota",
+	"Boise":          "America/Boise",
+	"Bougainville":   "Pacific/Bougainville",
+	"Brazzaville":    "Africa/Brazzaville",
+	"Brisbane":       "Australia/Brisbane",
+	"Broken_Hill":    "Australia/Broken_Hill",
+	"Brunei":         "Asia/Brunei",
+	"Brussels":       "Europe/Brussels",
+	"Bucharest":      "Europe/Bucharest",
+	"Budapest":       "Europe/Budapest",
+	"Buenos_Aires":   "America/Argentina/Buenos_Aires",
+	"Bujumbura":      "Africa/Bujumbura",
+	"Cairo":          "Africa/Cairo",
+	"Cambridge_Bay":  "America/Cambridge_Bay",
+	"Campo_Grande":   "America/Campo_Grande",
+	"Canary":         "Atlantic/Canary",
+	"Cancun":         "America/Cancun",
+	"Cape_Verde":     "Atlantic/Cape_Verde",
+	"Caracas":        "America/Caracas",
+	"Casablanca":     "Africa/Casablanca",
+	"Casey":          "Antarctica/Casey",
+	"Catamarca":      "America/Argentina/Catamarca",
+	"Cayenne":        "America/Cayenne",
+	"Cayman":         "America/Cayman",
+	"Center":         "America/North_Dakota/Center",
+	"Ceuta":          "Africa/Ceuta",
+	"Chagos":         "Indian/Chagos",
+	"Chatham":        "Pacific/Chatham",
+	"Chicago":        "America/Chicago",
+	"Chihuahua":      "America/Chihuahua",
+	"Chisinau":       "Europe/Chisinau",
+	"Chita":          "Asia/Chita",
+	"Christmas":      "Indian/Christmas",
+	"Chuuk":          "Pacific/Chuuk",
+	"Ciudad_Juarez":  "America/Ciudad_Juarez",
+	"Cocos":          "Indian/Cocos",
+	"Colombo":        "Asia/Colombo",
+	"Comoro":         "Indian/Comoro",
+	"Conakry":        "Africa/Conakry",
+	"Copenhagen":     "Europe/Copenhagen",
+	"Cordoba":        "America/Argentina/Cordoba",
+	"Costa_Rica":     "America/Costa_Rica",
+	"Coyhaique":      "America/Coyhaique",
+	"Creston":        "America/Creston",
+	"Cuiaba":         "America/Cuiaba",
+	"Curacao":        "America/Curacao",
+	"Dakar":          "Africa/Dakar",
+	"Damascus":       "Asia/Damascus",
+	"Danmarkshavn":   "America/Danmarkshavn",
+	"Dar_es_Salaam":  "Africa/Dar_es_Salaam",
+	"Darwin":         "Australia/Darwin",
+	"Davis":          "Antarctica/Davis",
+	"Dawson":         "America/Dawson",
+	"Dawson_Creek":   "America/Dawson_Creek",
+	"Denver":         "America/Denver",
+	"Detroit":        "America/Detroit",
+	"Dhaka":          "Asia/Dhaka",
+	"Dili":           "Asia/Dili",
+	"Djibouti":       "Africa/Djibouti",
+	"Dominica":       "America/Dominica",
+	"Douala":         "Africa/Douala",
+	"Dubai":          "Asia/Dubai",
+	"Dublin":         "Europe/Dublin",
+	"DumontDUrville": "Antarctica/DumontDUrville",
+	"Dushanbe":       "Asia/Dushanbe",
+	"Easter":         "Pacific/Easter",
+	"Edmonton":       "America/Edmonton",
+	"Efate":          "Pacific/Efate",
+	"Eirunepe":       "America/Eirunepe",
+	"El_Aaiun":       "Africa/El_Aaiun",
+	"El_Salvador":    "America/El_Salvador",
+	"Eucla":          "Australia/Eucla",
+	"Fakaofo":        "Pacific/Fakaofo",
+	"Famagusta":      "Asia/Famagusta",
+	"Faroe":          "Atlantic/Faroe",
+	"Fiji":           "Pacific/Fiji",
+	"Fort_Nelson":    "America/Fort_Nelson",
+	"Fortaleza":      "America/Fortaleza",
+	"Freetown":       "Africa/Freetown",
+	"Funafuti":       "Pacific/Funafuti",
+	"GMT":            "Etc/GMT",
+	"GMT+1":          "Etc/GMT+1",
+	"GMT+10":         "Etc/GMT+10",
+	"GMT+11":         "Etc/GMT+11",
+	"GMT+12":         "Etc/GMT+12",
+	"GMT+2":          "Etc/GMT+2",
+	"GMT+3":          "Etc/GMT+3",
+	"GMT+4":          "Etc/GMT+4",
+	"GMT+5":          "Etc/GMT+5",
+	"GMT+6":          "Etc/GMT+6",
+	"GMT+7":          "Etc/GMT+7",
+	"GMT+8":          "Etc/GMT+8",
+	"GMT+9":          "Etc/GMT+9",
+	"GMT-1":          "Etc/GMT-1",
+	"GMT-10":         "Etc/GMT-10",
+	"GMT-11":         "Etc/GMT-11",
+	"GMT-12":         "Etc/GMT-12",
+	"GMT-13":         "Etc/GMT-13",
+	"GMT-14":         "Etc/GMT-14",
+	"GMT-2":          "Etc/GMT-2",
+	"GMT-3":          "Etc/GMT-3",
+	"GMT-4":          "Etc/GMT-4",
+	"GMT-5":          "Etc/GMT-5",
+	"GMT-6":          "Etc/GMT-6",
+	"GMT-7":          "Etc/GMT-7",
+	"GMT-8":          "Etc/GMT-8",
+	"GMT-9":          "Etc/GMT-9",
+	"Gaborone":       "Africa/Gaborone",
+	"Galapagos":      "Pacific/Galapagos",
+	"Gambier":        "Pacific/Gambier",
+	"Gaza":           "Asia/Gaza",
+	"Gibraltar":      "Europe/Gibraltar",
+	"Glace_Bay":      "America/Glace_Bay",
+	"Goose_Bay":      "America/Goose_Bay",
+	"Grand_Turk":     "America/Grand_Turk",
+	"Grenada":        "America/Grenada",
+	"Guadalcanal":    "Pacific/Guadalcanal",
+	"Guadeloupe":     "America/Guadeloupe",
+	"Guam":           "Pacific/Guam",
+	"Guatemala":      "America/Guatemala",
+	"Guayaquil":      "America/Guayaquil",
+	"Guernsey":       "Europe/Guernsey",
+	"Guyana":         "America/Guyana",
+	"Halifax":        "America/Halifax",
+	"Harare":         "Africa/Harare",
+	"Havana":         "America/Havana",
+	"Hebron":         "Asia/Hebron",
+	"Helsinki":       "Europe/Helsinki",
+	"Hermosillo":     "America/Hermosillo",
+	"Ho_Chi_Minh":    "Asia/Ho_Chi_Minh",
+	"Hobart":         "Australia/Hobart",
+	"Hong_Kong":      "Asia/Hong_Kong",
+	"Honolulu":       "Pacific/Honolulu",
+	"Hovd":           "Asia/Hovd",
+	"Indianapolis":   "America/Indiana/Indianapolis",
+	"Inuvik":         "America/Inuvik",
+	"Iqaluit":        "America/Iqaluit",
+	"Irkutsk":        "Asia/Irkutsk",
+	"Isle_of_Man":    "Europe/Isle_of_Man",
+	"Istanbul":       "Europe/Istanbul",
+	"Jakarta":        "Asia/Jakarta",
+	"Jamaica":        "America/Jamaica",
+	"Jayapura":       "Asia/Jayapura",
+	"Jersey":         "Europe/Jersey",
+	"Jerusalem":      "Asia/Jerusalem",
+	"Johannesburg":   "Africa/Johannesburg",
+	"Juba":           "Africa/Juba",
+	"Jujuy":          "America/Argentina/Jujuy",
+	"Juneau":         "America/Juneau",
+	"Kabul":          "Asia/Kabul",
+	"Kaliningrad":    "Europe/Kaliningrad",
+	"Kamchatka":      "Asia/Kamchatka",
+	"Kampala":        "Africa/Kampala",
+	"Kanton":         "Pacific/Kanton",
+	"Karachi":        "Asia/Karachi",
+	"Kathmandu":      "Asia/Kathmandu",
+	"Kerguelen":      "Indian/Kerguelen",
+	"Khandyga":       "Asia/Khandyga",
+	"Khartoum":       "Africa/Khartoum",
+	"Kigali":         "Africa/Kigali",
+	"Kinshasa":       "Africa/Kinshasa",
+	"Kiritimati":     "Pacific/Kiritimati",
+	"Kirov":          "Europe/Kirov",
+	"Knox":           "America/Indiana/Knox",
+	"Kolkata":        "Asia/Kolkata",
+	"Kosrae":         "Pacific/Kosrae",
+	"Krasnoyarsk":    "Asia/Krasnoyarsk",
+	"Kuala_Lumpur":   "Asia/Kuala_Lumpur",
+	"Kuching":        "Asia/Kuching",
+	"Kuwait":         "Asia/Kuwait",
+	"Kwajalein":      "Pacific/Kwajalein",
+	"Kyiv":           "Europe/Kyiv",
+	"La_Paz":         "America/La_Paz",
+	"La_Rioja":       "America/Argentina/La_Rioja",
+	"Lagos":          "Africa/Lagos",
+	"Libreville":     "Africa/Libreville",
+	"Lima":           "America/Lima",
+	"Lindeman":       "Australia/Lindeman",
+	"Lisbon":         "Europe/Lisbon",
+	"Ljubljana":      "Europe/Ljubljana",
+	"Lome":           "Africa/Lome",
+	"London":         "Europe/London",
+	"Lord_Howe":      "Australia/Lord_Howe",
+	"Los_Angeles":    "America/Los_Angeles",
+	"Louisville":     "America/Kentucky/Louisville",
+	"Luanda":         "Africa/Luanda",
+	"Lubumbashi":     "Africa/Lubumbashi",
+	"Lusaka":         "Africa/Lusaka",
+	"Luxembourg":     "Europe/Luxembourg",
+	"Macau":          "Asia/Macau",
+	"Maceio":         "America/Maceio",
+	"Macquarie":      "Antarctica/Macquarie",
+	"Madeira":        "Atlantic/Madeira",
+	"Madrid":         "Europe/Madrid",
+	"Magadan":        "Asia/Magadan",
+	"Mahe":           "Indian/Mahe",
+	"Majuro":         "Pacific/Majuro",
+	"Makassar":       "Asia/Makassar",
+	"Malabo":         "Africa/Malabo",
+	"Maldives":       "Indian/Maldives",
+	"Malta":          "Europe/Malta",
+	"Managua":        "America/Managua",
+	"Manaus":         "America/Manaus",
+	"Manila":         "Asia/Manila",
+	"Maputo":         "Africa/Maputo",
+	"Marengo":        "America/Indiana/Marengo",
+	"Marquesas":      "Pacific/Marquesas",
+	"Martinique":     "America/Martinique",
+	"Maseru":         "Africa/Maseru",
+	"Matamoros":      "America/Matamoros",
+	"Mauritius":      "Indian/Mauritius",
+	"Mawson":         "Antarctica/Mawson",
+	"Mayotte":        "Indian/Mayotte",
+	"Mazatlan":       "America/Mazatlan",
+	"Mbabane":        "Africa/Mbabane",
+	"McMurdo":        "Antarctica/McMurdo",
+	"Melbourne":      "Australia/Melbourne",
+	"Mendoza":        "America/Argentina/Mendoza",
+	"Menominee":      "America/Menominee",
+	"Merida":         "America/Merida",
+	"Metlakatla":     "America/Metlakatla",
+	"Mexico_City":    "America/Mexico_City",
+	"Midway":         "Pacific/Midway",
+	"Minsk":          "Europe/Minsk",
+	"Miquelon":       "America/Miquelon",
+	"Mogadishu":      "Africa/Mogadishu",
+	"Monaco":         "Europe/Monaco",
+	"Moncton":        "America/Moncton",
+	"Monrovia":       "Africa/Monrovia",
+	"Monterrey":      "America/Monterrey",
+	"Montevideo":     "America/Montevideo",
+	"Monticello":     "America/Kentucky/Monticello",
+	"Montserrat":     "America/Montserrat",
+	"Moscow":         "Europe/Moscow",
+	"Muscat":         "Asia/Muscat",
+	"Nairobi":        "Africa/Nairobi",
+	"Nassau":         "America/Nassau",
+	"Nauru":          "Pacific/Nauru",
+	"Ndjamena":       "Africa/Ndjamena",
+	"New_Salem":      "America/North_Dakota/New_Salem",
+	"New_York":       "America/New_York",
+	"Niamey":         "Africa/Niamey",
+	"Nicosia":        "Asia/Nicosia",
+	"Niue":           "Pacific/Niue",
+	"Nome":           "America/Nome",
+	"Norfolk":        "Pacific/Norfolk",
+	"Noronha":        "America/Noronha",
+	"Nouakchott":     "Africa/Nouakchott",
+	"Noumea":         "Pacific/Noumea",
+	"Novokuznetsk":   "Asia/Novokuznetsk",
+	"Novosibirsk":    "Asia/Novosibirsk",
+	"Nuuk":           "America/Nuuk",
+	"Ojinaga":        "America/Ojinaga",
+	"Omsk":           "Asia/Omsk",
+	"Oral":           "Asia/Oral",
+	"Oslo":           "Europe/Oslo",
+	"Ouagadougou":    "Africa/Ouagadougou",
+	"Pago_Pago":      "Pacific/Pago_Pago",
+	"Palau":          "Pacific/Palau",
+	"Palmer":         "Antarctica/Palmer",
+	"Panama":         "America/Panama",
+	"Paramaribo":     "America/Paramaribo",
+	"Paris":          "Europe/Paris",
+	"Perth":          "Australia/Perth",
+	"Petersburg":     "America/Indiana/Petersburg",
+	"Phnom_Penh":     "Asia/Phnom_Penh",
+	"Phoenix":        "America/Phoenix",
+	"Pitcairn":       "Pacific/Pitcairn",
+	"Pohnpei":        "Pacific/Pohnpei",
+	"Pontianak":      "Asia/Pontianak",
+	"Port-au-Prince": "America/Port-au-Prince",
+	"Port_Moresby":   "Pacific/Port_Moresby",
+	"Port_of_Spain":  "America/Port_of_Spain",
+	"Porto-Novo":     "Africa/Porto-Novo",
+	"Porto_Velho":    "America/Porto_Velho",
+	"Prague":         "Europe/Prague",
+	"Puerto_Rico":    "America/Puerto_Rico",
+	"Punta_Arenas":   "America/Punta_Arenas",
+	"Pyongyang":      "Asia/Pyongyang",
+	"Qatar":          "Asia/Qatar",
+	"Qostanay":       "Asia/Qostanay",
+	"Qyzylorda":      "Asia/Qyzylorda",
+	"Rankin_Inlet":   "America/Rankin_Inlet",
+	"Rarotonga":      "Pacific/Rarotonga",
+	"Recife":         "America/Recife",
+	"Regina":         "America/Regina",
+	"Resolute":       "America/Resolute",
+	"Reunion":        "Indian/Reunion",
+	"Reykjavik":      "Atlantic/Reykjavik",
+	"Riga":           "Europe/Riga",
+	"Rio_Branco":     "America/Rio_Branco",
+	"Rio_Gallegos":   "America/Argentina/Rio_Gallegos",
+	"Riyadh":         "Asia/Riyadh",
+	"Rome":           "Europe/Rome",
+	"Rothera":        "Antarctica/Rothera",
+	"Saipan":         "Pacific/Saipan",
+	"Sakhalin":       "Asia/Sakhalin",
+	"Salta":          "America/Argentina/Salta",
+	"Samara":         "Europe/Samara",
+	"Samarkand":      "Asia/Samarkand",
+	"San_Juan":       "America/Argentina/San_Juan",
+	"San_Luis":       "America/Argentina/San_Luis",
+	"Santarem":       "America/Santarem",
+	"Santiago":       "America/Santiago",
+	"Santo_Domingo":  "America/Santo_Domingo",
+	"Sao_Paulo":      "America/Sao_Paulo",
+	"Sao_Tome":       "Africa/Sao_Tome",
+	"Sarajevo":       "Europe/Sarajevo",
+	"Saratov":        "Europe/Saratov",
+	"Scoresbysund":   "America/Scoresbysund",
+	"Seoul":          "Asia/Seoul",
+	"Shanghai":       "Asia/Shanghai",
+	"Simferopol":     "Europe/Simferopol",
+	"Singapore":      "Asia/Singapore",
+	"Sitka":          "America/Sitka",
+	"Skopje":         "Europe/Skopje",
+	"Sofia":          "Europe/Sofia",
+	"South_Georgia":  "Atlantic/South_Georgia",
+	"Srednekolymsk":  "Asia/Srednekolymsk",
+	"St_Helena":      "Atlantic/St_Helena",
+	"St_Johns":       "America/St_Johns",
+	"St_Kitts":       "America/St_Kitts",
+	"St_Lucia":       "America/St_Lucia",
+	"St_Thomas":      "America/St_Thomas",
+	"St_Vincent":     "America/St_Vincent",
+	"Stanley":        "Atlantic/Stanley",
+	"Stockholm":      "Europe/Stockholm",
+	"Swift_Current":  "America/Swift_Current",
+	"Sydney":         "Australia/Sydney",
+	"Syowa":          "Antarctica/Syowa",
+	"Tahiti":         "Pacific/Tahiti",
+	"Taipei":         "Asia/Taipei",
+	"Tallinn":        "Europe/Tallinn",
+	"Tarawa":         "Pacific/Tarawa",
+	"Tashkent":       "Asia/Tashkent",
+	"Tbilisi":        "Asia/Tbilisi",
+	"Tegucigalpa":    "America/Tegucigalpa",
+	"Tehran":         "Asia/Tehran",
+	"Tell_City":      "America/Indiana/Tell_City",
+	"Thimphu":        "Asia/Thimphu",
+	"Thule":          "America/Thule",
+	"Tijuana":        "America/Tijuana",
+	"Tirane":         "Europe/Tirane",
+	"Tokyo":          "Asia/Tokyo",
+	"Tomsk":          "Asia/Tomsk",
+	"Tongatapu":      "Pacific/Tongatapu",
+	"Toronto":        "America/Toronto",
+	"Tortola":        "America/Tortola",
+	"Tripoli":        "Africa/Tripoli",
+	"Troll":          "Antarctica/Troll",
+	"Tucuman":        "America/Argentina/Tucuman",
+	"Tunis":          "Africa/Tunis",
+	"UTC":            "Etc/UTC",
+	"Ulaanbaatar":    "Asia/Ulaanbaatar",
+	"Ulyanovsk":      "Europe/Ulyanovsk",
+	"Urumqi":         "Asia/Urumqi",
+	"Ushuaia":        "America/Argentina/Ushuaia",
+	"Ust-Nera":       "Asia/Ust-Nera",
+	"Vaduz":          "Europe/Vaduz",
+	"Vancouver":      "America/Vancouver",
+	"Vevay":          "America/Indiana/Vevay",
+	"Vienna":         "Europe/Vienna",
+	"Vientiane":      "Asia/Vientiane",
+	"Vilnius":        "Europe/Vilnius",
+	"Vincennes":      "America/Indiana/Vincennes",
+	"Vladivostok":    "Asia/Vladivostok",
+	"Volgograd":      "Europe/Volgograd",
+	"Vostok":         "Antarctica/Vostok",
+	"Wake":           "Pacific/Wake",
+	"Wallis":         "Pacific/Wallis",
+	"Warsaw":         "Europe/Warsaw",
+	"Whitehorse":     "America/Whitehorse",
+	"Winamac":        "America/Indiana/Winamac",
+	"Windhoek":       "Africa/Windhoek",
+	"Winnipeg":       "America/Winnipeg",
+	"Yakutat":        "America/Yakutat",
+	"Yakutsk":        "Asia/Yakutsk",
+	"Yangon":         "Asia/Yangon",
+	"Yekaterinburg":  "Asia/Yekaterinburg",
+	"Yerevan":        "Asia/Yerevan",
+	"Zagreb":         "Europe/Zagreb",
+	"Zurich":         "Europe/Zurich",
+}
+
+// tzAliases maps IANA link names, and the unambiguous city portion of those links, to canonical zone names.
+var tzAliases = map[string]string{
+	"ACT":                              "Australia/Sydney",
+	"Africa/Asmera":                    "Africa/Nairobi",
+	"Africa/Timbuktu":                  "Africa/Abidjan",
+	"America/Argentina/ComodRivadavia": "America/Argentina/Catamarca",
+	"America/Atka":                     "America/Adak",
+	"America/Buenos_Aires":             "America/Argentina/Buenos_Aires",
+	"America/Catamarca":                "America/Argentina/Catamarca",
+	"America/Coral_Harbour":            "America/Panama",
+	"America/Cordoba":                  "America/Argentina/Cordoba",
+	"America/Ensenada":                 "America/Tijuana",
+	"America/Fort_Wayne":               "America/Indiana/Indianapolis",
+	"America/Godthab":                  "America/Nuuk",
+	"America/Indianapolis":             "America/Indiana/Indianapolis",
+	"America/Jujuy":                    "America/Argentina/Jujuy",
+	"America/Knox_IN":                  "America/Indiana/Knox",
+	"America/Kralendijk":               "America/Puerto_Rico",
+	"America/Louisville":               "America/Kentucky/Louisville",
+	"America/Lower_Princes":            "America/Puerto_Rico",
+	"America/Marigot":                  "America/Puerto_Rico",
+	"America/Mendoza":                  "America/Argentina/Mendoza",
+	"America/Montreal":                 "America/Toronto",
+	"America/Nipigon":                  "America/Toronto",
+	"America/Pangnirtung":              "America/Iqaluit",
+	"America/Porto_Acre":               "America/Rio_Branco",
+	"America/Rainy_River":              "America/Winnipeg",
+	"America/Rosario":                  "America/Argentina/Cordoba",
+	"America/Santa_Isabel":             "America/Tijuana",
+	"America/Shiprock":                 "America/Denver",
+	"America/St_Barthelemy":            "America/Puerto_Rico",
+	"America/Thunder_Bay":              "America/Toronto",
+	"America/Virgin":                   "America/Puerto_Rico",
+	"America/Yellowknife":              "America/Edmonton",
+	"Antarctica/South_Pole":            "Pacific/Auckland",
+	"Arctic/Longyearbyen":              "Europe/Berlin",
+	"Ashkhabad":                        "Asia/Ashgabat",
+	"Asia/Ashkhabad":                   "Asia/Ashgabat",
+	"Asia/Calcutta":                    "Asia/Kolkata",
+	"Asia/Choibalsan":                  "Asia/Ulaanbaatar",
+	"Asia/Chongqing":                   "Asia/Shanghai",
+	"Asia/Chungking":                   "Asia/Shanghai",
+	"Asia/Dacca":                       "Asia/Dhaka",
+	"Asia/Harbin":                      "Asia/Shanghai",
+	"Asia/Istanbul":                    "Europe/Istanbul",
+	"Asia/Kashgar":                     "Asia/Urumqi",
+	"Asia/Katmandu":                    "Asia/Kathmandu",
+	"Asia/Macao":                       "Asia/Macau",
+	"Asia/Rangoon":                     "Asia/Yangon",
+	"Asia/Saigon":                      "Asia/Ho_Chi_Minh",
+	"Asia/Tel_Aviv":                    "Asia/Jerusalem",
+	"Asia/Thimbu":                      "Asia/Thimphu",
+	"Asia/Ujung_Pandang":               "Asia/Makassar",
+	"Asia/Ulan_Bator":                  "Asia/Ulaanbaatar",
+	"Asmera":                           "Africa/Nairobi",
+	"Atka":                             "America/Adak",
+	"Atlantic/Faeroe":                  "Atlantic/Faroe",
+	"Atlantic/Jan_Mayen":               "Europe/Berlin",
+	"Australia/ACT":                    "Australia/Sydney",
+	"Australia/Canberra":               "Australia/Sydney",
+	"Australia/Currie":                 "Australia/Hobart",
+	"Australia/LHI":                    "Australia/Lord_Howe",
+	"Australia/NSW":                    "Australia/Sydney",
+	"Australia/North":                  "Australia/Darwin",
+	"Australia/Queensland":             "Australia/Brisbane",
+	"Australia/South":                  "Australia/Adelaide",
+	"Australia/Tasmania":               "Australia/Hobart",
+	"Australia/Victoria":               "Australia/Melbourne",
+	"Australia/West":                   "Australia/Perth",
+	"Australia/Yancowinna":             "Australia/Broken_Hill",
+	"Belfast":                          "Europe/London",
+	"Bratislava":                       "Europe/Prague",
+	"Brazil/Acre":                      "America/Rio_Branco",
+	"Brazil/DeNoronha":                 "America/Noronha",
+	"Brazil/East":                      "America/Sao_Paulo",
+	"Brazil/West":                      "America/Manaus",
+	"Busingen":                         "Europe/Zurich",
+	"Calcutta":                         "Asia/Kolkata",
+	"Canada/Atlantic":                  "America/Halifax",
+	"Canada/Central":                   "America/Winnipeg",
+	"Canada/Eastern":                   "America/Toronto",
+	"Canada/Mountain":                  "America/Edmonton",
+	"Canada/Newfoundland":              "America/St_Johns",
+	"Canada/Pacific":                   "America/Vancouver",
+	"Canada/Saskatchewan":              "America/Regina",
+	"Canada/Yukon":                     "America/Whitehorse",
+	"Canberra":                         "Australia/Sydney",
+	"Chile/Continental":                "America/Santiago",
+	"Chile/EasterIsland":               "Pacific/Easter",
+	"Choibalsan":                       "Asia/Ulaanbaatar",
+	"Chongqing":                        "Asia/Shanghai",
+	"Chungking":                        "Asia/Shanghai",
+	"ComodRivadavia":                   "America/Argentina/Catamarca",
+	"Coral_Harbour":                    "America/Panama",
+	"Cuba":                             "America/Havana",
+	"Currie":                           "Australia/Hobart",
+	"Dacca":                            "Asia/Dhaka",
+	"Egypt":                            "Africa/Cairo",
+	"Eire":                             "Europe/Dublin",
+	"Enderbury":                        "Pacific/Kanton",
+	"Ensenada":                         "America/Tijuana",
+	"Etc/GMT+0":                        "Etc/GMT",
+	"Etc/GMT-0":                        "Etc/GMT",
+	"Etc/GMT0":                         "Etc/GMT",
+	"Etc/Greenwich":                    "Etc/GMT",
+	"Etc/UCT":                          "Etc/UTC",
+	"Etc/Universal":                    "Etc/UTC",
+	"Etc/Zulu":                         "Etc/UTC",
+	"Europe/Belfast":                   "Europe/London",
+	"Europe/Bratislava":                "Europe/Prague",
+	"Europe/Busingen":                  "Europe/Zurich",
+	"Europe/Kiev":                      "Europe/Kyiv",
+	"Europe/Mariehamn":                 "Europe/Helsinki",
+	"Europe/Nicosia":                   "Asia/Nicosia",
+	"Europe/Podgorica":                 "Europe/Belgrade",
+	"Europe/San_Marino":                "Europe/Rome",
+	"Europe/Tiraspol":                  "Europe/Chisinau",
+	"Europe/Uzhgorod":                  "Europe/Kyiv",
+	"Europe/Vatican":                   "Europe/Rome",
+	"Europe/Zaporozhye":                "Europe/Kyiv",
+	"Faeroe":                           "Atlantic/Faroe",
+	"Fort_Wayne":                       "America/Indiana/Indianapolis",
+	"GB":                               "Europe/London",
+	"GB-Eire":                          "Europe/London",
+	"GMT":                              "Etc/GMT",
+	"GMT+0":                            "Etc/GMT",
+	"GMT-0":                            "Etc/GMT",
+	"GMT0":                             "Etc/GMT",
+	"Godthab":                          "America/Nuuk",
+	"Greenwich":                        "Etc/GMT",
+	"Harbin":                           "Asia/Shanghai",
+	"Hongkong":                         "Asia/Hong_Kong",
+	"Iceland":                          "Africa/Abidjan",
+	"Iran":                             "Asia/Tehran",
+	"Israel":                           "Asia/Jerusalem",
+	"Jamaica":                          "America/Jamaica",
+	"Jan_Mayen":                        "Europe/Berlin",
+	"Japan":                            "Asia/Tokyo",
+	"Johnston":                         "Pacific/Honolulu",
+	"Kashgar":                          "Asia/Urumqi",
+	"Katmandu":                         "Asia/Kathmandu",
+	"Kiev":                             "Europe/Kyiv",
+	"Knox_IN":                          "America/Indiana/Knox",
+	"Kralendijk":                       "America/Puerto_Rico",
+	"Kwajalein":                        "Pacific/Kwajalein",
+	"LHI":                              "Australia/Lord_Howe",
+	"Libya":                            "Africa/Tripoli",
+	"Lower_Princes":                    "America/Puerto_Rico",
+	"Macao":                            "Asia/Macau",
+	"Mariehamn":                        "Europe/Helsinki",
+	"Marigot":                          "America/Puerto_Rico",
+	"Mexico/BajaNorte":                 "America/Tijuana",
+	"Mexico/BajaSur":                   "America/Mazatlan",
+	"Mexico/General":                   "America/Mexico_City",
+	"Montreal":                         "America/Toronto",
+	"NSW":                              "Australia/Sydney",
+	"NZ":                               "Pacific/Auckland",
+	"NZ-CHAT":                          "Pacific/Chatham",
+	"Navajo":                           "America/Denver",
+	"Nipigon":                          "America/Toronto",
+	"North":                            "Australia/Darwin",
+	"PRC":                              "Asia/Shanghai",
+	"Pacific/Enderbury":                "Pacific/Kanton",
+	"Pacific/Johnston":                 "Pacific/Honolulu",
+	"Pacific/Ponape":                   "Pacific/Guadalcanal",
+	"Pacific/Samoa":                    "Pacific/Pago_Pago",
+	"Pacific/Truk":                     "Pacific/Port_Moresby",
+	"Pacific/Yap":                      "Pacific/Port_Moresby",
+	"Pangnirtung":                      "America/Iqaluit",
+	"Podgorica":                        "Europe/Belgrade",
+	"Poland":                           "Europe/Warsaw",
+	"Ponape":                           "Pacific/Guadalcanal",
+	"Porto_Acre":                       "America/Rio_Branco",
+	"Portugal":                         "Europe/Lisbon",
+	"Queensland":                       "Australia/Brisbane",
+	"ROC":                              "Asia/Taipei",
+	"ROK":                              "Asia/Seoul",
+	"Rainy_River":                      "America/Winnipeg",
+	"Rangoon":                          "Asia/Yangon",
+	"Rosario":                          "America/Argentina/Cordoba",
+	"Saigon":                           "Asia/Ho_Chi_Minh",
+	"Samoa":                            "Pacific/Pago_Pago",
+	"San_Marino":                       "Europe/Rome",
+	"Santa_Isabel":                     "America/Tijuana",
+	"Shiprock":                         "America/Denver",
+	"Singapore":                        "Asia/Singapore",
+	"South":                            "Australia/Adelaide",
+	"South_Pole":                       "Pacific/Auckland",
+	"St_Barthelemy":                    "America/Puerto_Rico",
+	"Tasmania":                         "Australia/Hobart",
+	"Tel_Aviv":                         "Asia/Jerusalem",
+	"Thimbu":                           "Asia/Thimphu",
+	"Thunder_Bay":                      "America/Toronto",
+	"Timbuktu":                         "Africa/Abidjan",
+	"Tiraspol":                         "Europe/Chisinau",
+	"Truk":                             "Pacific/Port_Moresby",
+	"Turkey":                           "Europe/Istanbul",
+	"UCT":                              "Etc/UTC",
+	"US/Alaska":                        "America/Anchorage",
+	"US/Aleutian":                      "America/Adak",
+	"US/Arizona":                       "America/Phoenix",
+	"US/Central":                       "America/Chicago",
+	"US/East-Indiana":                  "America/Indiana/Indianapolis",
+	"US/Eastern":                       "America/New_York",
+	"US/Hawaii":                        "Pacific/Honolulu",
+	"US/Indiana-Starke":                "America/Indiana/Knox",
+	"US/Michigan":                      "America/Detroit",
+	"US/Mountain":                      "America/Denver",
+	"US/Pacific":                       "America/Los_Angeles",
+	"US/Samoa":                         "Pacific/Pago_Pago",
+	"UTC":                              "Etc/UTC",
+	"Ujung_Pandang":                    "Asia/Makassar",
+	"Ulan_Bator":                       "Asia/Ulaanbaatar",
+	"Universal":                        "Etc/UTC",
+	"Uzhgorod":                         "Europe/Kyiv",
+	"Vatican":                          "Europe/Rome",
+	"Victoria":                         "Australia/Melbourne",
+	"Virgin":                           "America/Puerto_Rico",
+	"W-SU":                             "Europe/Moscow",
+	"West":                             "Australia/Perth",
+	"Yancowinna":                       "Australia/Broken_Hill",
+	"Yap":                              "Pacific/Port_Moresby",
+	"Yellowknife":                      "America/Edmonton",
+	"Zaporozhye":                       "Europe/Kyiv",
+	"Zulu":                             "Etc/UTC",
+}
+
+// tzLinkNames maps the Haystack names of link zones to their IANA link names.
+var tzLinkNames = map[string]string{
+	"ACT":            "Australia/ACT",
+	"Ashkhabad":      "Asia/Ashkhabad",
+	"Asmera":         "Africa/Asmera",
+	"Atka":           "America/Atka",
+	"Belfast":        "Europe/Belfast",
+	"Bratislava":     "Europe/Bratislava",
+	"Busingen":       "Europe/Busingen",
+	"Calcutta":       "Asia/Calcutta",
+	"Canberra":       "Australia/Canberra",
+	"Choibalsan":     "Asia/Choibalsan",
+	"Chongqing":      "Asia/Chongqing",
+	"Chungking":      "Asia/Chungking",
+	"ComodRivadavia": "America/Argentina/ComodRivadavia",
+	"Coral_Harbour":  "America/Coral_Harbour",
+	"Currie":         "Australia/Currie",
+	"Dacca":          "Asia/Dacca",
+	"Enderbury":      "Pacific/Enderbury",
+	"Ensenada":       "America/Ensenada",
+	"Faeroe":         "Atlantic/Faeroe",
+	"Fort_Wayne":     "America/Fort_Wayne",
+	"Godthab":        "America/Godthab",
+	"Harbin":         "Asia/Harbin",
+	"Jan_Mayen":      "Atlantic/Jan_Mayen",
+	"Johnston":       "Pacific/Johnston",
+	"Kashgar":        "Asia/Kashgar",
+	"Katmandu":       "Asia/Katmandu",
+	"Kiev":           "Europe/Kiev",
+	"Knox_IN":        "America/Knox_IN",
+	"Kralendijk":     "America/Kralendijk",
+	"LHI":            "Australia/LHI",
+	"Lower_Princes":  "America/Lower_Princes",
+	"Macao":          "Asia/Macao",
+	"Mariehamn":      "Europe/Mariehamn",
+	"Marigot":        "America/Marigot",
+	"Montreal":       "America/Montreal",
+	"NSW":            "Australia/NSW",
+	"Nipigon":        "America/Nipigon",
+	"North":          "Australia/North",
+	"Pangnirtung":    "America/Pangnirtung",
+	"Podgorica":      "Europe/Podgorica",
+	"Ponape":         "Pacific/Ponape",
+	"Porto_Acre":     "America/Porto_Acre",
+	"Queensland":     "Australia/Queensland",
+	"Rainy_River":    "America/Rainy_River",
+	"Rangoon":        "Asia/Rangoon",
+	"Rosario":        "America/Rosario",
+	"Saigon":         "Asia/Saigon",
+	"Samoa":          "Pacific/Samoa",
+	"San_Marino":     "Europe/San_Marino",
+	"Santa_Isabel":   "America/Santa_Isabel",
+	"Shiprock":       "America/Shiprock",
+	"South":          "Australia/South",
+	"South_Pole":     "Antarctica/South_Pole",
+	"St_Barthelemy":  "America/St_Barthelemy",
+	"Tasmania":       "Australia/Tasmania",
+	"Tel_Aviv":       "Asia/Tel_Aviv",
+	"Thimbu":         "Asia/Thimbu",
+	"Thunder_Bay":    "America/Thunder_Bay",
+	"Timbuktu":       "Africa/Timbuktu",
+	"Tiraspol":       "Europe/Tiraspol",
+	"Truk":           "Pacific/Truk",
+	"Ujung_Pandang":  "Asia/Ujung_Pandang",
+	"Ulan_Bator":     "Asia/Ulan_Bator",
+	"Uzhgorod":       "Europe/Uzhgorod",
+	"Vatican":        "Europe/Vatican",
+	"Victoria":       "Australia/Victoria",
+	"Virgin":         "America/Virgin",
+	"West":           "Australia/West",
+	"Yancowinna":     "Australia/Yancowinna",
+	"Yap":            "Pacific/Yap",
+	"Yellowknife":    "America/Yellowknife",
+	"Zaporozhye":     "Europe/Zaporozhye",
+}
@@ -0,0 +1,173 @@
+//go:build ignore
+// +build ignore
+
+// tzgen generates the Haystack timezone tables in tzdb.go from the IANA timezone database. It reads the compact
+// 'tzdata.zi' zic input file, which is distributed with the tz database and installed by most tzdata packages.
+//
+// Usage:
+//
+//	go run tzgen.go [-in /usr/share/zoneinfo/tzdata.zi] [-out tzdb.go]
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Only zones in these regions are included, matching the Haystack/Fantom timezone database.
+var regions = map[string]bool{
+	"Africa":     true,
+	"America":    true,
+	"Antarctica": true,
+	"Asia":       true,
+	"Atlantic":   true,
+	"Australia":  true,
+	"Etc":        true,
+	"Europe":     true,
+	"Indian":     true,
+	"Pacific":    true,
+}
+
+func main() {
+	in := flag.String("in", "/usr/share/zoneinfo/tzdata.zi", "path to the tzdata.zi file")
+	out := flag.String("out", "tzdb.go", "path of the generated Go file")
+	flag.Parse()
+
+	file, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	version := ""
+	zones := []string{}
+	links := map[string]string{} // link name -> target
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# version ") {
+			version = strings.TrimPrefix(line, "# version ")
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		switch fields[0] {
+		case "Z":
+			zones = append(zones, fields[1])
+		case "L":
+			links[fields[2]] = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	// Haystack names are the city portion of the canonical zone names. They must be unique.
+	names := map[string]string{} // haystack name -> canonical
+	canonical := map[string]bool{}
+	for _, zone := range zones {
+		if !inRegion(zone) {
+			continue
+		}
+		name := shortName(zone)
+		if existing, ok := names[name]; ok {
+			log.Fatalf("timezone name collision: %s and %s both map to %s", existing, zone, name)
+		}
+		names[name] = zone
+		canonical[zone] = true
+	}
+
+	// Aliases resolve link names (both full names and their city portions) to canonical zones. Short aliases that
+	// collide with a canonical name, or that are ambiguous between links, are dropped.
+	//
+	// The unambiguous city portions of links in the Haystack regions are Haystack names in their own right, like
+	// "Kralendijk" for "America/Kralendijk", so they are also kept with their full link names. The Etc links are
+	// only other names for UTC, and are left to resolve to it.
+	aliases := map[string]string{}
+	linkNames := map[string]string{} // haystack name -> link
+	ambiguous := map[string]bool{}
+	for link, target := range links {
+		for !canonical[target] && links[target] != "" {
+			target = links[target]
+		}
+		if !canonical[target] {
+			continue
+		}
+		aliases[link] = target
+		if !inRegion(link) {
+			continue
+		}
+		short := shortName(link)
+		if _, ok := names[short]; ok {
+			continue
+		}
+		if existing, ok := linkNames[short]; ok && existing != link {
+			ambiguous[short] = true
+		}
+		aliases[short] = target
+		if !strings.HasPrefix(link, "Etc/") {
+			linkNames[short] = link
+		}
+	}
+	for short := range ambiguous {
+		delete(aliases, short)
+		delete(linkNames, short)
+	}
+
+	buf := bytes.Buffer{}
+	fmt.Fprintf(&buf, "// Code generated by \"go run tzgen.go\"; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package haystack\n\n")
+	fmt.Fprintf(&buf, "// tzdbVersion is the version of the IANA timezone database the tables were generated from.\n")
+	fmt.Fprintf(&buf, "const tzdbVersion = %q\n\n", version)
+	fmt.Fprintf(&buf, "// tzNames maps Haystack timezone names to canonical IANA zone names.\n")
+	fmt.Fprintf(&buf, "var tzNames = map[string]string{\n")
+	writeMap(&buf, names)
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// tzAliases maps IANA link names, and the unambiguous city portion of those links, to canonical zone names.\n")
+	fmt.Fprintf(&buf, "var tzAliases = map[string]string{\n")
+	writeMap(&buf, aliases)
+	fmt.Fprintf(&buf, "}\n\n")
+	fmt.Fprintf(&buf, "// tzLinkNames maps the Haystack names of link zones to their IANA link names.\n")
+	fmt.Fprintf(&buf, "var tzLinkNames = map[string]string{\n")
+	writeMap(&buf, linkNames)
+	fmt.Fprintf(&buf, "}\n")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(*out, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func inRegion(zone string) bool {
+	parts := strings.Split(zone, "/")
+	return len(parts) > 1 && regions[parts[0]]
+}
+
+func shortName(zone string) string {
+	parts := strings.Split(zone, "/")
+	return parts[len(parts)-1]
+}
+
+func writeMap(buf *bytes.Buffer, items map[string]string) {
+	keys := []string{}
+	for key := range items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(buf, "\t%q: %q,\n", key, items[key])
+	}
+}
@@ -0,0 +1,514 @@
+package watch
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+)
+
+// Source provides the current records of watched ids. It is implemented by store.MemStore and store.FileStore. The
+// row for an id that doesn't exist must have no `id` tag.
+type Source interface {
+	ReadByIds(ids []haystack.Ref) haystack.Grid
+}
+
+// ChangeSource reports the ids of records that change, like an entity store or a current value source. It is
+// implemented by store.MemStore and store.FileStore.
+type ChangeSource interface {
+	OnChange(listener func(ids []haystack.Ref))
+}
+
+// Clock provides the current time. Tests may use a fake clock to control lease expiry.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Limits restrict the watches of a user. A value of 0 is unlimited.
+type Limits struct {
+	MaxWatches int           // open watches per user
+	MaxIds     int           // ids per watch
+	MaxLease   time.Duration // longest lease that can be requested
+}
+
+// DefaultLease is the lease of a watch if none is requested
+const DefaultLease = time.Minute
+
+// Manager implements the 'watchSub', 'watchUnsub' and 'watchPoll' ops. Watches are owned by the user that created
+// them, and are closed once their lease passes without a subscribe or poll. Changes are reported to the Manager by the
+// sources it follows, or with Changed, and each watch returns the changed records it subscribes to on its next poll.
+//
+// A Manager is safe for concurrent use.
+type Manager struct {
+	lock          sync.Mutex
+	source        Source
+	clock         Clock
+	watches       map[string]*watch
+	byRec         map[string]map[string]*watch // rec id -> watch id -> watch
+	limits        map[string]Limits
+	defaultLimits Limits
+	stop          chan struct{}
+}
+
+type watch struct {
+	id      string
+	dis     string
+	user    string
+	lease   time.Duration
+	expires time.Time
+	ids     []haystack.Ref      // in subscription order
+	subbed  map[string]bool     // rec id -> true
+	changed map[string]struct{} // rec ids changed since the last poll
+	notify  []chan struct{}     // signalled when a subscribed rec changes
+}
+
+// NewManager creates a Manager that reads records from the source. If the source is also a ChangeSource, like a
+// store.MemStore, the Manager follows its changes.
+func NewManager(source Source) *Manager {
+	manager := &Manager{
+		source:  source,
+		clock:   realClock{},
+		watches: map[string]*watch{},
+		byRec:   map[string]map[string]*watch{},
+		limits:  map[string]Limits{},
+	}
+	if changes, ok := source.(ChangeSource); ok {
+		manager.Follow(changes)
+	}
+	return manager
+}
+
+// Follow reports the changes of the source to the Manager, such as the current values of points that are kept apart
+// from their records.
+func (manager *Manager) Follow(source ChangeSource) {
+	source.OnChange(func(ids []haystack.Ref) {
+		manager.Changed(ids...)
+	})
+}
+
+// SetClock sets the clock used for leases. The default is the system clock.
+func (manager *Manager) SetClock(clock Clock) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.clock = clock
+}
+
+// SetDefaultLimits sets the limits of users without their own.
+func (manager *Manager) SetDefaultLimits(limits Limits) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.defaultLimits = limits
+}
+
+// SetLimits sets the limits of a user.
+func (manager *Manager) SetLimits(user string, limits Limits) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	manager.limits[user] = limits
+}
+
+// Sub subscribes the watch to the ids, and returns the current records of the ids with the watch's `watchId` and
+// `lease` in the grid meta. If the watch id is empty, a new watch is created with the display name. If the lease is 0
+// or less, a new watch is given the DefaultLease and an existing watch keeps its lease. Subscribing renews the lease.
+func (manager *Manager) Sub(user string, watchId string, dis string, lease time.Duration, ids []haystack.Ref) (haystack.Grid, error) {
+	manager.lock.Lock()
+	limits := manager.userLimits(user)
+	if limits.MaxLease > 0 && lease > limits.MaxLease {
+		lease = limits.MaxLease
+	}
+
+	var w *watch
+	if watchId == "" {
+		if limits.MaxWatches > 0 && manager.userWatchCount(user) >= limits.MaxWatches {
+			manager.lock.Unlock()
+			return haystack.EmptyGrid(), NewLimitError(user, "watches", limits.MaxWatches)
+		}
+		if lease <= 0 {
+			lease = DefaultLease
+		}
+		w = &watch{
+			id:      "w-" + haystack.GenRef().Id(),
+			dis:     dis,
+			user:    user,
+			lease:   lease,
+			subbed:  map[string]bool{},
+			changed: map[string]struct{}{},
+		}
+	} else {
+		var err error
+		w, err = manager.get(user, watchId)
+		if err != nil {
+			manager.lock.Unlock()
+			return haystack.EmptyGrid(), err
+		}
+		if lease > 0 {
+			w.lease = lease
+		}
+	}
+
+	added := 0
+	for _, id := range ids {
+		if !w.subbed[id.Id()] {
+			added++
+		}
+	}
+	if limits.MaxIds > 0 && len(w.ids)+added > limits.MaxIds {
+		manager.lock.Unlock()
+		return haystack.EmptyGrid(), NewLimitError(user, "ids per watch", limits.MaxIds)
+	}
+
+	manager.watches[w.id] = w
+	for _, id := range ids {
+		if w.subbed[id.Id()] {
+			continue
+		}
+		w.subbed[id.Id()] = true
+		w.ids = append(w.ids, haystack.NewRef(id.Id(), ""))
+		watches, ok := manager.byRec[id.Id()]
+		if !ok {
+			watches = map[string]*watch{}
+			manager.byRec[id.Id()] = watches
+		}
+		watches[w.id] = w
+	}
+	w.expires = manager.clock.Now().Add(w.lease)
+	meta := w.meta()
+	manager.lock.Unlock()
+
+	return manager.read(ids, meta), nil
+}
+
+// Unsub removes the ids from the watch. If close is true, the watch is closed instead.
+func (manager *Manager) Unsub(user string, watchId string, ids []haystack.Ref, close bool) error {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	w, err := manager.get(user, watchId)
+	if err != nil {
+		return err
+	}
+	if close {
+		manager.close(w)
+		return nil
+	}
+	for _, id := range ids {
+		manager.unsubRec(w, id.Id())
+	}
+	return nil
+}
+
+// Poll returns the records that the watch subscribes to that have changed since the last poll, or all of them if
+// refresh is true. Records that no longer exist are returned with only their `id` and a `removed` marker. Polling
+// renews the lease.
+func (manager *Manager) Poll(user string, watchId string, refresh bool) (haystack.Grid, error) {
+	manager.lock.Lock()
+	w, err := manager.get(user, watchId)
+	if err != nil {
+		manager.lock.Unlock()
+		return haystack.EmptyGrid(), err
+	}
+	ids := []haystack.Ref{}
+	for _, id := range w.ids {
+		if _, changed := w.changed[id.Id()]; refresh || changed {
+			ids = append(ids, id)
+		}
+	}
+	w.changed = map[string]struct{}{}
+	w.expires = manager.clock.Now().Add(w.lease)
+	meta := w.meta()
+	manager.lock.Unlock()
+
+	return manager.read(ids, meta), nil
+}
+
+// Changed reports that the records have changed, so that the watches that subscribe to them return them on their
+// next poll.
+func (manager *Manager) Changed(ids ...haystack.Ref) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	for _, id := range ids {
+		for _, w := range manager.byRec[id.Id()] {
+			w.changed[id.Id()] = struct{}{}
+			for _, ch := range w.notify {
+				select {
+				case ch <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// Notify returns a channel that receives a value when a record of the user's watch changes, so that changes can be
+// pushed instead of waiting for a poll. Notifications are merged until they are received. The channel is closed when
+// the watch is closed, and the returned function stops the notifications.
+func (manager *Manager) Notify(user string, watchId string) (<-chan struct{}, func(), error) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	w, err := manager.get(user, watchId)
+	if err != nil {
+		return nil, nil, err
+	}
+	ch := make(chan struct{}, 1)
+	w.notify = append(w.notify, ch)
+	cancel := func() {
+		manager.lock.Lock()
+		defer manager.lock.Unlock()
+		for i, other := range w.notify {
+			if other == ch {
+				w.notify = append(w.notify[:i], w.notify[i+1:]...)
+				close(ch)
+				return
+			}
+		}
+	}
+	return ch, cancel, nil
+}
+
+// Sweep closes the watches whose lease has passed, and returns how many were closed.
+func (manager *Manager) Sweep() int {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	now := manager.clock.Now()
+	closed := 0
+	for _, w := range manager.watches {
+		if now.After(w.expires) {
+			manager.close(w)
+			closed++
+		}
+	}
+	return closed
+}
+
+// Start sweeps expired watches on a background goroutine at the interval, until Stop is called.
+func (manager *Manager) Start(interval time.Duration) {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	if manager.stop != nil {
+		return
+	}
+	stop := make(chan struct{})
+	manager.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				manager.Sweep()
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background sweeping started by Start.
+func (manager *Manager) Stop() {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	if manager.stop != nil {
+		close(manager.stop)
+		manager.stop = nil
+	}
+}
+
+// WatchIds returns the ids of the user's open watches, sorted.
+func (manager *Manager) WatchIds(user string) []string {
+	manager.lock.Lock()
+	defer manager.lock.Unlock()
+	ids := []string{}
+	for _, w := range manager.watches {
+		if w.user == user {
+			ids = append(ids, w.id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// HandleSub handles a 'watchSub' request grid. The meta has either a `watchDis` to create a watch or a `watchId` to
+// add to one, and an optional `lease` Number. The rows have the ids in an `id` or `ids` column.
+func (manager *Manager) HandleSub(user string, req haystack.Grid) (haystack.Grid, error) {
+	meta := req.Meta()
+	watchId, dis := "", ""
+	if meta.Has("watchId") {
+		str, err := meta.GetStr("watchId")
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		watchId = str.String()
+	} else {
+		str, err := meta.GetStr("watchDis")
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+		dis = str.String()
+	}
+	var lease time.Duration
+	if meta.Has("lease") {
+		var err error
+		lease, err = meta.GetDuration("lease")
+		if err != nil {
+			return haystack.EmptyGrid(), err
+		}
+	}
+	ids, err := reqIds(req)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return manager.Sub(user, watchId, dis, lease, ids)
+}
+
+// HandleUnsub handles a 'watchUnsub' request grid. The meta has the `watchId` and a `close` marker to close the
+// watch; otherwise the ids in the rows are removed from it. An empty grid is returned.
+func (manager *Manager) HandleUnsub(user string, req haystack.Grid) (haystack.Grid, error) {
+	watchId, err := req.Meta().GetStr("watchId")
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	ids, err := reqIds(req)
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	err = manager.Unsub(user, watchId.String(), ids, req.Meta().Has("close"))
+	return haystack.EmptyGrid(), err
+}
+
+// HandlePoll handles a 'watchPoll' request grid. The meta has the `watchId` and an optional `refresh` marker.
+func (manager *Manager) HandlePoll(user string, req haystack.Grid) (haystack.Grid, error) {
+	watchId, err := req.Meta().GetStr("watchId")
+	if err != nil {
+		return haystack.EmptyGrid(), err
+	}
+	return manager.Poll(user, watchId.String(), req.Meta().Has("refresh"))
+}
+
+// userLimits returns the limits of the user. The caller must hold the lock.
+func (manager *Manager) userLimits(user string) Limits {
+	if limits, ok := manager.limits[user]; ok {
+		return limits
+	}
+	return manager.defaultLimits
+}
+
+// userWatchCount returns the number of open watches of the user. The caller must hold the lock.
+func (manager *Manager) userWatchCount(user string) int {
+	count := 0
+	for _, w := range manager.watches {
+		if w.user == user {
+			count++
+		}
+	}
+	return count
+}
+
+// get returns the user's open watch. The caller must hold the lock.
+func (manager *Manager) get(user string, watchId string) (*watch, error) {
+	w, ok := manager.watches[watchId]
+	if !ok || w.user != user || manager.clock.Now().After(w.expires) {
+		return nil, NewUnknownWatchError(watchId)
+	}
+	return w, nil
+}
+
+// close removes the watch. The caller must hold the lock.
+func (manager *Manager) close(w *watch) {
+	// unsubRec removes from w.ids, so iterate over a copy
+	for _, id := range append([]haystack.Ref{}, w.ids...) {
+		manager.unsubRec(w, id.Id())
+	}
+	for _, ch := range w.notify {
+		close(ch)
+	}
+	w.notify = nil
+	delete(manager.watches, w.id)
+}
+
+// unsubRec removes a record from the watch. The caller must hold the lock.
+func (manager *Manager) unsubRec(w *watch, id string) {
+	if !w.subbed[id] {
+		return
+	}
+	delete(w.subbed, id)
+	delete(w.changed, id)
+	for i, ref := range w.ids {
+		if ref.Id() == id {
+			w.ids = append(w.ids[:i], w.ids[i+1:]...)
+			break
+		}
+	}
+	watches := manager.byRec[id]
+	delete(watches, w.id)
+	if len(watches) == 0 {
+		delete(manager.byRec, id)
+	}
+}
+
+func (w *watch) meta() haystack.Dict {
+	return haystack.NewDict(map[string]haystack.Val{
+		"watchId": haystack.NewStr(w.id),
+		"lease":   haystack.NewNumberFromDuration(w.lease),
+	})
+}
+
+// read returns the current records of the ids from the source, with the meta
+func (manager *Manager) read(ids []haystack.Ref, meta haystack.Dict) haystack.Grid {
+	recs := []haystack.Dict{}
+	if len(ids) > 0 {
+		rows := manager.source.ReadByIds(ids).Rows()
+		for i, id := range ids {
+			var rec haystack.Dict
+			if i < len(rows) {
+				rec = rows[i].ToDict()
+			}
+			if _, err := rec.GetRef("id"); err != nil {
+				rec = haystack.NewDict(map[string]haystack.Val{"id": id, "removed": haystack.NewMarker()})
+			}
+			recs = append(recs, haystack.EmptyDict().Patch(rec))
+		}
+	}
+
+	names := map[string]bool{"id": true}
+	for _, rec := range recs {
+		for _, name := range rec.Names() {
+			names[name] = true
+		}
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaDict(meta)
+	for _, name := range sorted {
+		gb.AddColNoMeta(name)
+	}
+	gb.AddRowDicts(recs)
+	return gb.ToGrid()
+}
+
+func reqIds(req haystack.Grid) ([]haystack.Ref, error) {
+	col := "id"
+	if req.Col("id") == nil && req.Col("ids") != nil {
+		col = "ids"
+	}
+	ids := []haystack.Ref{}
+	for _, row := range req.Rows() {
+		ref, ok := row.Get(col).(haystack.Ref)
+		if !ok {
+			return nil, errors.New("watch request row has no ref in '" + col + "'")
+		}
+		ids = append(ids, ref)
+	}
+	return ids, nil
+}
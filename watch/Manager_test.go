@@ -0,0 +1,255 @@
+package watch
+
+import (
+	"testing"
+	"time"
+
+	"github.com/NeedleInAJayStack/haystack"
+	"github.com/NeedleInAJayStack/haystack/store"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (clock *fakeClock) Now() time.Time {
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(duration time.Duration) {
+	clock.now = clock.now.Add(duration)
+}
+
+func newTestManager(t *testing.T) (*Manager, *store.MemStore, *fakeClock) {
+	recs := store.NewMemStore()
+	assert.Nil(t, recs.LoadZinc(`ver:"3.0"
+id,dis,curVal
+@a,"A",1
+@b,"B",2
+@c,"C",3
+`))
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	manager := NewManager(recs)
+	manager.SetClock(clock)
+	return manager, recs, clock
+}
+
+func refs(ids ...string) []haystack.Ref {
+	result := []haystack.Ref{}
+	for _, id := range ids {
+		result = append(result, haystack.NewRef(id, ""))
+	}
+	return result
+}
+
+func gridIds(grid haystack.Grid) []string {
+	result := []string{}
+	for _, row := range grid.Rows() {
+		result = append(result, row.Get("id").(haystack.Ref).Id())
+	}
+	return result
+}
+
+func TestManager_Poll(t *testing.T) {
+	manager, recs, _ := newTestManager(t)
+	grid, err := manager.Sub("joe", "", "test", 0, refs("a", "b"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, gridIds(grid))
+	assert.Equal(t, haystack.NewNumber(1, "min"), grid.Meta().Get("lease"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+
+	grid, err = manager.Poll("joe", watchId, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, grid.RowCount())
+	assert.Equal(t, haystack.NewStr(watchId), grid.Meta().Get("watchId"))
+
+	_, err = recs.Update(haystack.NewRef("b", ""), haystack.NewDict(map[string]haystack.Val{"curVal": haystack.NewNumber(20, "")}))
+	assert.Nil(t, err)
+	manager.Changed(refs("b", "c")...)
+	grid, err = manager.Poll("joe", watchId, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, gridIds(grid))
+	assert.Equal(t, haystack.NewNumber(20, ""), grid.RowAt(0).Get("curVal"))
+
+	grid, err = manager.Poll("joe", watchId, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, grid.RowCount())
+
+	grid, err = manager.Poll("joe", watchId, true)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a", "b"}, gridIds(grid))
+
+	assert.Nil(t, recs.Remove(haystack.NewRef("a", "")))
+	manager.Changed(refs("a")...)
+	grid, err = manager.Poll("joe", watchId, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, gridIds(grid))
+	assert.Equal(t, haystack.NewMarker(), grid.RowAt(0).Get("removed"))
+
+	_, err = manager.Poll("jane", watchId, false)
+	assert.Equal(t, NewUnknownWatchError(watchId), err)
+}
+
+func TestManager_Unsub(t *testing.T) {
+	manager, _, _ := newTestManager(t)
+	grid, _ := manager.Sub("joe", "", "test", 0, refs("a", "b"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+	grid, err := manager.Sub("joe", watchId, "", 0, refs("b", "c"))
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b", "c"}, gridIds(grid))
+
+	assert.Nil(t, manager.Unsub("joe", watchId, refs("a"), false))
+	manager.Changed(refs("a", "c")...)
+	grid, _ = manager.Poll("joe", watchId, false)
+	assert.Equal(t, []string{"c"}, gridIds(grid))
+
+	assert.Nil(t, manager.Unsub("joe", watchId, nil, true))
+	_, err = manager.Poll("joe", watchId, false)
+	assert.NotNil(t, err)
+	assert.Empty(t, manager.WatchIds("joe"))
+}
+
+func TestManager_Unsub_close(t *testing.T) {
+	manager, _, _ := newTestManager(t)
+	grid, _ := manager.Sub("joe", "", "test", 0, refs("a", "b", "c"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+	grid, _ = manager.Sub("jane", "", "other", 0, refs("b"))
+	otherId := grid.Meta().Get("watchId").(haystack.Str).String()
+
+	assert.Nil(t, manager.Unsub("joe", watchId, nil, true))
+	assert.Equal(t, 1, len(manager.byRec))
+	assert.Equal(t, 1, len(manager.byRec["b"]))
+	manager.Changed(refs("a", "b", "c")...)
+	grid, err := manager.Poll("jane", otherId, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"b"}, gridIds(grid))
+
+	assert.Nil(t, manager.Unsub("jane", otherId, nil, true))
+	assert.Empty(t, manager.byRec)
+}
+
+func TestManager_Follow(t *testing.T) {
+	manager, recs, _ := newTestManager(t)
+	grid, _ := manager.Sub("joe", "", "test", 0, refs("a", "b"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+
+	// The store is followed without calling Changed
+	_, err := recs.Update(haystack.NewRef("a", ""), haystack.NewDict(map[string]haystack.Val{"curVal": haystack.NewNumber(10, "")}))
+	assert.Nil(t, err)
+	grid, _ = manager.Poll("joe", watchId, false)
+	assert.Equal(t, []string{"a"}, gridIds(grid))
+	assert.Equal(t, haystack.NewNumber(10, ""), grid.RowAt(0).Get("curVal"))
+
+	// Other sources can be followed too
+	curVals := store.NewMemStore()
+	manager.Follow(curVals)
+	assert.Nil(t, curVals.Add(haystack.NewDict(map[string]haystack.Val{"id": haystack.NewRef("b", "")})))
+	grid, _ = manager.Poll("joe", watchId, false)
+	assert.Equal(t, []string{"b"}, gridIds(grid))
+}
+
+func TestManager_Notify(t *testing.T) {
+	manager, _, _ := newTestManager(t)
+	grid, _ := manager.Sub("joe", "", "test", 0, refs("a", "b"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+	_, _, err := manager.Notify("jane", watchId)
+	assert.NotNil(t, err)
+
+	notify, cancel, err := manager.Notify("joe", watchId)
+	assert.Nil(t, err)
+	manager.Changed(refs("c")...)
+	assert.Equal(t, 0, len(notify))
+	manager.Changed(refs("a")...)
+	manager.Changed(refs("b")...)
+	assert.Equal(t, 1, len(notify))
+	<-notify
+	cancel()
+	_, open := <-notify
+	assert.False(t, open)
+
+	notify, _, err = manager.Notify("joe", watchId)
+	assert.Nil(t, err)
+	assert.Nil(t, manager.Unsub("joe", watchId, nil, true))
+	_, open = <-notify
+	assert.False(t, open)
+}
+
+func TestManager_Lease(t *testing.T) {
+	manager, _, clock := newTestManager(t)
+	grid, _ := manager.Sub("joe", "", "short", 10*time.Second, refs("a"))
+	short := grid.Meta().Get("watchId").(haystack.Str).String()
+	grid, _ = manager.Sub("joe", "", "long", time.Hour, refs("a"))
+	long := grid.Meta().Get("watchId").(haystack.Str).String()
+
+	clock.Advance(8 * time.Second)
+	_, err := manager.Poll("joe", short, false)
+	assert.Nil(t, err)
+	clock.Advance(8 * time.Second)
+	assert.Equal(t, 0, manager.Sweep())
+
+	clock.Advance(3 * time.Second)
+	_, err = manager.Poll("joe", short, false)
+	assert.Equal(t, NewUnknownWatchError(short), err)
+	assert.Equal(t, 1, manager.Sweep())
+	assert.Equal(t, []string{long}, manager.WatchIds("joe"))
+}
+
+func TestManager_Limits(t *testing.T) {
+	manager, _, _ := newTestManager(t)
+	manager.SetDefaultLimits(Limits{MaxWatches: 1, MaxIds: 2, MaxLease: time.Minute})
+	manager.SetLimits("admin", Limits{})
+
+	grid, err := manager.Sub("joe", "", "test", time.Hour, refs("a", "b"))
+	assert.Nil(t, err)
+	assert.Equal(t, haystack.NewNumber(1, "min"), grid.Meta().Get("lease"))
+	watchId := grid.Meta().Get("watchId").(haystack.Str).String()
+
+	_, err = manager.Sub("joe", watchId, "", 0, refs("c"))
+	assert.Equal(t, NewLimitError("joe", "ids per watch", 2), err)
+	_, err = manager.Sub("joe", watchId, "", 0, refs("a"))
+	assert.Nil(t, err)
+	_, err = manager.Sub("joe", "", "second", 0, refs("c"))
+	assert.Equal(t, NewLimitError("joe", "watches", 1), err)
+
+	_, err = manager.Sub("admin", "", "first", 0, refs("a", "b", "c"))
+	assert.Nil(t, err)
+	_, err = manager.Sub("admin", "", "second", 0, refs("c"))
+	assert.Nil(t, err)
+}
+
+func TestManager_Handle(t *testing.T) {
+	manager, _, _ := newTestManager(t)
+	gb := haystack.NewGridBuilder()
+	gb.AddMetaVal("watchDis", haystack.NewStr("test"))
+	gb.AddMetaVal("lease", haystack.NewNumber(30, "s"))
+	gb.AddColNoMeta("ids")
+	gb.AddRow([]haystack.Val{haystack.NewRef("a", "")})
+	grid, err := manager.HandleSub("joe", gb.ToGrid())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, gridIds(grid))
+	assert.Equal(t, haystack.NewNumber(30, "s"), grid.Meta().Get("lease"))
+
+	manager.Changed(refs("a")...)
+	gb = haystack.NewGridBuilder()
+	gb.AddMetaVal("watchId", grid.Meta().Get("watchId"))
+	grid, err = manager.HandlePoll("joe", gb.ToGrid())
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"a"}, gridIds(grid))
+
+	gb.AddMetaVal("close", haystack.NewMarker())
+	_, err = manager.HandleUnsub("joe", gb.ToGrid())
+	assert.Nil(t, err)
+	assert.Empty(t, manager.WatchIds("joe"))
+}
+
+func TestManager_Start(t *testing.T) {
+	manager, _, clock := newTestManager(t)
+	manager.Sub("joe", "", "test", time.Second, refs("a"))
+	clock.Advance(time.Minute)
+	manager.Start(time.Millisecond)
+	defer manager.Stop()
+	assert.Eventually(t, func() bool {
+		return len(manager.WatchIds("joe")) == 0
+	}, time.Second, time.Millisecond)
+}
@@ -0,0 +1,41 @@
+package watch
+
+import "strconv"
+
+// UnknownWatchError occurs when a watch doesn't exist, has expired, or belongs to another user.
+type UnknownWatchError struct {
+	Id string
+}
+
+// NewUnknownWatchError creates a new UnknownWatchError object.
+func NewUnknownWatchError(id string) UnknownWatchError {
+	return UnknownWatchError{Id: id}
+}
+
+func (err UnknownWatchError) Error() string {
+	return "Unknown watch: " + err.Id
+}
+
+// UnknownWatchErrType is the `errType` of the err grids of UnknownWatchErrors, so that clients can subscribe again.
+const UnknownWatchErrType = "UnknownWatchErr"
+
+// ErrType returns UnknownWatchErrType, for server.ErrGrid.
+func (err UnknownWatchError) ErrType() string {
+	return UnknownWatchErrType
+}
+
+// LimitError occurs when a subscription would exceed a user's Limits.
+type LimitError struct {
+	User  string
+	Limit string
+	Max   int
+}
+
+// NewLimitError creates a new LimitError object.
+func NewLimitError(user string, limit string, max int) LimitError {
+	return LimitError{User: user, Limit: limit, Max: max}
+}
+
+func (err LimitError) Error() string {
+	return "Watch limit exceeded for " + err.User + ": at most " + strconv.Itoa(err.Max) + " " + err.Limit
+}